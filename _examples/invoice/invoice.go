@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 
 	kit "github.com/DomeLiquid/mixin-kit-go"
@@ -8,10 +9,22 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+var (
+	recipient = flag.String("recipient", "", "recipient mixin user id")
+)
+
+/*
+go run . --recipient <user id>
+*/
 func main() {
-	iw := kit.NewMixinInvoiceUserId("")
+	flag.Parse()
+
+	iw, err := kit.NewMixinInvoiceUserIdE(*recipient)
+	if err != nil {
+		panic(err)
+	}
 	traceId := mixin.RandomTraceID()
-	err := iw.AddEntryIndex(traceId, "64692c23-8971-4cf4-84a7-4dd1271dd887", decimal.NewFromFloat(0.01), "test memo1", nil)
+	err = iw.AddEntryIndex(traceId, "64692c23-8971-4cf4-84a7-4dd1271dd887", decimal.NewFromFloat(0.01), "test memo1", nil)
 	if err != nil {
 		panic(err)
 	}
@@ -25,5 +38,5 @@ func main() {
 	// _ = iw.AddEntryIndex(mixin.RandomTraceID(), "43d61dcd-e413-450d-80b8-101d5e903357", decimal.NewFromFloat(0.01), "test memo4", []uint8{2})
 	_ = iw.AddEntryIndex(mixin.RandomTraceID(), "723ef46d-cd07-38af-bc40-988940bbc532", decimal.NewFromFloat(0.01), "test memo4", []uint8{2})
 
-	fmt.Printf("https://mixin.one/pay/%s\n\n", iw.String())
+	fmt.Printf("%s\n\n", iw.PaymentURL())
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+)
+
+var (
+	config         = flag.String("config", "", "encrypted keystore file path")
+	spendPublicKey = flag.String("spend-public-key", "", "spend public key of the bot")
+	network        = flag.String("network", "unix", "unix or tcp")
+	address        = flag.String("address", "/tmp/mixin-signer.sock", "unix socket path or host:port")
+)
+
+/*
+独立的签名进程, spend key 只存在于这个进程中. 口令和访问 token 从环境变量读取:
+
+	MIXIN_KEYSTORE_PASSPHRASE=... MIXIN_SIGNER_TOKEN=... ./signer --config ../keystore.enc.json --spend-public-key ...
+
+业务进程使用
+
+	kit.NewMixinClientWrapper(config, kit.WithTransactionSigner(
+		kit.NewRemoteSigner("unix", "/tmp/mixin-signer.sock", kit.WithSignerToken(token)),
+	))
+*/
+func main() {
+	flag.Parse()
+
+	// 没有 token 时 NewSignerServer 返回 kit.ErrSignerNoToken
+	token := os.Getenv("MIXIN_SIGNER_TOKEN")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ks, err := kit.LoadEncryptedKeystore(*config)
+	if err != nil {
+		log.Panicln(err)
+	}
+	spendKey, err := ks.DecryptSpendKey([]byte(os.Getenv("MIXIN_KEYSTORE_PASSPHRASE")), *spendPublicKey)
+	if err != nil {
+		log.Panicln(err)
+	}
+	defer kit.ZeroKey(&spendKey)
+
+	l, err := kit.ListenSigner(*network, *address)
+	if err != nil {
+		log.Panicln(err)
+	}
+
+	handler, err := kit.NewSignerServer(kit.SpendKeySigner{Key: spendKey},
+		kit.WithSignerServerToken(token),
+		kit.WithSignHook(func(ctx context.Context, tx *mixinnet.Transaction) error {
+			log.Printf("sign transaction: %d inputs, %d outputs\n", len(tx.Inputs), len(tx.Outputs))
+			return nil
+		}),
+	)
+	if err != nil {
+		log.Panicln(err)
+	}
+	server := &http.Server{Handler: handler}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("signer listening on %s %s\n", *network, *address)
+	if err := server.Serve(l); err != nil && err != http.ErrServerClosed {
+		log.Panicln(err)
+	}
+}
@@ -2,10 +2,8 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"log"
-	"os"
 
 	kit "github.com/DomeLiquid/mixin-kit-go"
 	"github.com/fox-one/mixin-sdk-go/v2"
@@ -29,17 +27,15 @@ Test Tx: https://mixin.space/tx/7b517a40d0ab82524b1fbb87693fdfc3399e28c61dc9222e
 func main() {
 	flag.Parse()
 
-	f, err := os.Open(*config)
+	config, err := kit.LoadConfig(context.Background(),
+		kit.WithConfigFile(*config),
+		kit.WithSecretProvider(kit.EnvSecretProvider{Prefix: kit.DefaultConfigEnvPrefix}),
+	)
 	if err != nil {
 		log.Panicln(err)
 	}
 
-	var config kit.Config
-	if err := json.NewDecoder(f).Decode(&config); err != nil {
-		log.Panicln(err)
-	}
-
-	kitCli, err := kit.NewMixinClientWrapper(&config)
+	kitCli, err := kit.NewMixinClientWrapper(config)
 	if err != nil {
 		log.Panicf("init client wrapper error: %+v \n", err)
 	}
@@ -2,10 +2,8 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"flag"
 	"log"
-	"os"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -23,17 +21,15 @@ CGO_ENABLED=0  go build -o web3 && ./web3 --config ../config_debug.json
 func main() {
 	flag.Parse()
 
-	f, err := os.Open(*config)
+	config, err := kit.LoadConfig(context.Background(),
+		kit.WithConfigFile(*config),
+		kit.WithSecretProvider(kit.EnvSecretProvider{Prefix: kit.DefaultConfigEnvPrefix}),
+	)
 	if err != nil {
 		log.Panicln(err)
 	}
 
-	var config kit.Config
-	if err := json.NewDecoder(f).Decode(&config); err != nil {
-		log.Panicln(err)
-	}
-
-	kitCli, err := kit.NewMixinClientWrapper(&config)
+	kitCli, err := kit.NewMixinClientWrapper(config)
 	if err != nil {
 		log.Panicf("init client wrapper error: %+v \n", err)
 	}
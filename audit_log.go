@@ -0,0 +1,399 @@
+package kit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+var ErrAuditTampered = errors.New("audit log tampered")
+
+// AuditEvent 审计记录的类型, 每笔交易在签名前记录 sign, 提交后记录 submitted 或 failed
+type AuditEvent string
+
+const (
+	AuditEventSign      AuditEvent = "sign"
+	AuditEventSubmitted AuditEvent = "submitted"
+	AuditEventFailed    AuditEvent = "failed"
+)
+
+type AuditInput struct {
+	TransactionHash string          `json:"transaction_hash"`
+	OutputIndex     uint8           `json:"output_index"`
+	Amount          decimal.Decimal `json:"amount"`
+}
+
+type AuditOutput struct {
+	Members   []string        `json:"members"`
+	Threshold uint8           `json:"threshold"`
+	Amount    decimal.Decimal `json:"amount"`
+}
+
+// AuditEntry 审计日志的一条记录, Hash = sha256(json(记录, hash 为空)), 其中包含上一条的 Hash
+type AuditEntry struct {
+	Seq      uint64    `json:"seq"`
+	Time     time.Time `json:"time"`
+	PrevHash string    `json:"prev_hash"`
+	Hash     string    `json:"hash"`
+
+	Event           AuditEvent    `json:"event"`
+	AppID           string        `json:"app_id"`
+	Initiator       string        `json:"initiator,omitempty"`
+	Reason          string        `json:"reason,omitempty"`
+	RequestId       string        `json:"request_id"`
+	AssetId         string        `json:"asset_id"`
+	Memo            string        `json:"memo,omitempty"`
+	Inputs          []AuditInput  `json:"inputs"`
+	Outputs         []AuditOutput `json:"outputs"` // 不含找零
+	TransactionHash string        `json:"transaction_hash,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+func (e *AuditEntry) computeHash() (string, error) {
+	c := *e
+	c.Hash = ""
+	b, err := json.Marshal(&c)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type auditContextKey struct{}
+
+type auditContext struct {
+	initiator string
+	reason    string
+}
+
+// ContextWithAudit 记录发起转账的调用方和原因, 写入该 ctx 下所有交易的审计记录
+func ContextWithAudit(ctx context.Context, initiator, reason string) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, auditContext{initiator: initiator, reason: reason})
+}
+
+// AuditSink 审计记录的输出
+type AuditSink interface {
+	WriteAudit(ctx context.Context, entry *AuditEntry) error
+}
+
+// AuditHeadSink 知道已写入的最后一条记录的输出, 如 FileAuditSink; NewAuditLog 从它接续哈希链
+type AuditHeadSink interface {
+	AuditSink
+	Last() *AuditEntry
+}
+
+// AuditSinkFunc 函数形式的 AuditSink
+type AuditSinkFunc func(ctx context.Context, entry *AuditEntry) error
+
+func (f AuditSinkFunc) WriteAudit(ctx context.Context, entry *AuditEntry) error {
+	return f(ctx, entry)
+}
+
+// SlogAuditSink 将审计记录写入 logger
+func SlogAuditSink(logger *slog.Logger) AuditSink {
+	return AuditSinkFunc(func(ctx context.Context, e *AuditEntry) error {
+		logger.InfoContext(ctx, "audit",
+			slog.Uint64("seq", e.Seq),
+			slog.String("event", string(e.Event)),
+			slog.String("request_id", e.RequestId),
+			slog.String("asset_id", e.AssetId),
+			slog.String("initiator", e.Initiator),
+			slog.String("reason", e.Reason),
+			slog.String("transaction_hash", e.TransactionHash),
+			slog.String("error", e.Error),
+			slog.String("hash", e.Hash),
+		)
+		return nil
+	})
+}
+
+// FileAuditSink 以 JSON Lines 追加写入文件, 每条记录写入后 fsync; 写入或 fsync 失败时截断回写入前的长度
+type FileAuditSink struct {
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	last   *AuditEntry
+	broken error // 截断失败后文件状态未知, 不再写入
+}
+
+// OpenFileAuditSink 打开 path 用于追加, 已有的记录会先校验, 见 VerifyAuditLog
+func OpenFileAuditSink(path string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	last, err := VerifyAuditLog(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &FileAuditSink{f: f, size: info.Size(), last: last}, nil
+}
+
+// Last 返回文件中的最后一条记录, 见 AuditHeadSink
+func (s *FileAuditSink) Last() *AuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.last
+}
+
+func (s *FileAuditSink) WriteAudit(ctx context.Context, entry *AuditEntry) error {
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.broken != nil {
+		return s.broken
+	}
+
+	_, err = s.f.Write(b)
+	if err == nil {
+		err = s.f.Sync()
+	}
+	if err != nil {
+		// 去掉可能已经写入的部分, 保证文件中只有完整且已落盘的记录
+		if terr := s.f.Truncate(s.size); terr != nil {
+			s.broken = fmt.Errorf("audit file in unknown state: %w", errors.Join(err, terr))
+			return s.broken
+		}
+		return err
+	}
+
+	s.size += int64(len(b))
+	last := *entry
+	s.last = &last
+	return nil
+}
+
+func (s *FileAuditSink) Close() error {
+	return s.f.Close()
+}
+
+// AuditLog 只追加的哈希链审计日志
+type AuditLog struct {
+	sinks []AuditSink
+	now   func() time.Time
+
+	mu       sync.Mutex
+	head     bool // 已通过 WithAuditHead 设置
+	seq      uint64
+	lastHash string
+	pending  [][]*AuditEntry // 每个输出写入失败, 等待补写的记录
+}
+
+// AuditLogOption 定义 AuditLog 选项
+type AuditLogOption func(*AuditLog)
+
+// WithAuditSink 添加审计记录的输出, 任一输出失败时 Append 返回错误, 见 AuditLog.Append
+func WithAuditSink(sink AuditSink) AuditLogOption {
+	return func(l *AuditLog) {
+		l.sinks = append(l.sinks, sink)
+	}
+}
+
+// WithAuditHead 从 last 之后继续哈希链, 用于不实现 AuditHeadSink 的输出; 设置后不再从输出读取
+func WithAuditHead(last *AuditEntry) AuditLogOption {
+	return func(l *AuditLog) {
+		l.head = true
+		if last != nil {
+			l.seq, l.lastHash = last.Seq, last.Hash
+		}
+	}
+}
+
+// NewAuditLog 没有 WithAuditHead 时, 从实现了 AuditHeadSink 的输出中序号最大的记录继续哈希链
+func NewAuditLog(opts ...AuditLogOption) *AuditLog {
+	l := &AuditLog{now: time.Now}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	if !l.head {
+		for _, sink := range l.sinks {
+			if hs, ok := sink.(AuditHeadSink); ok {
+				if last := hs.Last(); last != nil && last.Seq > l.seq {
+					l.seq, l.lastHash = last.Seq, last.Hash
+				}
+			}
+		}
+	}
+
+	l.pending = make([][]*AuditEntry, len(l.sinks))
+	return l
+}
+
+// Append 设置 entry 的序号, 时间和哈希后写入所有输出, 任一输出失败时返回错误.
+// 只要有一个输出写入成功哈希链就会推进, 失败的输出会在下次 Append 时先按顺序补写缺少的记录,
+// 因此每个输出中的序号都是连续的; 所有输出都失败时不推进
+func (l *AuditLog) Append(ctx context.Context, entry *AuditEntry) error {
+	if a, ok := ctx.Value(auditContextKey{}).(auditContext); ok {
+		if entry.Initiator == "" {
+			entry.Initiator = a.initiator
+		}
+		if entry.Reason == "" {
+			entry.Reason = a.reason
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Seq = l.seq + 1
+	entry.Time = l.now().UTC()
+	entry.PrevHash = l.lastHash
+	hash, err := entry.computeHash()
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+
+	record := *entry
+	written := len(l.sinks) == 0
+	pending := make([][]*AuditEntry, len(l.sinks))
+	var errs []error
+	for i, sink := range l.sinks {
+		queue := append(l.pending[i][:len(l.pending[i]):len(l.pending[i])], &record)
+		for len(queue) > 0 {
+			if err := sink.WriteAudit(ctx, queue[0]); err != nil {
+				errs = append(errs, fmt.Errorf("write audit seq %d: %w", queue[0].Seq, err))
+				break
+			}
+			if queue[0] == &record {
+				written = true
+			}
+			queue = queue[1:]
+		}
+		pending[i] = queue
+	}
+
+	if !written {
+		// 没有任何输出写入这条记录, 不推进, 从补写队列中去掉
+		for i, queue := range pending {
+			if n := len(queue); n > 0 && queue[n-1] == &record {
+				pending[i] = queue[:n-1]
+			}
+		}
+	} else {
+		l.seq, l.lastHash = entry.Seq, entry.Hash
+	}
+	l.pending = pending
+	return errors.Join(errs...)
+}
+
+// AuditTamperError 审计日志第 Seq 条记录校验失败
+type AuditTamperError struct {
+	Line   int
+	Seq    uint64
+	Reason string
+}
+
+func (e *AuditTamperError) Error() string {
+	return fmt.Sprintf("%s: line %d seq %d: %s", ErrAuditTampered, e.Line, e.Seq, e.Reason)
+}
+
+func (e *AuditTamperError) Is(target error) bool { return target == ErrAuditTampered }
+
+// VerifyAuditLog 逐行校验 JSON Lines 格式的审计日志: 第一条记录的序号为 1, 序号连续, 哈希正确, 且每条记录指向上一条.
+// 返回最后一条记录, 日志为空时返回 nil
+func VerifyAuditLog(r io.Reader) (*AuditEntry, error) {
+	return VerifyAuditLogFrom(r, nil)
+}
+
+// VerifyAuditLogFrom 校验从 head 之后开始的日志, 用于轮转后的分段: 第一条记录必须接在 head 之后.
+// head 为 nil 时与 VerifyAuditLog 相同. 日志为空时返回 head
+func VerifyAuditLogFrom(r io.Reader, head *AuditEntry) (*AuditEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	last := head
+	if last == nil {
+		// 日志开头之前的虚拟记录
+		last = &AuditEntry{}
+	}
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, &AuditTamperError{Line: line, Reason: "invalid json: " + err.Error()}
+		}
+
+		switch {
+		case entry.Seq != last.Seq+1:
+			return nil, &AuditTamperError{Line: line, Seq: entry.Seq, Reason: fmt.Sprintf("expect seq %d", last.Seq+1)}
+		case entry.PrevHash != last.Hash:
+			return nil, &AuditTamperError{Line: line, Seq: entry.Seq, Reason: "prev_hash not matched"}
+		}
+
+		hash, err := entry.computeHash()
+		if err != nil {
+			return nil, err
+		}
+		if hash != entry.Hash {
+			return nil, &AuditTamperError{Line: line, Seq: entry.Seq, Reason: "hash not matched"}
+		}
+		last = &entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if last.Seq == 0 {
+		return nil, nil
+	}
+	return last, nil
+}
+
+func newAuditEntry(appId, requestId, assetId, memo string, utxos []*mixin.SafeUtxo, outputs []*mixin.TransactionOutput) *AuditEntry {
+	entry := &AuditEntry{
+		AppID:     appId,
+		RequestId: requestId,
+		AssetId:   assetId,
+		Memo:      memo,
+		Inputs:    make([]AuditInput, len(utxos)),
+		Outputs:   make([]AuditOutput, len(outputs)),
+	}
+	for i, utxo := range utxos {
+		entry.Inputs[i] = AuditInput{
+			TransactionHash: utxo.TransactionHash.String(),
+			OutputIndex:     utxo.OutputIndex,
+			Amount:          utxo.Amount,
+		}
+	}
+	for i, out := range outputs {
+		entry.Outputs[i] = AuditOutput{
+			Members:   out.Address.Members(),
+			Threshold: out.Address.Threshold,
+			Amount:    out.Amount,
+		}
+	}
+	return entry
+}
@@ -0,0 +1,54 @@
+package kit_test
+
+import (
+	"context"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+func TestClientWrapper_AuditLog(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+
+	var entries []kit.AuditEntry
+	log := kit.NewAuditLog(kit.WithAuditSink(kit.AuditSinkFunc(func(ctx context.Context, entry *kit.AuditEntry) error {
+		entries = append(entries, *entry)
+		return nil
+	})))
+	client := server.NewClientWrapper(t, kit.WithAuditLog(log))
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+
+	requestId := mixin.RandomTraceID()
+	ctx := kit.ContextWithAudit(context.Background(), "ops", "weekly payout")
+	if _, err := client.TransferOne(ctx, &kit.TransferOneRequest{
+		RequestId: requestId,
+		AssetId:   testPoolAsset,
+		Member:    testPoolRecipient,
+		Amount:    decimal.NewFromInt(4),
+		Memo:      "payout",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want 2", len(entries))
+	}
+	sign, submitted := entries[0], entries[1]
+	if sign.Event != kit.AuditEventSign || submitted.Event != kit.AuditEventSubmitted {
+		t.Errorf("events = %s, %s", sign.Event, submitted.Event)
+	}
+	if submitted.PrevHash != sign.Hash {
+		t.Errorf("submitted.PrevHash = %s, want %s", submitted.PrevHash, sign.Hash)
+	}
+	for _, e := range entries {
+		if e.RequestId != requestId || e.Initiator != "ops" || e.Reason != "weekly payout" || e.Memo != "payout" || e.TransactionHash == "" {
+			t.Errorf("entry = %+v", e)
+		}
+		if len(e.Inputs) != 1 || len(e.Outputs) != 1 || e.Outputs[0].Members[0] != testPoolRecipient || !e.Outputs[0].Amount.Equal(decimal.NewFromInt(4)) {
+			t.Errorf("entry inputs = %+v, outputs = %+v", e.Inputs, e.Outputs)
+		}
+	}
+}
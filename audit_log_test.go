@@ -0,0 +1,250 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAuditLog_FileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	ctx := ContextWithAudit(context.Background(), "alice", "payout")
+
+	sink, err := OpenFileAuditSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := NewAuditLog(WithAuditSink(sink))
+	for _, event := range []AuditEvent{AuditEventSign, AuditEventSubmitted} {
+		if err := log.Append(ctx, &AuditEntry{Event: event, RequestId: "1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sink.Close()
+
+	// 重新打开后接续哈希链
+	sink, err = OpenFileAuditSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := sink.Last(); last == nil || last.Seq != 2 || last.Initiator != "alice" || last.Reason != "payout" {
+		t.Fatalf("Last() = %+v", last)
+	}
+	// 从 sink 读取哈希链的头
+	log = NewAuditLog(WithAuditSink(sink))
+	if err := log.Append(ctx, &AuditEntry{Event: AuditEventSign, RequestId: "2"}); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	last, err := VerifyAuditLog(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Seq != 3 {
+		t.Errorf("VerifyAuditLog() last seq = %d, want 3", last.Seq)
+	}
+}
+
+func TestVerifyAuditLog_Tampered(t *testing.T) {
+	var lines []string
+	log := NewAuditLog(WithAuditSink(AuditSinkFunc(func(ctx context.Context, entry *AuditEntry) error {
+		b, err := json.Marshal(entry)
+		lines = append(lines, string(b))
+		return err
+	})))
+	for _, requestId := range []string{"1", "2", "3"} {
+		if err := log.Append(context.Background(), &AuditEntry{Event: AuditEventSign, RequestId: requestId, Memo: "memo"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		lines  func() []string
+		wantOK bool
+	}{
+		{
+			name:   "intact",
+			lines:  func() []string { return lines },
+			wantOK: true,
+		},
+		{
+			name: "modified",
+			lines: func() []string {
+				return []string{lines[0], strings.Replace(lines[1], `"memo":"memo"`, `"memo":"evil"`, 1), lines[2]}
+			},
+		},
+		{
+			name:  "deleted",
+			lines: func() []string { return []string{lines[0], lines[2]} },
+		},
+		{
+			name:  "reordered",
+			lines: func() []string { return []string{lines[0], lines[2], lines[1]} },
+		},
+		{
+			name:  "head deleted",
+			lines: func() []string { return []string{lines[1], lines[2]} },
+		},
+		{
+			name:  "invalid json",
+			lines: func() []string { return []string{lines[0], "{"} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyAuditLog(bytes.NewBufferString(strings.Join(tt.lines(), "\n")))
+			if tt.wantOK {
+				if err != nil {
+					t.Errorf("VerifyAuditLog() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrAuditTampered) {
+				t.Errorf("VerifyAuditLog() error = %v, want ErrAuditTampered", err)
+			}
+		})
+	}
+}
+
+func TestAuditLog_FailingSink(t *testing.T) {
+	var good, flaky []uint64
+	fail := 0
+	log := NewAuditLog(
+		WithAuditSink(AuditSinkFunc(func(ctx context.Context, entry *AuditEntry) error {
+			good = append(good, entry.Seq)
+			return nil
+		})),
+		WithAuditSink(AuditSinkFunc(func(ctx context.Context, entry *AuditEntry) error {
+			if fail > 0 {
+				fail--
+				return errors.New("disk full")
+			}
+			flaky = append(flaky, entry.Seq)
+			return nil
+		})),
+	)
+
+	ctx := context.Background()
+	// 第二个输出失败, 第一个已经写入, 哈希链推进
+	fail = 1
+	if err := log.Append(ctx, &AuditEntry{Event: AuditEventSign}); err == nil {
+		t.Fatal("Append() succeeded, want error")
+	}
+	// 下次先补写 seq 1
+	if err := log.Append(ctx, &AuditEntry{Event: AuditEventSubmitted}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{1, 2}; !slices.Equal(good, want) || !slices.Equal(flaky, want) {
+		t.Errorf("good = %v, flaky = %v, want %v", good, flaky, want)
+	}
+
+	// 所有输出都失败时不推进
+	failAll := NewAuditLog(WithAuditSink(AuditSinkFunc(func(ctx context.Context, entry *AuditEntry) error {
+		if entry.RequestId == "bad" {
+			return errors.New("disk full")
+		}
+		good = append(good[:0], entry.Seq)
+		return nil
+	})))
+	if err := failAll.Append(ctx, &AuditEntry{RequestId: "bad"}); err == nil {
+		t.Fatal("Append() succeeded, want error")
+	}
+	if err := failAll.Append(ctx, &AuditEntry{RequestId: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(good, []uint64{1}) {
+		t.Errorf("seq after failed append = %v, want [1]", good)
+	}
+}
+
+func TestFileAuditSink_AfterFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := OpenFileAuditSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var remote []uint64
+	var fail bool
+	log := NewAuditLog(
+		WithAuditSink(sink),
+		WithAuditSink(AuditSinkFunc(func(ctx context.Context, entry *AuditEntry) error {
+			if fail {
+				return errors.New("remote down")
+			}
+			remote = append(remote, entry.Seq)
+			return nil
+		})),
+	)
+
+	ctx := context.Background()
+	for i, f := range []bool{false, true, false} {
+		fail = f
+		if err := log.Append(ctx, &AuditEntry{Event: AuditEventSign, RequestId: strconv.Itoa(i)}); (err != nil) != f {
+			t.Fatalf("Append(%d) error = %v", i, err)
+		}
+	}
+	if !slices.Equal(remote, []uint64{1, 2, 3}) {
+		t.Errorf("remote = %v, want [1 2 3]", remote)
+	}
+
+	// 文件写入失败, 文件中只保留之前的记录
+	sink.f.Close()
+	if err := log.Append(ctx, &AuditEntry{Event: AuditEventSign, RequestId: "3"}); err == nil {
+		t.Fatal("Append() succeeded, want error")
+	}
+	if last := sink.Last(); last.Seq != 3 {
+		t.Errorf("Last() = %+v, want seq 3", last)
+	}
+
+	reopened, err := OpenFileAuditSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	if last := reopened.Last(); last == nil || last.Seq != 3 {
+		t.Errorf("reopened Last() = %+v, want seq 3", last)
+	}
+}
+
+func TestVerifyAuditLogFrom(t *testing.T) {
+	var lines []string
+	var entries []AuditEntry
+	log := NewAuditLog(WithAuditSink(AuditSinkFunc(func(ctx context.Context, entry *AuditEntry) error {
+		b, err := json.Marshal(entry)
+		lines = append(lines, string(b))
+		entries = append(entries, *entry)
+		return err
+	})))
+	for _, requestId := range []string{"1", "2", "3"} {
+		if err := log.Append(context.Background(), &AuditEntry{Event: AuditEventSign, RequestId: requestId}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 轮转后的分段从上一段的最后一条接续
+	segment := strings.Join(lines[1:], "\n")
+	last, err := VerifyAuditLogFrom(strings.NewReader(segment), &entries[0])
+	if err != nil || last.Seq != 3 {
+		t.Errorf("VerifyAuditLogFrom() = %+v, %v", last, err)
+	}
+	if _, err := VerifyAuditLogFrom(strings.NewReader(lines[2]), &entries[0]); !errors.Is(err, ErrAuditTampered) {
+		t.Errorf("segment with missing head: err = %v, want ErrAuditTampered", err)
+	}
+	if last, err := VerifyAuditLogFrom(strings.NewReader(""), &entries[2]); err != nil || last.Seq != 3 {
+		t.Errorf("empty segment = %+v, %v, want head", last, err)
+	}
+}
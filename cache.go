@@ -0,0 +1,60 @@
+package kit
+
+import (
+	"sync"
+	"time"
+)
+
+type ttlCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// ttlCache 简单的过期缓存, 过期的条目在读取时清理
+type ttlCache[K comparable, V any] struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[K]ttlCacheEntry[V]
+}
+
+func newTTLCache[K comparable, V any](ttl time.Duration) *ttlCache[K, V] {
+	return &ttlCache[K, V]{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[K]ttlCacheEntry[V]),
+	}
+}
+
+func (c *ttlCache[K, V]) Get(key K) (v V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return v, false
+	}
+	if !c.now().Before(e.expiresAt) {
+		delete(c.entries, key)
+		return v, false
+	}
+	return e.value, true
+}
+
+func (c *ttlCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = ttlCacheEntry[V]{
+		value:     value,
+		expiresAt: c.now().Add(c.ttl),
+	}
+}
+
+func (c *ttlCache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[K]ttlCacheEntry[V])
+}
@@ -0,0 +1,123 @@
+package kit
+
+import (
+	"log/slog"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+)
+
+type clientWrapperOptions struct {
+	user           *mixin.User
+	spendPublicKey string
+	spendKey       mixinnet.Key
+	keystore       *EncryptedKeystore
+	passphrase     []byte
+	lazyUser       bool
+	policy         *SpendingPolicy
+	signer         TransactionSigner
+	audit          *AuditLog
+	tracer         Tracer
+	meter          Meter
+	logger         *slog.Logger
+	web3Options    []Web3ClientOption
+	marketOptions  []MarketClientOption
+}
+
+// ClientWrapperOption 定义 NewMixinClientWrapper 的选项
+type ClientWrapperOption func(*clientWrapperOptions)
+
+// WithUser 使用已知的机器人用户信息, 构造时不再请求 /me
+func WithUser(user *mixin.User) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.user = user
+	}
+}
+
+// WithSpendPublicKey 使用已知的 spend 公钥校验 spend key, 构造时不再请求 /me
+func WithSpendPublicKey(publicKey string) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.spendPublicKey = publicKey
+	}
+}
+
+// WithSpendKey 使用已解析的 spend key, 忽略 Config.SpendKey, 如 EncryptedKeystore.DecryptSpendKey 的结果;
+// 与 Config.SpendKey 一样在第一次使用前用 spend 公钥校验
+func WithSpendKey(key mixinnet.Key) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.spendKey = key
+	}
+}
+
+// WithEncryptedSpendKey 第一次需要 spend key 时用 passphrase 从 ks 解密 (EncryptedKeystore.DecryptSpendKey),
+// 忽略 Config.SpendKey; spend key 和口令都不会以 string 的形式保存, 口令在解密后清零
+func WithEncryptedSpendKey(ks *EncryptedKeystore, passphrase []byte) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.keystore = ks
+		o.passphrase = append([]byte(nil), passphrase...)
+	}
+}
+
+// WithTransactionSigner 使用 signer 签名交易, 如 RemoteSigner; 此时不需要 Config.SpendKey
+func WithTransactionSigner(signer TransactionSigner) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.signer = signer
+	}
+}
+
+// WithAuditLog 每笔交易签名前和提交后写入 log, 调用方可以用 ContextWithAudit 记录发起者和原因
+func WithAuditLog(log *AuditLog) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.audit = log
+	}
+}
+
+// WithTracer 为 utxo 查询, 聚合, 签名, 提交和 Route 请求创建 span
+func WithTracer(tracer Tracer) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.tracer = tracer
+	}
+}
+
+// WithMeter 记录转账, 聚合和 API 请求的指标, 如 NewPrometheusRegistry()
+func WithMeter(meter Meter) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.meter = meter
+	}
+}
+
+// WithLazyUser 构造时不请求 /me, 在第一次需要 spend key 时再获取
+func WithLazyUser() ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.lazyUser = true
+	}
+}
+
+// WithSpendingPolicy 所有转账在签名前经过 policy 检查, 被拒绝时返回的错误满足 errors.Is(err, ErrSpendingPolicy)
+func WithSpendingPolicy(policy *SpendingPolicy) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.policy = policy
+	}
+}
+
+// WithLogger 设置 bot 客户端和 Route 请求日志使用的 logger
+func WithLogger(logger *slog.Logger) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.logger = logger
+	}
+}
+
+// WithWeb3Options 设置 Route 客户端的选项, 如 WithBaseURL, WithTimeout, WithHTTPClient.
+// 这些选项只作用于当前客户端的 Route 请求; Mixin API 的地址和超时是进程级的, 见 SetGlobalSafeApiHost
+func WithWeb3Options(opts ...Web3ClientOption) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.web3Options = append(o.web3Options, opts...)
+	}
+}
+
+// WithMarketOptions 设置行情客户端的选项
+func WithMarketOptions(opts ...MarketClientOption) ClientWrapperOption {
+	return func(o *clientWrapperOptions) {
+		o.marketOptions = append(o.marketOptions, opts...)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+// invoiceEntries 可重复的 -entry asset:amount[:memo]
+type invoiceEntries []invoiceEntry
+
+type invoiceEntry struct {
+	assetId string
+	amount  decimal.Decimal
+	memo    string
+}
+
+func (v *invoiceEntries) String() string { return fmt.Sprint(len(*v)) }
+
+func (v *invoiceEntries) Set(s string) error {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 {
+		return errors.New("expect asset:amount[:memo]")
+	}
+	amount, err := decimal.NewFromString(parts[1])
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", parts[1])
+	}
+	entry := invoiceEntry{assetId: parts[0], amount: amount}
+	if len(parts) == 3 {
+		entry.memo = parts[2]
+	}
+	*v = append(*v, entry)
+	return nil
+}
+
+var invoiceCommand = &command{
+	name:  "invoice",
+	usage: "build or decode payment invoices",
+	subcommands: []*command{
+		{name: "build", usage: "build an invoice", run: buildInvoice},
+		{name: "decode", usage: "decode and validate an invoice", run: decodeInvoice},
+	},
+}
+
+type invoiceView struct {
+	Invoice    string                     `json:"invoice"`
+	PaymentURL string                     `json:"payment_url"`
+	Recipient  []string                   `json:"recipient"`
+	Threshold  uint8                      `json:"threshold"`
+	Entries    []invoiceEntryView         `json:"entries"`
+	Totals     map[string]decimal.Decimal `json:"totals"`
+	Error      string                     `json:"error,omitempty"`
+}
+
+type invoiceEntryView struct {
+	TraceId string          `json:"trace_id"`
+	AssetId string          `json:"asset_id"`
+	Amount  decimal.Decimal `json:"amount"`
+	Extra   string          `json:"extra,omitempty"`
+}
+
+func newInvoiceView(iw *kit.MixinInvoiceWrapper) *invoiceView {
+	v := &invoiceView{
+		Invoice:    iw.String(),
+		PaymentURL: iw.PaymentURL(),
+		Totals:     iw.AssetTotals(),
+	}
+	if r := iw.Invoice.Recipient; r != nil {
+		v.Recipient, v.Threshold = r.Members(), r.Threshold
+	}
+	for _, e := range iw.Invoice.Entries {
+		if e == nil {
+			continue
+		}
+		amount, _ := decimal.NewFromString(e.Amount.String())
+		v.Entries = append(v.Entries, invoiceEntryView{TraceId: e.TraceId, AssetId: e.AssetId, Amount: amount, Extra: string(e.Extra)})
+	}
+	if err := iw.Validate(); err != nil {
+		v.Error = err.Error()
+	}
+	return v
+}
+
+func buildInvoice(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "invoice build")
+	to := fs.String("to", "", "recipient user id, comma separated members, or MIX address")
+	threshold := fs.Uint("threshold", 1, "threshold when -to has several members")
+	var entries invoiceEntries
+	fs.Var(&entries, "entry", "asset:amount[:memo], repeatable; entry i references entry i-1 with -chain")
+	chain := fs.Bool("chain", false, "each entry references the previous one")
+	if err := parseFlags(fs, args, "to"); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(fs.Output(), "invoice build: at least one -entry is required")
+		return errUsage
+	}
+
+	var iw *kit.MixinInvoiceWrapper
+	var err error
+	if strings.HasPrefix(*to, "MIX") {
+		iw, err = kit.NewMixinInvoiceMixAddress(*to)
+	} else {
+		iw, err = kit.NewMixinInvoiceMembers(strings.Split(*to, ","), uint8(*threshold))
+	}
+	if err != nil {
+		return err
+	}
+
+	for i, entry := range entries {
+		var refs []uint8
+		if *chain && i > 0 {
+			refs = []uint8{uint8(i - 1)}
+		}
+		if err := iw.AddEntryIndex(mixin.RandomTraceID(), entry.assetId, entry.amount, entry.memo, refs); err != nil {
+			return fmt.Errorf("entry %d: %w", i, err)
+		}
+	}
+	if err := iw.Validate(); err != nil {
+		return err
+	}
+
+	v := newInvoiceView(iw)
+	return e.print(v, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\n\n%s\n", v.Invoice, v.PaymentURL)
+	})
+}
+
+func decodeInvoice(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "invoice decode")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(fs.Output(), "usage: mixin-kit invoice decode <invoice or payment url>")
+		return errUsage
+	}
+
+	// 支持 https://mixin.one/pay/MIN... 形式的链接
+	s, _, _ := strings.Cut(fs.Arg(0), "?")
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		s = s[i+1:]
+	}
+	iw, err := kit.NewMixinInvoiceWrapperFromString(s)
+	if err != nil {
+		return err
+	}
+
+	v := newInvoiceView(iw)
+	return e.print(v, func(w io.Writer) {
+		fmt.Fprint(w, iw.Describe())
+		if v.Error != "" {
+			fmt.Fprintf(w, "invalid: %s\n", v.Error)
+		}
+	})
+}
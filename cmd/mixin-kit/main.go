@@ -0,0 +1,223 @@
+// mixin-kit 机器人钱包的日常操作工具, 基于 kit.ClientWrapper.
+//
+//	mixin-kit [flags] <command> [subcommand] [flags]
+//
+// 配置通过 -config 读取 (json / toml / yaml), 或 -keystore 读取加密的 keystore,
+// 口令从环境变量 MIXIN_KEYSTORE_PASSPHRASE 读取; MIXIN_ 开头的环境变量覆盖文件中的字段, 如 MIXIN_SPEND_KEY.
+// 所有命令都支持 -json 输出和 -dry-run (只检查, 不发送交易).
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+)
+
+var errUsage = errors.New("usage")
+
+// env 命令运行时的全局选项和输出
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	config   string
+	keystore string
+	apiHost  string
+	json     bool
+	dryRun   bool
+
+	client *kit.ClientWrapper
+}
+
+// bind 在 fs 上注册全局选项, 命令前后都可以使用
+func (e *env) bind(fs *flag.FlagSet) {
+	fs.StringVar(&e.config, "config", e.config, "config file (json, toml, yaml)")
+	fs.StringVar(&e.keystore, "keystore", e.keystore, "encrypted keystore file, passphrase from MIXIN_KEYSTORE_PASSPHRASE")
+	fs.StringVar(&e.apiHost, "api-host", e.apiHost, "Mixin API host, e.g. https://api.mixin.one")
+	fs.BoolVar(&e.json, "json", e.json, "print JSON output")
+	fs.BoolVar(&e.dryRun, "dry-run", e.dryRun, "check only, do not send transactions")
+}
+
+// Client 按全局选项创建 ClientWrapper; 只读命令不需要 spend key, 使用 lazy 模式
+func (e *env) Client(ctx context.Context, spend bool) (*kit.ClientWrapper, error) {
+	if e.client != nil {
+		return e.client, nil
+	}
+
+	var clientOpts []kit.ClientWrapperOption
+	opts := []kit.ConfigOption{kit.WithConfigEnv(kit.DefaultConfigEnvPrefix)}
+	switch {
+	case e.keystore != "":
+		// spend key 只在转账前解密为 mixinnet.Key, 不经过 Config.SpendKey
+		passphrase := kit.EnvSecretProvider{Prefix: kit.DefaultConfigEnvPrefix}
+		opts = append([]kit.ConfigOption{kit.WithEncryptedSessionFile(e.keystore, passphrase)}, opts...)
+		ks, err := kit.LoadEncryptedKeystore(e.keystore)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := passphrase.Secret(ctx, "keystore_passphrase")
+		if err != nil {
+			return nil, fmt.Errorf("load keystore passphrase: %w", err)
+		}
+		clientOpts = append(clientOpts, kit.WithEncryptedSpendKey(ks, []byte(secret)))
+	case e.config != "":
+		opts = append([]kit.ConfigOption{kit.WithConfigFile(e.config)}, opts...)
+	}
+
+	config, err := kit.LoadConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.apiHost != "" {
+		kit.SetGlobalSafeApiHost(e.apiHost)
+	}
+	if !spend || e.dryRun {
+		clientOpts = append(clientOpts, kit.WithLazyUser())
+	}
+	if e.client, err = kit.NewMixinClientWrapper(config, clientOpts...); err != nil {
+		return nil, err
+	}
+	return e.client, nil
+}
+
+// print 输出结果, -json 时输出 v, 否则调用 text
+func (e *env) print(v any, text func(w io.Writer)) error {
+	if e.json {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	text(tw)
+	return tw.Flush()
+}
+
+// sending 发出交易前把 request id 输出到 stderr, 失败或中断后可以用它安全重试
+func (e *env) sending(requestId string) {
+	fmt.Fprintf(e.stderr, "request id %s, rerun with -request-id %s to retry safely\n", requestId, requestId)
+}
+
+// command 子命令, run 和 subcommands 二选一
+type command struct {
+	name        string
+	usage       string
+	run         func(ctx context.Context, e *env, args []string) error
+	subcommands []*command
+}
+
+var commands = []*command{
+	balanceCommand,
+	utxosCommand,
+	transferCommand,
+	transferBatchCommand,
+	inscriptionCommand,
+	swapCommand,
+	invoiceCommand,
+	marketCommand,
+}
+
+func findCommand(commands []*command, name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func printUsage(w io.Writer, prefix string, commands []*command) {
+	fmt.Fprintf(w, "usage: %s [flags] <command>\n\ncommands:\n", prefix)
+	sorted := append([]*command(nil), commands...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	for _, c := range sorted {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.usage)
+	}
+}
+
+// newFlagSet 创建子命令的 FlagSet, 并注册全局选项
+func newFlagSet(e *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	e.bind(fs)
+	return fs
+}
+
+// parseFlags 解析子命令参数, 检查 required 中的选项不为空
+func parseFlags(fs *flag.FlagSet, args []string, required ...string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	for _, name := range required {
+		if f := fs.Lookup(name); f == nil || f.Value.String() == "" {
+			fmt.Fprintf(fs.Output(), "%s: -%s is required\n", fs.Name(), name)
+			fs.Usage()
+			return errUsage
+		}
+	}
+	return nil
+}
+
+func run(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("mixin-kit", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	e.bind(fs)
+	fs.Usage = func() {
+		printUsage(fs.Output(), "mixin-kit", commands)
+		fmt.Fprintln(fs.Output(), "\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	prefix := "mixin-kit"
+	list := commands
+	args = fs.Args()
+	for {
+		if len(args) == 0 {
+			printUsage(e.stderr, prefix, list)
+			return errUsage
+		}
+		c := findCommand(list, args[0])
+		if c == nil {
+			fmt.Fprintf(e.stderr, "%s: unknown command %q\n\n", prefix, args[0])
+			printUsage(e.stderr, prefix, list)
+			return errUsage
+		}
+		prefix += " " + c.name
+		if c.run != nil {
+			return c.run(ctx, e, args[1:])
+		}
+		list, args = c.subcommands, args[1:]
+	}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	e := &env{
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		config: os.Getenv("MIXIN_KIT_CONFIG"),
+	}
+	if err := run(ctx, e, os.Args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "error:", strings.TrimSpace(err.Error()))
+		os.Exit(1)
+	}
+}
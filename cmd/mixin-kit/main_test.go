@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	testAsset     = "965e5c6e-434c-3fa9-b780-c50f43cd955c"
+	testRecipient = "e9e5b807-fa8b-455a-8dfa-b189d28310ff"
+	testOther     = "8dcf823d-9eb3-4da2-8734-f0aad50c0da6"
+)
+
+// runCLI 运行命令, 返回 stdout
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), &env{stdout: &stdout, stderr: &stderr}, args)
+	return stdout.String(), err
+}
+
+// newTestServer 启动模拟服务并写入配置文件, 返回配置文件路径
+func newTestServer(t *testing.T) (*kittest.SafeServer, string) {
+	server := kittest.NewSafeServer(t)
+	server.UseApiHost(t)
+
+	b, err := json.Marshal(server.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return server, path
+}
+
+func TestRun_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"unknown"},
+		{"utxos"},
+		{"transfer", "-asset", testAsset},
+	} {
+		if _, err := runCLI(t, args...); !errors.Is(err, errUsage) {
+			t.Errorf("run(%q) error = %v, want errUsage", args, err)
+		}
+	}
+}
+
+func TestInvoice_BuildDecode(t *testing.T) {
+	out, err := runCLI(t, "invoice", "build", "-json", "-to", testRecipient, "-entry", testAsset+":1.5:first", "-entry", testAsset+":2", "-chain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var built invoiceView
+	if err := json.Unmarshal([]byte(out), &built); err != nil {
+		t.Fatal(err)
+	}
+	if len(built.Entries) != 2 || !built.Totals[testAsset].Equal(decimal.RequireFromString("3.5")) {
+		t.Fatalf("built = %+v", built)
+	}
+
+	out, err = runCLI(t, "invoice", "decode", "--json", built.PaymentURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded invoiceView
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Invoice != built.Invoice || decoded.Error != "" || decoded.Recipient[0] != testRecipient {
+		t.Errorf("decoded = %+v", decoded)
+	}
+}
+
+func TestCheckSwapPayment(t *testing.T) {
+	req := &kit.QuoteRequest{InputMint: testAsset, OutputMint: testOther, Amount: decimal.RequireFromString("1.5")}
+	tests := []struct {
+		name    string
+		payment kit.SwapTx
+		wantErr bool
+	}{
+		{"match", kit.SwapTx{Asset: testAsset, Amount: decimal.RequireFromString("1.50")}, false},
+		{"other asset", kit.SwapTx{Asset: testOther, Amount: decimal.RequireFromString("1.5")}, true},
+		{"other amount", kit.SwapTx{Asset: testAsset, Amount: decimal.RequireFromString("15")}, true},
+	}
+	for _, tt := range tests {
+		if err := checkSwapPayment(req, &tt.payment); (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkSwapPayment() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	server, config := newTestServer(t)
+	server.Deposit(testAsset, decimal.NewFromInt(10))
+
+	// -dry-run 只检查余额
+	if _, err := runCLI(t, "-config", config, "transfer", "-dry-run", "-asset", testAsset, "-to", testRecipient, "-amount", "4"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runCLI(t, "-config", config, "transfer", "-dry-run", "-asset", testAsset, "-to", testRecipient, "-amount", "11"); err == nil || !strings.Contains(err.Error(), "insufficient balance") {
+		t.Errorf("dry run error = %v, want insufficient balance", err)
+	}
+	if got := server.Balance(testAsset, testRecipient); !got.IsZero() {
+		t.Fatalf("dry run transferred %s", got)
+	}
+
+	// 参数在访问网络之前检查, -dry-run 也一样
+	for _, args := range [][]string{
+		{"-to", "alice", "-amount", "1"},
+		{"-to", testRecipient, "-amount", "0"},
+		{"-to", testRecipient, "-amount", "1", "-request-id", "retry-1"},
+	} {
+		for _, dryRun := range []string{"-dry-run=false", "-dry-run"} {
+			args := append([]string{"-config", config, "transfer", dryRun, "-asset", testAsset}, args...)
+			if _, err := runCLI(t, args...); err == nil || !strings.Contains(err.Error(), "invalid") {
+				t.Errorf("run(%q) error = %v, want invalid argument", args, err)
+			}
+		}
+	}
+
+	out, err := runCLI(t, "-config", config, "-json", "transfer", "-asset", testAsset, "-to", testRecipient, "-amount", "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result transferResult
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.TransactionHash == "" {
+		t.Errorf("result = %+v", result)
+	}
+	if got := server.Balance(testAsset, testRecipient); !got.Equal(decimal.NewFromInt(4)) {
+		t.Errorf("recipient balance = %s, want 4", got)
+	}
+
+	out, err = runCLI(t, "-config", config, "balance", "-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var balances map[string]decimal.Decimal
+	if err := json.Unmarshal([]byte(out), &balances); err != nil {
+		t.Fatal(err)
+	}
+	if !balances[testAsset].Equal(decimal.NewFromInt(6)) {
+		t.Errorf("balances = %v, want 6", balances)
+	}
+}
+
+func TestTransferBatch(t *testing.T) {
+	server, config := newTestServer(t)
+	server.Deposit(testAsset, decimal.NewFromInt(10))
+
+	file := filepath.Join(t.TempDir(), "payout.csv")
+	csv := "member,amount\n" + testRecipient + ",1\n" + testOther + ",2\n" + testRecipient + ",0.5\n"
+	if err := os.WriteFile(file, []byte(csv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "results.csv")
+
+	if _, err := runCLI(t, "-config", config, "transfer-batch", "-csv", file, "-asset", testAsset, "-out", out); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.Balance(testAsset, testRecipient); !got.Equal(decimal.RequireFromString("1.5")) {
+		t.Errorf("recipient balance = %s, want 1.5", got)
+	}
+	results, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(results), kit.PayoutStatusSubmitted); n != 3 {
+		t.Errorf("results have %d submitted rows, want 3:\n%s", n, results)
+	}
+	if got := server.Balance(testAsset, testOther); !got.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("other balance = %s, want 2", got)
+	}
+}
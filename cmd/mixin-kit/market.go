@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+)
+
+var marketCommand = &command{
+	name:  "market",
+	usage: "show market info and price history",
+	subcommands: []*command{
+		{name: "info", usage: "show market info of an asset", run: marketInfo},
+		{name: "history", usage: "show price history of an asset", run: marketHistory},
+	},
+}
+
+func marketInfo(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "market info")
+	asset := fs.String("asset", "", "asset id")
+	if err := parseFlags(fs, args, "asset"); err != nil {
+		return err
+	}
+
+	client, err := e.Client(ctx, false)
+	if err != nil {
+		return err
+	}
+	info, err := client.GetAssetInfo(ctx, *asset)
+	if err != nil {
+		return err
+	}
+	return e.print(info, func(w io.Writer) {
+		fmt.Fprintf(w, "asset\t%s (%s)\nprice\t%s\nchange 24h\t%s%%\nhigh 24h\t%s\nlow 24h\t%s\nvolume 24h\t%s\nmarket cap\t%s (#%s)\n",
+			info.Symbol, info.Name, info.CurrentPrice, info.PriceChangePercentage24H, info.High24H, info.Low24H, info.TotalVolume, info.MarketCap, info.MarketCapRank)
+	})
+}
+
+// historyType 解析 1D, 1W, 1M, YTD, ALL
+type historyType kit.HistoryPriceType
+
+func (t *historyType) String() string { return kit.HistoryPriceType(*t).String() }
+
+func (t *historyType) Set(s string) error {
+	for i := kit.PriceType1D; i <= kit.PriceTypeALL; i++ {
+		if strings.EqualFold(i.String(), s) {
+			*t = historyType(i)
+			return nil
+		}
+	}
+	return errors.New("expect 1D, 1W, 1M, YTD or ALL")
+}
+
+var _ flag.Value = (*historyType)(nil)
+
+func marketHistory(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "market history")
+	asset := fs.String("asset", "", "asset id")
+	typ := historyType(kit.PriceType1D)
+	fs.Var(&typ, "type", "1D, 1W, 1M, YTD or ALL")
+	interval := fs.Duration("interval", 0, "resample into OHLC candles, e.g. 1h")
+	if err := parseFlags(fs, args, "asset"); err != nil {
+		return err
+	}
+
+	client, err := e.Client(ctx, false)
+	if err != nil {
+		return err
+	}
+	history, err := client.GetPriceHistory(ctx, *asset, kit.HistoryPriceType(typ))
+	if err != nil {
+		return err
+	}
+
+	if *interval > 0 {
+		candles, err := history.Resample(*interval)
+		if err != nil {
+			return err
+		}
+		return e.print(candles, func(w io.Writer) {
+			fmt.Fprintln(w, "START\tOPEN\tHIGH\tLOW\tCLOSE")
+			for _, c := range candles {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Start.Format(time.RFC3339), c.Open, c.High, c.Low, c.Close)
+			}
+		})
+	}
+
+	points, err := history.Points()
+	if err != nil {
+		return err
+	}
+	return e.print(points, func(w io.Writer) {
+		fmt.Fprintln(w, "TIME\tPRICE")
+		for _, p := range points {
+			fmt.Fprintf(w, "%s\t%s\n", p.Time.Format(time.RFC3339), p.Price)
+		}
+	})
+}
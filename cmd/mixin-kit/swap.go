@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/shopspring/decimal"
+)
+
+var swapCommand = &command{
+	name:  "swap",
+	usage: "quote, execute or check swaps through Mixin Route",
+	subcommands: []*command{
+		{name: "quote", usage: "get a swap quote", run: swapQuote},
+		{name: "execute", usage: "create a swap order and pay it", run: swapExecute},
+		{name: "status", usage: "show a swap order", run: swapStatus},
+	},
+}
+
+// swapFlags 解析 quote 和 execute 共用的参数
+func swapFlags(e *env, name string, args []string) (*kit.QuoteRequest, error) {
+	fs := newFlagSet(e, name)
+	from := fs.String("from", "", "input asset id")
+	to := fs.String("to", "", "output asset id")
+	amount := fs.String("amount", "", "input amount")
+	if err := parseFlags(fs, args, "from", "to", "amount"); err != nil {
+		return nil, err
+	}
+	if err := checkUUIDs("from", *from, "to", *to); err != nil {
+		return nil, err
+	}
+
+	value, err := decimal.NewFromString(*amount)
+	if err != nil || !value.IsPositive() {
+		return nil, fmt.Errorf("invalid amount %q", *amount)
+	}
+	return &kit.QuoteRequest{InputMint: *from, OutputMint: *to, Amount: value}, nil
+}
+
+func printQuote(w io.Writer, quote *kit.QuoteResponseView) {
+	fmt.Fprintf(w, "input\t%s %s\noutput\t%s %s\n", quote.InAmount, quote.InputMint, quote.OutAmount, quote.OutputMint)
+}
+
+func swapQuote(ctx context.Context, e *env, args []string) error {
+	req, err := swapFlags(e, "swap quote", args)
+	if err != nil {
+		return err
+	}
+
+	client, err := e.Client(ctx, false)
+	if err != nil {
+		return err
+	}
+	quote, err := client.Web3Quote(ctx, *req)
+	if err != nil {
+		return err
+	}
+	return e.print(quote, func(w io.Writer) { printQuote(w, &quote) })
+}
+
+type swapResult struct {
+	Quote           kit.QuoteResponseView `json:"quote"`
+	Payment         *kit.SwapTx           `json:"payment"`
+	TransactionHash string                `json:"transaction_hash,omitempty"`
+	DryRun          bool                  `json:"dry_run,omitempty"`
+}
+
+func swapExecute(ctx context.Context, e *env, args []string) error {
+	req, err := swapFlags(e, "swap execute", args)
+	if err != nil {
+		return err
+	}
+
+	client, err := e.Client(ctx, true)
+	if err != nil {
+		return err
+	}
+	quote, err := client.Web3Quote(ctx, *req)
+	if err != nil {
+		return err
+	}
+	result := &swapResult{Quote: quote, DryRun: e.dryRun}
+	text := func(w io.Writer) {
+		printQuote(w, &result.Quote)
+		if result.Payment != nil {
+			fmt.Fprintf(w, "order\t%s\npayee\t%s\ntrace\t%s\n", result.Payment.OrderId, result.Payment.Payee, result.Payment.Trace)
+		}
+		if result.TransactionHash != "" {
+			fmt.Fprintf(w, "transaction\t%s\n", result.TransactionHash)
+		}
+	}
+	// 创建订单后需要立即支付, -dry-run 只问价
+	if e.dryRun {
+		return e.print(result, text)
+	}
+
+	swap, err := client.Web3Swap(ctx, kit.SwapRequest{
+		Payer:       client.ClientID,
+		InputMint:   req.InputMint,
+		InputAmount: req.Amount,
+		OutputMint:  req.OutputMint,
+		Payload:     quote.Payload,
+	})
+	if err != nil {
+		return err
+	}
+	if result.Payment, err = swap.DecodeTx(); err != nil {
+		return fmt.Errorf("decode swap payment: %w", err)
+	}
+	if err := checkSwapPayment(req, result.Payment); err != nil {
+		return err
+	}
+
+	request, err := client.TransferOne(ctx, &kit.TransferOneRequest{
+		RequestId: result.Payment.Trace,
+		AssetId:   result.Payment.Asset,
+		Member:    result.Payment.Payee,
+		Amount:    result.Payment.Amount,
+		Memo:      result.Payment.Memo,
+	})
+	if err != nil {
+		return fmt.Errorf("pay swap order %s: %w", result.Payment.OrderId, err)
+	}
+	result.TransactionHash = request.TransactionHash
+	return e.print(result, text)
+}
+
+// checkSwapPayment 支付前检查 Route 返回的支付资产和金额与 -from, -amount 一致
+func checkSwapPayment(req *kit.QuoteRequest, payment *kit.SwapTx) error {
+	if payment.Asset != req.InputMint || !payment.Amount.Equal(req.Amount) {
+		return fmt.Errorf("swap order %s asks for %s %s, want %s %s", payment.OrderId, payment.Amount, payment.Asset, req.Amount, req.InputMint)
+	}
+	return nil
+}
+
+func swapStatus(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "swap status")
+	orderId := fs.String("order", "", "order id")
+	if err := parseFlags(fs, args, "order"); err != nil {
+		return err
+	}
+
+	client, err := e.Client(ctx, false)
+	if err != nil {
+		return err
+	}
+	order, err := client.GetWeb3SwapOrder(ctx, *orderId)
+	if err != nil {
+		return err
+	}
+	return e.print(order, func(w io.Writer) {
+		fmt.Fprintf(w, "order\t%s\nstate\t%s\npay\t%s %s\nreceive\t%s %s\ncreated\t%s\n",
+			order.OrderId, order.State, order.Amount, order.AssetId, order.ReceiveAmount, order.ReceiveAssetId, order.CreatedAt)
+	})
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/gofrs/uuid/v5"
+	"github.com/shopspring/decimal"
+)
+
+var balanceCommand = &command{
+	name:  "balance",
+	usage: "show unspent balances",
+	run: func(ctx context.Context, e *env, args []string) error {
+		fs := newFlagSet(e, "balance")
+		asset := fs.String("asset", "", "asset id, all assets if empty")
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+
+		client, err := e.Client(ctx, false)
+		if err != nil {
+			return err
+		}
+		balances, err := client.Balances(ctx, *asset)
+		if err != nil {
+			return err
+		}
+
+		assets := make([]string, 0, len(balances))
+		for assetId := range balances {
+			assets = append(assets, assetId)
+		}
+		sort.Strings(assets)
+		return e.print(balances, func(w io.Writer) {
+			fmt.Fprintln(w, "ASSET\tBALANCE")
+			for _, assetId := range assets {
+				fmt.Fprintf(w, "%s\t%s\n", assetId, balances[assetId])
+			}
+		})
+	},
+}
+
+var utxosCommand = &command{
+	name:  "utxos",
+	usage: "list or consolidate utxos",
+	subcommands: []*command{
+		{
+			name:  "list",
+			usage: "list utxos",
+			run: func(ctx context.Context, e *env, args []string) error {
+				return listUtxos(ctx, e, "utxos list", args, false)
+			},
+		},
+		{
+			name:  "consolidate",
+			usage: "merge utxos until at most 255 are left",
+			run:   consolidateUtxos,
+		},
+	},
+}
+
+func listUtxos(ctx context.Context, e *env, name string, args []string, inscriptions bool) error {
+	fs := newFlagSet(e, name)
+	asset := fs.String("asset", "", "asset id")
+	state := fs.String("state", string(mixin.SafeUtxoStateUnspent), "unspent, signed or spent")
+	offset := fs.Uint64("offset", 0, "start after this sequence")
+	limit := fs.Int("limit", 100, "max utxos")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	client, err := e.Client(ctx, false)
+	if err != nil {
+		return err
+	}
+	utxos, err := client.SafeListUtxos(ctx, mixin.SafeListUtxoOption{
+		Asset:     *asset,
+		State:     mixin.SafeUtxoState(*state),
+		Threshold: 1,
+		Offset:    *offset,
+		Limit:     *limit,
+		Order:     "ASC",
+	})
+	if err != nil {
+		return err
+	}
+
+	if inscriptions {
+		filtered := utxos[:0]
+		for _, utxo := range utxos {
+			if utxo.InscriptionHash.HasValue() {
+				filtered = append(filtered, utxo)
+			}
+		}
+		utxos = filtered
+	}
+
+	return e.print(utxos, func(w io.Writer) {
+		fmt.Fprintln(w, "SEQUENCE\tOUTPUT\tASSET\tAMOUNT\tSTATE\tINSCRIPTION")
+		for _, utxo := range utxos {
+			inscription := ""
+			if utxo.InscriptionHash.HasValue() {
+				inscription = utxo.InscriptionHash.String()
+			}
+			fmt.Fprintf(w, "%d\t%s:%d\t%s\t%s\t%s\t%s\n", utxo.Sequence, utxo.TransactionHash, utxo.OutputIndex, utxo.AssetID, utxo.Amount, utxo.State, inscription)
+		}
+	})
+}
+
+type consolidateResult struct {
+	AssetId string `json:"asset_id"`
+	Before  int    `json:"before"`
+	After   int    `json:"after"`
+	DryRun  bool   `json:"dry_run,omitempty"`
+}
+
+func consolidateUtxos(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "utxos consolidate")
+	asset := fs.String("asset", "", "asset id")
+	if err := parseFlags(fs, args, "asset"); err != nil {
+		return err
+	}
+
+	client, err := e.Client(ctx, true)
+	if err != nil {
+		return err
+	}
+	utxos, err := client.SafeListUtxos(ctx, mixin.SafeListUtxoOption{
+		Asset:     *asset,
+		State:     mixin.SafeUtxoStateUnspent,
+		Threshold: 1,
+		Limit:     500,
+	})
+	if err != nil {
+		return err
+	}
+
+	result := consolidateResult{AssetId: *asset, Before: len(utxos), After: len(utxos), DryRun: e.dryRun}
+	if !e.dryRun && len(utxos) > kit.MAX_UTXO_NUM {
+		after, err := client.SyncArrgegateUtxos(ctx, *asset)
+		if err != nil {
+			return err
+		}
+		result.After = len(after)
+	}
+
+	return e.print(result, func(w io.Writer) {
+		switch {
+		case result.Before <= kit.MAX_UTXO_NUM:
+			fmt.Fprintf(w, "%d utxos, nothing to consolidate\n", result.Before)
+		case result.DryRun:
+			fmt.Fprintf(w, "%d utxos, would consolidate to at most %d\n", result.Before, kit.MAX_UTXO_NUM)
+		default:
+			fmt.Fprintf(w, "consolidated %d utxos into %d\n", result.Before, result.After)
+		}
+	})
+}
+
+type transferResult struct {
+	RequestId       string          `json:"request_id"`
+	AssetId         string          `json:"asset_id"`
+	Amount          decimal.Decimal `json:"amount"`
+	Recipients      int             `json:"recipients"`
+	TransactionHash string          `json:"transaction_hash,omitempty"`
+	State           string          `json:"state,omitempty"`
+	DryRun          bool            `json:"dry_run,omitempty"`
+}
+
+func (r *transferResult) text(w io.Writer) {
+	if r.DryRun {
+		fmt.Fprintf(w, "dry run: would transfer %s of %s to %d recipient(s), request %s\n", r.Amount, r.AssetId, r.Recipients, r.RequestId)
+		return
+	}
+	fmt.Fprintf(w, "request\t%s\nasset\t%s\namount\t%s\nrecipients\t%d\n", r.RequestId, r.AssetId, r.Amount, r.Recipients)
+	if r.TransactionHash != "" {
+		fmt.Fprintf(w, "transaction\t%s\nstate\t%s\n", r.TransactionHash, r.State)
+	}
+}
+
+// checkBalance -dry-run 时检查余额是否足够
+func checkBalance(ctx context.Context, client *kit.ClientWrapper, assetId string, amount decimal.Decimal) error {
+	balances, err := client.Balances(ctx, assetId)
+	if err != nil {
+		return err
+	}
+	if balance := balances[assetId]; balance.LessThan(amount) {
+		return fmt.Errorf("insufficient balance: have %s, need %s", balance, amount)
+	}
+	return nil
+}
+
+// checkUUIDs 在访问网络之前检查 flag 的值是 uuid, values 按 name, value 成对传入
+func checkUUIDs(values ...string) error {
+	for i := 0; i+1 < len(values); i += 2 {
+		if _, err := uuid.FromString(values[i+1]); err != nil {
+			return fmt.Errorf("invalid -%s %q: not a uuid", values[i], values[i+1])
+		}
+	}
+	return nil
+}
+
+var transferCommand = &command{
+	name:  "transfer",
+	usage: "transfer to a user",
+	run: func(ctx context.Context, e *env, args []string) error {
+		fs := newFlagSet(e, "transfer")
+		asset := fs.String("asset", "", "asset id")
+		to := fs.String("to", "", "recipient user id")
+		amount := fs.String("amount", "", "amount")
+		memo := fs.String("memo", "", "memo")
+		requestId := fs.String("request-id", "", "request id, random if empty; reuse it to retry safely")
+		if err := parseFlags(fs, args, "asset", "to", "amount"); err != nil {
+			return err
+		}
+
+		if *requestId == "" {
+			*requestId = mixin.RandomTraceID()
+		}
+		if err := checkUUIDs("asset", *asset, "to", *to, "request-id", *requestId); err != nil {
+			return err
+		}
+		value, err := decimal.NewFromString(*amount)
+		if err != nil || !value.IsPositive() {
+			return fmt.Errorf("invalid amount %q", *amount)
+		}
+
+		client, err := e.Client(ctx, true)
+		if err != nil {
+			return err
+		}
+		result := &transferResult{RequestId: *requestId, AssetId: *asset, Amount: value, Recipients: 1, DryRun: e.dryRun}
+		if e.dryRun {
+			if err := checkBalance(ctx, client, *asset, value); err != nil {
+				return err
+			}
+			return e.print(result, result.text)
+		}
+
+		e.sending(*requestId)
+		request, err := client.TransferOne(ctx, &kit.TransferOneRequest{
+			RequestId: *requestId,
+			AssetId:   *asset,
+			Member:    *to,
+			Amount:    value,
+			Memo:      *memo,
+		})
+		if err != nil {
+			return fmt.Errorf("request %s: %w", *requestId, err)
+		}
+		result.TransactionHash, result.State = request.TransactionHash, string(request.State)
+		return e.print(result, result.text)
+	},
+}
+
+var transferBatchCommand = &command{
+	name:  "transfer-batch",
+	usage: "transfer to many users from a csv or jsonl payout file",
+	run: func(ctx context.Context, e *env, args []string) error {
+		fs := newFlagSet(e, "transfer-batch")
+		file := fs.String("file", "", "payout file (.csv or .jsonl): members, threshold, amount, memo, asset_id; a csv without header is read as member,amount")
+		fs.StringVar(file, "csv", "", "alias of -file")
+		asset := fs.String("asset", "", "asset id for rows without asset_id")
+		memo := fs.String("memo", "", "memo for rows without memo")
+		requestId := fs.String("request-id", "", "request id, random if empty; reuse it to retry safely")
+		out := fs.String("out", "", "write per-row results to this file (.csv or .jsonl)")
+		if err := parseFlags(fs, args, "file"); err != nil {
+			return err
+		}
+
+		rows, err := kit.ReadPayoutFile(*file)
+		if err != nil {
+			return err
+		}
+		if *requestId == "" {
+			*requestId = mixin.RandomTraceID()
+		}
+
+		client, err := e.Client(ctx, true)
+		if err != nil {
+			return err
+		}
+		importer := kit.NewPayoutImporter(client,
+			kit.WithPayoutAsset(*asset),
+			kit.WithPayoutMemo(*memo),
+			kit.WithPayoutProgress(func(p kit.PayoutProgress) {
+				fmt.Fprintf(e.stderr, "batch %d/%d: %d/%d rows submitted\n", p.Batch, p.Batches, p.Rows, p.TotalRows)
+			}),
+		)
+		plan, err := importer.Plan(ctx, rows)
+		if err != nil {
+			return err
+		}
+
+		result := &batchResult{RequestId: *requestId, Rows: len(plan.Rows), Payouts: len(plan.Payouts), Totals: plan.Totals, Balances: plan.Balances, DryRun: e.dryRun}
+		if e.dryRun {
+			return e.print(result, result.text)
+		}
+
+		e.sending(*requestId)
+		results, runErr := importer.Run(ctx, plan, *requestId)
+		if runErr != nil {
+			runErr = fmt.Errorf("request %s: %w", *requestId, runErr)
+		}
+		if *out != "" {
+			if err := writePayoutResults(*out, results); err != nil {
+				return errors.Join(runErr, err)
+			}
+		}
+		if runErr != nil {
+			return runErr
+		}
+		result.Results = results
+		return e.print(result, result.text)
+	},
+}
+
+type batchResult struct {
+	RequestId string                     `json:"request_id"`
+	Rows      int                        `json:"rows"`
+	Payouts   int                        `json:"payouts"`
+	Totals    map[string]decimal.Decimal `json:"totals"`
+	Balances  map[string]decimal.Decimal `json:"balances"`
+	Results   []kit.PayoutResult         `json:"results,omitempty"`
+	DryRun    bool                       `json:"dry_run,omitempty"`
+}
+
+func (r *batchResult) text(w io.Writer) {
+	fmt.Fprintf(w, "request\t%s\nrows\t%d\npayouts\t%d\n", r.RequestId, r.Rows, r.Payouts)
+	assets := make([]string, 0, len(r.Totals))
+	for assetId := range r.Totals {
+		assets = append(assets, assetId)
+	}
+	sort.Strings(assets)
+	for _, assetId := range assets {
+		fmt.Fprintf(w, "total\t%s %s\tbalance %s\n", r.Totals[assetId], assetId, r.Balances[assetId])
+	}
+	if r.DryRun {
+		fmt.Fprintln(w, "dry run\ttrue")
+	}
+}
+
+// writePayoutResults 按扩展名写出每行的结果
+func writePayoutResults(path string, results []kit.PayoutResult) error {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := kit.WritePayoutResults(f, format, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var inscriptionCommand = &command{
+	name:  "inscription",
+	usage: "list or send inscriptions",
+	subcommands: []*command{
+		{
+			name:  "list",
+			usage: "list inscription utxos",
+			run: func(ctx context.Context, e *env, args []string) error {
+				return listUtxos(ctx, e, "inscription list", args, true)
+			},
+		},
+		{
+			name:  "send",
+			usage: "send an inscription to a user",
+			run:   sendInscription,
+		},
+	},
+}
+
+func sendInscription(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "inscription send")
+	asset := fs.String("asset", "", "collection asset id")
+	inscription := fs.String("inscription", "", "inscription hash")
+	to := fs.String("to", "", "recipient user id")
+	memo := fs.String("memo", "", "memo")
+	requestId := fs.String("request-id", "", "request id, random if empty; reuse it to retry safely")
+	if err := parseFlags(fs, args, "asset", "inscription", "to"); err != nil {
+		return err
+	}
+	if *requestId == "" {
+		*requestId = mixin.RandomTraceID()
+	}
+	if err := checkUUIDs("asset", *asset, "to", *to, "request-id", *requestId); err != nil {
+		return err
+	}
+
+	client, err := e.Client(ctx, true)
+	if err != nil {
+		return err
+	}
+	result := &transferResult{RequestId: *requestId, AssetId: *asset, Recipients: 1, DryRun: e.dryRun}
+	if e.dryRun {
+		return e.print(result, func(w io.Writer) {
+			fmt.Fprintf(w, "dry run: would send inscription %s to %s, request %s\n", *inscription, *to, *requestId)
+		})
+	}
+
+	e.sending(*requestId)
+	request, err := client.InscriptionTransfer(ctx, &kit.InscriptionTransferRequest{
+		RequestId:   *requestId,
+		AssetId:     *asset,
+		Inscription: *inscription,
+		Member:      *to,
+		Memo:        *memo,
+	})
+	if err != nil {
+		return fmt.Errorf("request %s: %w", *requestId, err)
+	}
+	result.TransactionHash, result.State = request.TransactionHash, string(request.State)
+	return e.print(result, result.text)
+}
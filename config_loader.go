@@ -0,0 +1,213 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnsupportedConfigFormat = errors.New("unsupported config format")
+	ErrSecretNotFound          = errors.New("secret not found")
+)
+
+// DefaultConfigEnvPrefix 环境变量前缀, 如 MIXIN_APP_ID, MIXIN_SPEND_KEY
+const DefaultConfigEnvPrefix = "MIXIN_"
+
+// SecretProvider 按名称读取密钥, 名称与 Config 的 json 字段名一致, 如 spend_key.
+// 密钥不存在时返回 ErrSecretNotFound
+type SecretProvider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// SecretProviderFunc 函数形式的 SecretProvider
+type SecretProviderFunc func(ctx context.Context, name string) (string, error)
+
+func (f SecretProviderFunc) Secret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// FileSecretProvider 从 Dir/name 文件读取密钥, 如 docker / k8s 挂载的 secret 目录
+type FileSecretProvider struct {
+	Dir string
+}
+
+func (p FileSecretProvider) Secret(ctx context.Context, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(p.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// EnvSecretProvider 从环境变量 Prefix + 大写 name 读取密钥, 如 MIXIN_SPEND_KEY
+type EnvSecretProvider struct {
+	Prefix string
+}
+
+func (p EnvSecretProvider) Secret(ctx context.Context, name string) (string, error) {
+	v, ok := os.LookupEnv(p.Prefix + strings.ToUpper(name))
+	if !ok || v == "" {
+		return "", ErrSecretNotFound
+	}
+	return strings.TrimSpace(v), nil
+}
+
+// ParseConfig 按 format 解析 keystore, format 为 json, toml, yaml 或 yml
+func ParseConfig(data []byte, format string) (*Config, error) {
+	var (
+		config Config
+		err    error
+	)
+	switch strings.ToLower(strings.TrimPrefix(format, ".")) {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		err = dec.Decode(&config)
+	case "toml":
+		_, err = toml.Decode(string(data), &config)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &config)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedConfigFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s config: %w", format, err)
+	}
+	return &config, nil
+}
+
+// LoadConfigFile 读取 keystore 文件, 按扩展名选择格式
+func LoadConfigFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfig(data, filepath.Ext(path))
+}
+
+// LoadConfigEnv 从 prefix 开头的环境变量读取 keystore, prefix 为空时使用 DefaultConfigEnvPrefix
+func LoadConfigEnv(prefix string) *Config {
+	if prefix == "" {
+		prefix = DefaultConfigEnvPrefix
+	}
+	var config Config
+	for name, field := range config.fields() {
+		*field = strings.TrimSpace(os.Getenv(prefix + strings.ToUpper(name)))
+	}
+	return &config
+}
+
+// fields 按 json 字段名返回 Config 各字段的指针
+func (c *Config) fields() map[string]*string {
+	return map[string]*string{
+		"app_id":              &c.AppID,
+		"session_id":          &c.SessionID,
+		"server_public_key":   &c.ServerPublicKey,
+		"session_private_key": &c.SessionPrivateKey,
+		"spend_key":           &c.SpendKey,
+	}
+}
+
+// merge 用 other 中的非空字段覆盖 c
+func (c *Config) merge(other *Config) {
+	fields := c.fields()
+	for name, field := range other.fields() {
+		if *field != "" {
+			*fields[name] = *field
+		}
+	}
+}
+
+type configSource func(ctx context.Context, config *Config) error
+
+type configLoader struct {
+	sources []configSource
+}
+
+// ConfigOption 定义 LoadConfig 的配置来源, 按传入顺序加载, 后面的非空字段覆盖前面的
+type ConfigOption func(*configLoader)
+
+// WithConfigFile 从 keystore 文件加载, 见 LoadConfigFile
+func WithConfigFile(path string) ConfigOption {
+	return func(l *configLoader) {
+		l.sources = append(l.sources, func(ctx context.Context, config *Config) error {
+			c, err := LoadConfigFile(path)
+			if err != nil {
+				return err
+			}
+			config.merge(c)
+			return nil
+		})
+	}
+}
+
+// WithConfigEnv 从环境变量加载, 见 LoadConfigEnv
+func WithConfigEnv(prefix string) ConfigOption {
+	return func(l *configLoader) {
+		l.sources = append(l.sources, func(ctx context.Context, config *Config) error {
+			config.merge(LoadConfigEnv(prefix))
+			return nil
+		})
+	}
+}
+
+// WithSecretProvider 从 provider 读取 names 对应的字段, names 为空时只读取 spend_key.
+// provider 返回 ErrSecretNotFound 时保留原值
+func WithSecretProvider(provider SecretProvider, names ...string) ConfigOption {
+	if len(names) == 0 {
+		names = []string{"spend_key"}
+	}
+	return func(l *configLoader) {
+		l.sources = append(l.sources, func(ctx context.Context, config *Config) error {
+			fields := config.fields()
+			for _, name := range names {
+				field, ok := fields[name]
+				if !ok {
+					return fmt.Errorf("unknown config field %q", name)
+				}
+				v, err := provider.Secret(ctx, name)
+				if errors.Is(err, ErrSecretNotFound) {
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("load secret %s: %w", name, err)
+				}
+				*field = v
+			}
+			return nil
+		})
+	}
+}
+
+// LoadConfig 依次从 opts 指定的来源加载 keystore, 如:
+//
+//	kit.LoadConfig(ctx,
+//		kit.WithConfigFile("keystore.toml"),
+//		kit.WithConfigEnv(""),
+//		kit.WithSecretProvider(kit.FileSecretProvider{Dir: "/run/secrets"}),
+//	)
+func LoadConfig(ctx context.Context, opts ...ConfigOption) (*Config, error) {
+	var l configLoader
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	var config Config
+	for _, source := range l.sources {
+		if err := source(ctx, &config); err != nil {
+			return nil, err
+		}
+	}
+	return &config, nil
+}
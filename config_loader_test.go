@@ -0,0 +1,85 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	want := Config{
+		AppID:             "app",
+		SessionID:         "session",
+		ServerPublicKey:   "server",
+		SessionPrivateKey: "private",
+		SpendKey:          "spend",
+	}
+
+	tests := []struct {
+		format string
+		data   string
+	}{
+		{"json", `{"app_id":"app","session_id":"session","server_public_key":"server","session_private_key":"private","spend_key":"spend"}`},
+		{"toml", "app_id = \"app\"\nsession_id = \"session\"\nserver_public_key = \"server\"\nsession_private_key = \"private\"\nspend_key = \"spend\"\n"},
+		{".yaml", "app_id: app\nsession_id: session\nserver_public_key: server\nsession_private_key: private\nspend_key: spend\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := ParseConfig([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != want {
+				t.Errorf("ParseConfig() = %+v, want %+v", *got, want)
+			}
+		})
+	}
+
+	if _, err := ParseConfig(nil, "ini"); !errors.Is(err, ErrUnsupportedConfigFormat) {
+		t.Errorf("ParseConfig(ini) error = %v", err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	keystore := filepath.Join(dir, "keystore.yml")
+	if err := os.WriteFile(keystore, []byte("app_id: app\nsession_id: session\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	secrets := filepath.Join(dir, "secrets")
+	if err := os.Mkdir(secrets, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(secrets, "spend_key"), []byte("spend-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEST_MIXIN_SESSION_ID", "session-from-env")
+	t.Setenv("TEST_MIXIN_SESSION_PRIVATE_KEY", "private-from-env")
+
+	config, err := LoadConfig(context.Background(),
+		WithConfigFile(keystore),
+		WithConfigEnv("TEST_MIXIN_"),
+		WithSecretProvider(FileSecretProvider{Dir: secrets}),
+		WithSecretProvider(EnvSecretProvider{Prefix: "TEST_MIXIN_"}, "spend_key", "session_private_key"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Config{
+		AppID:             "app",
+		SessionID:         "session-from-env",
+		SessionPrivateKey: "private-from-env",
+		SpendKey:          "spend-from-file",
+	}
+	if *config != want {
+		t.Errorf("LoadConfig() = %+v, want %+v", *config, want)
+	}
+
+	if _, err := LoadConfig(context.Background(), WithSecretProvider(EnvSecretProvider{}, "password")); err == nil {
+		t.Error("LoadConfig() with unknown field should fail")
+	}
+}
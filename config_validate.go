@@ -0,0 +1,166 @@
+package kit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"filippo.io/edwards25519"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"github.com/gofrs/uuid/v5"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// ConfigFieldError Config 某个字段的问题, Field 为 json 字段名
+type ConfigFieldError struct {
+	Field   string
+	Problem string
+}
+
+func (e ConfigFieldError) Error() string {
+	return e.Field + ": " + e.Problem
+}
+
+// ConfigErrors Config 校验发现的所有问题, errors.Is(err, ErrInvalidConfig) 为 true
+type ConfigErrors []ConfigFieldError
+
+func (e ConfigErrors) Error() string {
+	problems := make([]string, len(e))
+	for i, p := range e {
+		problems[i] = p.Error()
+	}
+	return ErrInvalidConfig.Error() + ": " + strings.Join(problems, "; ")
+}
+
+func (e ConfigErrors) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// Field 返回 field 的问题, 没有时返回空
+func (e ConfigErrors) Field(field string) []ConfigFieldError {
+	var problems []ConfigFieldError
+	for _, p := range e {
+		if p.Field == field {
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+func (e *ConfigErrors) add(field, format string, args ...any) {
+	*e = append(*e, ConfigFieldError{Field: field, Problem: fmt.Sprintf(format, args...)})
+}
+
+func (e ConfigErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Validate 离线校验 Config 各字段的格式, spend_key 为空时不校验.
+// 有问题时返回 ConfigErrors
+func (c *Config) Validate() error {
+	if c == nil {
+		return ErrConfigNil
+	}
+
+	var errs ConfigErrors
+	validateUUID(&errs, "app_id", c.AppID)
+	validateUUID(&errs, "session_id", c.SessionID)
+
+	if b, ok := decodeHexField(&errs, "server_public_key", c.ServerPublicKey, ed25519.PublicKeySize); ok {
+		if _, err := new(edwards25519.Point).SetBytes(b); err != nil {
+			errs.add("server_public_key", "not a valid ed25519 public key")
+		}
+	}
+
+	if b, ok := decodeHexField(&errs, "session_private_key", c.SessionPrivateKey, ed25519.SeedSize, ed25519.PrivateKeySize); ok && len(b) == ed25519.PrivateKeySize {
+		if !ed25519PrivateKeyConsistent(b) {
+			errs.add("session_private_key", "public key half does not match the seed")
+		} else {
+			errs.add("session_private_key", "must be the 32 bytes seed, got a 64 bytes private key; use the first 64 hex chars")
+		}
+	}
+
+	if c.SpendKey != "" {
+		if b, ok := decodeHexField(&errs, "spend_key", c.SpendKey, 32, ed25519.PrivateKeySize); ok && len(b) == ed25519.PrivateKeySize {
+			if !ed25519PrivateKeyConsistent(b) {
+				errs.add("spend_key", "public key half does not match the seed")
+			}
+		}
+	}
+
+	return errs.err()
+}
+
+// ValidateSpendKey 校验 spend_key 是否与机器人的 spend 公钥 (mixin.User.SpendPublicKey) 匹配
+func (c *Config) ValidateSpendKey(spendPublicKey string) error {
+	if c == nil {
+		return ErrConfigNil
+	}
+
+	var errs ConfigErrors
+	if c.SpendKey == "" {
+		errs.add("spend_key", "missing")
+		return errs
+	}
+	if b, err := hex.DecodeString(spendPublicKey); err != nil || len(b) != 32 {
+		errs.add("spend_key", "spend public key %q is not a 32 bytes hex key", spendPublicKey)
+		return errs
+	}
+	if _, err := mixinnet.ParseKeyWithPub(c.SpendKey, spendPublicKey); err != nil {
+		errs.add("spend_key", "does not match spend public key %s", spendPublicKey)
+	}
+	return errs.err()
+}
+
+func validateUUID(errs *ConfigErrors, field, value string) {
+	if value == "" {
+		errs.add(field, "missing")
+		return
+	}
+	id, err := uuid.FromString(value)
+	if err != nil {
+		errs.add(field, "not a valid uuid: %q", value)
+		return
+	}
+	if id.String() != value {
+		errs.add(field, "uuid must be in canonical lowercase form %s", id)
+	}
+}
+
+// decodeHexField 解码 hex 字段并检查字节长度是否为 sizes 之一
+func decodeHexField(errs *ConfigErrors, field, value string, sizes ...int) ([]byte, bool) {
+	if value == "" {
+		errs.add(field, "missing")
+		return nil, false
+	}
+	b, err := hex.DecodeString(value)
+	if err != nil {
+		errs.add(field, "not valid hex: %v", err)
+		return nil, false
+	}
+	for _, size := range sizes {
+		if len(b) == size {
+			return b, true
+		}
+	}
+
+	want := make([]string, len(sizes))
+	for i, size := range sizes {
+		want[i] = fmt.Sprint(size)
+	}
+	errs.add(field, "got %d bytes, want %s bytes", len(b), strings.Join(want, " or "))
+	return nil, false
+}
+
+// ed25519PrivateKeyConsistent 64 字节私钥的后 32 字节是否为种子对应的公钥
+func ed25519PrivateKeyConsistent(key []byte) bool {
+	derived := ed25519.NewKeyFromSeed(key[:ed25519.SeedSize])
+	return bytes.Equal(derived[ed25519.SeedSize:], key[ed25519.SeedSize:])
+}
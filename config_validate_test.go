@@ -0,0 +1,135 @@
+package kit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+)
+
+func testValidConfig() Config {
+	server := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{0x02}, ed25519.SeedSize))
+	return Config{
+		AppID:             testRouteAppID,
+		SessionID:         "0a6d4c2e-1b2f-4f0e-8a77-0f6c7a1e5b3d",
+		ServerPublicKey:   hex.EncodeToString(server.Public().(ed25519.PublicKey)),
+		SessionPrivateKey: hex.EncodeToString(bytes.Repeat([]byte{0x01}, ed25519.SeedSize)),
+		SpendKey:          hex.EncodeToString(bytes.Repeat([]byte{0x03}, 32)),
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	sessionKey := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{0x01}, ed25519.SeedSize))
+	broken := append([]byte(nil), sessionKey...)
+	broken[63] ^= 0xff
+
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		fields map[string]string
+	}{
+		{"valid", func(c *Config) {}, nil},
+		{"empty spend key", func(c *Config) { c.SpendKey = "" }, nil},
+		{
+			"missing",
+			func(c *Config) { *c = Config{} },
+			map[string]string{
+				"app_id":              "missing",
+				"session_id":          "missing",
+				"server_public_key":   "missing",
+				"session_private_key": "missing",
+			},
+		},
+		{
+			"uuid",
+			func(c *Config) {
+				c.AppID = "not-a-uuid"
+				c.SessionID = strings.ToUpper(c.SessionID)
+			},
+			map[string]string{
+				"app_id":     "not a valid uuid",
+				"session_id": "canonical",
+			},
+		},
+		{
+			"encodings",
+			func(c *Config) {
+				c.ServerPublicKey = "zz"
+				c.SessionPrivateKey = hex.EncodeToString(make([]byte, 16))
+				c.SpendKey = hex.EncodeToString(make([]byte, 48))
+			},
+			map[string]string{
+				"server_public_key":   "not valid hex",
+				"session_private_key": "got 16 bytes, want 32 or 64 bytes",
+				"spend_key":           "got 48 bytes",
+			},
+		},
+		{
+			"64 bytes session key",
+			func(c *Config) { c.SessionPrivateKey = hex.EncodeToString(sessionKey) },
+			map[string]string{"session_private_key": "must be the 32 bytes seed"},
+		},
+		{
+			"inconsistent session key",
+			func(c *Config) { c.SessionPrivateKey = hex.EncodeToString(broken) },
+			map[string]string{"session_private_key": "does not match the seed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testValidConfig()
+			tt.modify(&c)
+
+			err := c.Validate()
+			if len(tt.fields) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidConfig", err)
+			}
+
+			var errs ConfigErrors
+			errors.As(err, &errs)
+			if len(errs) != len(tt.fields) {
+				t.Errorf("Validate() = %v, want %d problems", errs, len(tt.fields))
+			}
+			for field, want := range tt.fields {
+				problems := errs.Field(field)
+				if len(problems) != 1 || !strings.Contains(problems[0].Problem, want) {
+					t.Errorf("%s problems = %v, want %q", field, problems, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateSpendKey(t *testing.T) {
+	c := testValidConfig()
+	spendKey, err := mixinnet.KeyFromSeed(c.SpendKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.ValidateSpendKey(spendKey.Public().String()); err != nil {
+		t.Errorf("ValidateSpendKey() error = %v", err)
+	}
+
+	other := mixinnet.KeyFromBytes(bytes.Repeat([]byte{0x04}, 64))
+	err = c.ValidateSpendKey(other.Public().String())
+	var errs ConfigErrors
+	if !errors.As(err, &errs) || len(errs.Field("spend_key")) != 1 {
+		t.Errorf("ValidateSpendKey(other) error = %v", err)
+	}
+
+	c.SpendKey = ""
+	if err := c.ValidateSpendKey(spendKey.Public().String()); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("ValidateSpendKey(empty) error = %v", err)
+	}
+}
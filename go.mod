@@ -3,16 +3,21 @@ module github.com/DomeLiquid/mixin-kit-go
 go 1.24.4
 
 require (
+	filippo.io/edwards25519 v1.1.0
+	github.com/BurntSushi/toml v1.5.0
 	github.com/MixinNetwork/bot-api-go-client/v3 v3.15.1
 	github.com/MixinNetwork/mixin v0.18.26
 	github.com/fox-one/mixin-sdk-go/v2 v2.1.0
 	github.com/go-resty/resty/v2 v2.16.5
 	github.com/gofrs/uuid/v5 v5.3.2
 	github.com/shopspring/decimal v1.4.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang.org/x/crypto v0.39.0
+	golang.org/x/sync v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/MixinNetwork/go-number v0.1.1 // indirect
 	github.com/btcsuite/btcutil v1.0.2 // indirect
 	github.com/fox-one/msgpack v1.0.0 // indirect
@@ -25,9 +30,7 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/vmihailenco/tagparser v0.1.2 // indirect
 	github.com/zeebo/blake3 v0.2.4 // indirect
-	golang.org/x/crypto v0.39.0 // indirect
 	golang.org/x/net v0.40.0 // indirect
-	golang.org/x/sync v0.14.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 	google.golang.org/appengine v1.6.8 // indirect
 	google.golang.org/protobuf v1.36.6 // indirect
@@ -1,23 +1,100 @@
 package kit
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/MixinNetwork/bot-api-go-client/v3"
 	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/gofrs/uuid/v5"
 	"github.com/shopspring/decimal"
 )
 
+var (
+	ErrInvoiceNil               = errors.New("invoice is nil")
+	ErrInvoiceNoRecipient       = errors.New("invoice has no recipient")
+	ErrInvoiceInvalidRecipient  = errors.New("invalid invoice recipient")
+	ErrInvoiceNoEntries         = errors.New("invoice has no entries")
+	ErrInvoiceEntryNil          = errors.New("invoice entry is nil")
+	ErrInvoiceInvalidTraceId    = errors.New("invalid trace id")
+	ErrInvoiceDuplicateTraceId  = errors.New("duplicate trace id")
+	ErrInvoiceInvalidAssetId    = errors.New("invalid asset id")
+	ErrInvoiceInvalidAmount     = errors.New("amount must be positive")
+	ErrInvoiceTooManyReferences = errors.New("too many references")
+	ErrInvoiceInvalidReference  = errors.New("index reference out of range")
+	ErrInvoiceReferenceCycle    = errors.New("reference cycle")
+)
+
 type MixinInvoiceWrapper struct {
 	Invoice *bot.MixinInvoice
 }
 
+// NewMixinInvoiceUserId 创建收款人为 uid 的发票, 不校验 uid; 需要校验时使用 NewMixinInvoiceUserIdE
 func NewMixinInvoiceUserId(uid string) *MixinInvoiceWrapper {
 	mi := bot.NewMixinInvoice(bot.NewUUIDMixAddress([]string{uid}, 1).String())
 	return &MixinInvoiceWrapper{mi}
 }
 
+// NewMixinInvoiceUserIdE 与 NewMixinInvoiceUserId 相同, uid 不合法时返回 ErrInvoiceInvalidRecipient
+func NewMixinInvoiceUserIdE(uid string) (*MixinInvoiceWrapper, error) {
+	return NewMixinInvoiceMembers([]string{uid}, 1)
+}
+
+// NewMixinInvoiceMembers 创建收款人为多签地址 (members, threshold) 的发票
+func NewMixinInvoiceMembers(members []string, threshold uint8) (*MixinInvoiceWrapper, error) {
+	if err := validateInvoiceMembers(members, threshold); err != nil {
+		return nil, err
+	}
+
+	mi := bot.NewMixinInvoice(bot.NewUUIDMixAddress(members, threshold).String())
+	return &MixinInvoiceWrapper{mi}, nil
+}
+
+// NewMixinInvoiceMixAddress 创建收款人为已有 MIX 地址的发票
+func NewMixinInvoiceMixAddress(address string) (*MixinInvoiceWrapper, error) {
+	ma, err := bot.NewMixAddressFromString(address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvoiceInvalidRecipient, err)
+	}
+	if err := validateInvoiceRecipient(ma); err != nil {
+		return nil, err
+	}
+
+	mi := bot.NewMixinInvoice(ma.String())
+	return &MixinInvoiceWrapper{mi}, nil
+}
+
+func validateInvoiceMembers(members []string, threshold uint8) error {
+	if len(members) == 0 {
+		return ErrInvoiceNoRecipient
+	}
+
+	if len(members) > MAX_UTXO_NUM {
+		return fmt.Errorf("%w: too many members %d", ErrInvoiceInvalidRecipient, len(members))
+	}
+
+	if threshold == 0 || int(threshold) > len(members) {
+		return fmt.Errorf("%w: threshold %d of %d members", ErrInvoiceInvalidRecipient, threshold, len(members))
+	}
+
+	seen := make(map[string]bool, len(members))
+	for _, member := range members {
+		id, err := uuid.FromString(member)
+		if err != nil {
+			return fmt.Errorf("%w: invalid member %q", ErrInvoiceInvalidRecipient, member)
+		}
+		if seen[id.String()] {
+			return fmt.Errorf("%w: duplicate member %q", ErrInvoiceInvalidRecipient, member)
+		}
+		seen[id.String()] = true
+	}
+
+	return nil
+}
+
 func (m *MixinInvoiceWrapper) AddEntryHash(traceId, assetId string, amount decimal.Decimal, memo string, hashReferences []crypto.Hash) error {
 	if len(memo) > common.ExtraSizeGeneralLimit {
 		return fmt.Errorf("memo too long")
@@ -65,3 +142,223 @@ func NewMixinInvoiceWrapperFromString(s string) (*MixinInvoiceWrapper, error) {
 	}
 	return &MixinInvoiceWrapper{mi}, nil
 }
+
+// Validate 检查发票的收款人、条目和引用关系, 返回所有发现的问题
+func (m *MixinInvoiceWrapper) Validate() error {
+	if m == nil || m.Invoice == nil {
+		return ErrInvoiceNil
+	}
+
+	var errs []error
+	if err := validateInvoiceRecipient(m.Invoice.Recipient); err != nil {
+		errs = append(errs, err)
+	}
+
+	entries := m.Invoice.Entries
+	if len(entries) == 0 {
+		errs = append(errs, ErrInvoiceNoEntries)
+	}
+
+	traceIds := make(map[string]int, len(entries))
+	for i, e := range entries {
+		if e == nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w", i, ErrInvoiceEntryNil))
+			continue
+		}
+
+		if _, err := uuid.FromString(e.TraceId); err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w: %q", i, ErrInvoiceInvalidTraceId, e.TraceId))
+		} else if j, ok := traceIds[e.TraceId]; ok {
+			errs = append(errs, fmt.Errorf("entry %d: %w: same as entry %d", i, ErrInvoiceDuplicateTraceId, j))
+		} else {
+			traceIds[e.TraceId] = i
+		}
+
+		if _, err := uuid.FromString(e.AssetId); err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %w: %q", i, ErrInvoiceInvalidAssetId, e.AssetId))
+		}
+
+		if amount, err := decimal.NewFromString(e.Amount.String()); err != nil || !amount.IsPositive() {
+			errs = append(errs, fmt.Errorf("entry %d: %w: %s", i, ErrInvoiceInvalidAmount, e.Amount.String()))
+		}
+
+		if len(e.IndexReferences)+len(e.HashReferences) > common.ReferencesCountLimit {
+			errs = append(errs, fmt.Errorf("entry %d: %w", i, ErrInvoiceTooManyReferences))
+		}
+
+		for _, ir := range e.IndexReferences {
+			if int(ir) >= len(entries) {
+				errs = append(errs, fmt.Errorf("entry %d: %w: %d", i, ErrInvoiceInvalidReference, ir))
+			} else if int(ir) == i {
+				errs = append(errs, fmt.Errorf("entry %d: %w: references itself", i, ErrInvoiceReferenceCycle))
+			}
+		}
+	}
+
+	if cycle := findInvoiceReferenceCycle(entries); len(cycle) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvoiceReferenceCycle, formatEntryIndexes(cycle, " -> ")))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateInvoiceRecipient(r *bot.MixAddress) error {
+	if r == nil {
+		return ErrInvoiceNoRecipient
+	}
+
+	members := r.Members()
+	if len(members) == 0 {
+		return ErrInvoiceNoRecipient
+	}
+
+	if int(r.Threshold) == 0 || int(r.Threshold) > len(members) {
+		return fmt.Errorf("%w: threshold %d of %d members", ErrInvoiceInvalidRecipient, r.Threshold, len(members))
+	}
+
+	return nil
+}
+
+// findInvoiceReferenceCycle 返回第一个由 IndexReferences 构成的环, 没有环时返回 nil
+func findInvoiceReferenceCycle(entries []*bot.InvoiceEntry) []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(entries))
+	var stack []int
+
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		stack = append(stack, i)
+		if entries[i] != nil {
+			for _, ir := range entries[i].IndexReferences {
+				next := int(ir)
+				// 越界和自引用由 Validate 单独报告
+				if next >= len(entries) || next == i {
+					continue
+				}
+
+				switch state[next] {
+				case visiting:
+					for k, idx := range stack {
+						if idx == next {
+							return append(append([]int{}, stack[k:]...), next)
+						}
+					}
+				case unvisited:
+					if cycle := visit(next); cycle != nil {
+						return cycle
+					}
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range entries {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// AssetTotals 按资产汇总发票中所有条目的金额
+func (m *MixinInvoiceWrapper) AssetTotals() map[string]decimal.Decimal {
+	totals := make(map[string]decimal.Decimal)
+	if m == nil || m.Invoice == nil {
+		return totals
+	}
+	for _, e := range m.Invoice.Entries {
+		if e == nil {
+			continue
+		}
+		amount, err := decimal.NewFromString(e.Amount.String())
+		if err != nil {
+			continue
+		}
+		totals[e.AssetId] = totals[e.AssetId].Add(amount)
+	}
+	return totals
+}
+
+// Describe 返回发票的可读摘要: 收款人、各条目及其引用关系、按资产汇总的金额
+func (m *MixinInvoiceWrapper) Describe() string {
+	if m == nil || m.Invoice == nil {
+		return "<nil invoice>\n"
+	}
+
+	var sb strings.Builder
+
+	if r := m.Invoice.Recipient; r != nil {
+		fmt.Fprintf(&sb, "recipient: %s (threshold %d of %s)\n", r.String(), r.Threshold, strings.Join(r.Members(), ", "))
+	} else {
+		sb.WriteString("recipient: <none>\n")
+	}
+
+	fmt.Fprintf(&sb, "entries: %d\n", len(m.Invoice.Entries))
+	for i, e := range m.Invoice.Entries {
+		if e == nil {
+			fmt.Fprintf(&sb, "  #%d <nil>\n", i)
+			continue
+		}
+		fmt.Fprintf(&sb, "  #%d trace=%s asset=%s amount=%s", i, e.TraceId, e.AssetId, e.Amount.String())
+		if len(e.Extra) > 0 {
+			fmt.Fprintf(&sb, " extra=%q", describeInvoiceExtra(e.Extra))
+		}
+		if refs := describeInvoiceReferences(e); len(refs) > 0 {
+			fmt.Fprintf(&sb, " refs=[%s]", strings.Join(refs, ", "))
+		}
+		sb.WriteString("\n")
+	}
+
+	totals := m.AssetTotals()
+	assets := make([]string, 0, len(totals))
+	for asset := range totals {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	sb.WriteString("totals:\n")
+	for _, asset := range assets {
+		fmt.Fprintf(&sb, "  %s %s\n", asset, totals[asset].String())
+	}
+
+	return sb.String()
+}
+
+func describeInvoiceReferences(e *bot.InvoiceEntry) []string {
+	refs := make([]string, 0, len(e.IndexReferences)+len(e.HashReferences))
+	for _, ir := range e.IndexReferences {
+		refs = append(refs, fmt.Sprintf("#%d", ir))
+	}
+	for _, hr := range e.HashReferences {
+		refs = append(refs, hr.String())
+	}
+	return refs
+}
+
+// storage 条目的 extra 可能很大, 摘要中只保留前 64 字节
+func describeInvoiceExtra(extra []byte) string {
+	const maxLen = 64
+	if len(extra) > maxLen {
+		return string(extra[:maxLen]) + "..."
+	}
+	return string(extra)
+}
+
+func formatEntryIndexes(indexes []int, sep string) string {
+	parts := make([]string, len(indexes))
+	for i, idx := range indexes {
+		parts[i] = fmt.Sprintf("#%d", idx)
+	}
+	return strings.Join(parts, sep)
+}
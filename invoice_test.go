@@ -0,0 +1,275 @@
+package kit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	testInvoiceRecipient = "e9e5b807-fa8b-455a-8dfa-b189d28310ff"
+	testInvoiceAssetBTC  = "c6d0c728-2624-429b-8e0d-d9d19b6592fa"
+	testInvoiceAssetSOL  = "64692c23-8971-4cf4-84a7-4dd1271dd887"
+	testInvoiceTrace1    = "0a3f9b4c-5c7e-4a1e-8d8b-2f4e7f3a1b01"
+	testInvoiceTrace2    = "0a3f9b4c-5c7e-4a1e-8d8b-2f4e7f3a1b02"
+	testInvoiceTrace3    = "0a3f9b4c-5c7e-4a1e-8d8b-2f4e7f3a1b03"
+)
+
+func TestMixinInvoiceWrapper_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		build   func(iw *MixinInvoiceWrapper)
+		wantErr error
+	}{
+		{
+			name: "valid",
+			build: func(iw *MixinInvoiceWrapper) {
+				_ = iw.AddEntryIndex(testInvoiceTrace1, testInvoiceAssetBTC, decimal.NewFromFloat(0.01), "memo1", nil)
+				_ = iw.AddEntryIndex(testInvoiceTrace2, testInvoiceAssetSOL, decimal.NewFromFloat(0.02), "memo2", []uint8{0})
+			},
+		},
+		{
+			name:    "no entries",
+			build:   func(iw *MixinInvoiceWrapper) {},
+			wantErr: ErrInvoiceNoEntries,
+		},
+		{
+			name: "duplicate trace id",
+			build: func(iw *MixinInvoiceWrapper) {
+				_ = iw.AddEntryIndex(testInvoiceTrace1, testInvoiceAssetBTC, decimal.NewFromFloat(0.01), "", nil)
+				_ = iw.AddEntryIndex(testInvoiceTrace1, testInvoiceAssetSOL, decimal.NewFromFloat(0.01), "", nil)
+			},
+			wantErr: ErrInvoiceDuplicateTraceId,
+		},
+		{
+			name: "invalid asset id",
+			build: func(iw *MixinInvoiceWrapper) {
+				_ = iw.AddEntryIndex(testInvoiceTrace1, "btc", decimal.NewFromFloat(0.01), "", nil)
+			},
+			wantErr: ErrInvoiceInvalidAssetId,
+		},
+		{
+			name: "zero amount",
+			build: func(iw *MixinInvoiceWrapper) {
+				_ = iw.AddEntryIndex(testInvoiceTrace1, testInvoiceAssetBTC, decimal.Zero, "", nil)
+			},
+			wantErr: ErrInvoiceInvalidAmount,
+		},
+		{
+			name: "reference cycle",
+			build: func(iw *MixinInvoiceWrapper) {
+				_ = iw.AddEntryIndex(testInvoiceTrace1, testInvoiceAssetBTC, decimal.NewFromFloat(0.01), "", nil)
+				_ = iw.AddEntryIndex(testInvoiceTrace2, testInvoiceAssetBTC, decimal.NewFromFloat(0.01), "", []uint8{0})
+				_ = iw.AddEntryIndex(testInvoiceTrace3, testInvoiceAssetBTC, decimal.NewFromFloat(0.01), "", []uint8{1})
+				iw.Invoice.Entries[0].IndexReferences = []byte{2}
+			},
+			wantErr: ErrInvoiceReferenceCycle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iw := NewMixinInvoiceUserId(testInvoiceRecipient)
+			tt.build(iw)
+
+			err := iw.Validate()
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Validate() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMixinInvoiceWrapper_Describe(t *testing.T) {
+	iw := NewMixinInvoiceUserId(testInvoiceRecipient)
+	_ = iw.AddEntryIndex(testInvoiceTrace1, testInvoiceAssetBTC, decimal.NewFromFloat(0.01), "memo1", nil)
+	_ = iw.AddEntryIndex(testInvoiceTrace2, testInvoiceAssetBTC, decimal.NewFromFloat(0.02), "memo2", []uint8{0})
+
+	got := iw.Describe()
+	for _, want := range []string{
+		testInvoiceRecipient,
+		"entries: 2",
+		"refs=[#0]",
+		testInvoiceAssetBTC + " 0.03",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Describe() = %q, missing %q", got, want)
+		}
+	}
+}
+
+func TestMixinInvoiceWrapper_Nil(t *testing.T) {
+	for _, iw := range []*MixinInvoiceWrapper{nil, {}} {
+		if err := iw.Validate(); !errors.Is(err, ErrInvoiceNil) {
+			t.Errorf("Validate() error = %v, want %v", err, ErrInvoiceNil)
+		}
+		if got := iw.AssetTotals(); len(got) != 0 {
+			t.Errorf("AssetTotals() = %v, want empty", got)
+		}
+		if got := iw.Describe(); !strings.Contains(got, "nil") {
+			t.Errorf("Describe() = %q", got)
+		}
+	}
+}
+
+func TestNewMixinInvoiceUserIdE(t *testing.T) {
+	iw, err := NewMixinInvoiceUserIdE(testInvoiceRecipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := iw.String(), NewMixinInvoiceUserId(testInvoiceRecipient).String(); got != want {
+		t.Errorf("invoice = %s, want %s", got, want)
+	}
+
+	if _, err := NewMixinInvoiceUserIdE("not-a-uuid"); !errors.Is(err, ErrInvoiceInvalidRecipient) {
+		t.Errorf("NewMixinInvoiceUserIdE() error = %v, want %v", err, ErrInvoiceInvalidRecipient)
+	}
+}
+
+func TestNewMixinInvoiceMembers(t *testing.T) {
+	const member2 = "6d3a9c1e-3b2f-4f0a-9a51-8c6b1f2e7d40"
+
+	tests := []struct {
+		name      string
+		members   []string
+		threshold uint8
+		wantErr   error
+	}{
+		{name: "single", members: []string{testInvoiceRecipient}, threshold: 1},
+		{name: "multisig", members: []string{testInvoiceRecipient, member2}, threshold: 2},
+		{name: "empty", members: nil, threshold: 1, wantErr: ErrInvoiceNoRecipient},
+		{name: "empty member", members: []string{""}, threshold: 1, wantErr: ErrInvoiceInvalidRecipient},
+		{name: "malformed member", members: []string{"not-a-uuid"}, threshold: 1, wantErr: ErrInvoiceInvalidRecipient},
+		{name: "zero threshold", members: []string{testInvoiceRecipient}, threshold: 0, wantErr: ErrInvoiceInvalidRecipient},
+		{name: "threshold too high", members: []string{testInvoiceRecipient}, threshold: 2, wantErr: ErrInvoiceInvalidRecipient},
+		{name: "duplicate member", members: []string{testInvoiceRecipient, testInvoiceRecipient}, threshold: 1, wantErr: ErrInvoiceInvalidRecipient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iw, err := NewMixinInvoiceMembers(tt.members, tt.threshold)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewMixinInvoiceMembers() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			// 通过 MIX 地址重建的发票应与原发票收款人一致
+			addr := iw.Invoice.Recipient.String()
+			iw2, err := NewMixinInvoiceMixAddress(addr)
+			if err != nil {
+				t.Fatalf("NewMixinInvoiceMixAddress(%s) error = %v", addr, err)
+			}
+			if got := iw2.Invoice.Recipient.String(); got != addr {
+				t.Errorf("recipient = %s, want %s", got, addr)
+			}
+		})
+	}
+}
+
+func TestInvoiceTracker_apply(t *testing.T) {
+	iw := NewMixinInvoiceUserId(testInvoiceRecipient)
+	_ = iw.AddEntryIndex(testInvoiceTrace1, testInvoiceAssetBTC, decimal.NewFromFloat(0.01), "", nil)
+	_ = iw.AddEntryIndex(testInvoiceTrace2, testInvoiceAssetSOL, decimal.NewFromFloat(0.02), "", nil)
+
+	tracker, err := NewInvoiceTracker(nil, iw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash := mixinnet.NewHash([]byte("tx1"))
+	utxo := &mixin.SafeUtxo{
+		OutputID:        "output-1",
+		RequestID:       testInvoiceTrace1,
+		AssetID:         testInvoiceAssetBTC,
+		Amount:          decimal.NewFromFloat(0.01),
+		TransactionHash: hash,
+		Sequence:        10,
+	}
+
+	// 同一个 utxo 重复出现时只计算一次
+	tracker.apply([]*mixin.SafeUtxo{utxo, utxo})
+
+	status := tracker.Status()
+	if status.Status != InvoicePaymentPartiallyPaid {
+		t.Errorf("status = %s, want %s", status.Status, InvoicePaymentPartiallyPaid)
+	}
+	if e := status.Entries[0]; e.Status != InvoicePaymentPaid || !e.PaidAmount.Equal(e.Amount) || len(e.TransactionHashes) != 1 || e.TransactionHashes[0] != hash.String() {
+		t.Errorf("entry 0 = %+v", e)
+	}
+	if e := status.Entries[1]; e.Status != InvoicePaymentUnpaid {
+		t.Errorf("entry 1 status = %s, want %s", e.Status, InvoicePaymentUnpaid)
+	}
+
+	tracker.apply([]*mixin.SafeUtxo{{
+		OutputID:  "output-2",
+		RequestID: testInvoiceTrace2,
+		AssetID:   testInvoiceAssetSOL,
+		Amount:    decimal.NewFromFloat(0.02),
+		Sequence:  11,
+	}})
+	if status := tracker.Status(); status.Status != InvoicePaymentPaid {
+		t.Errorf("status = %s, want %s", status.Status, InvoicePaymentPaid)
+	}
+	if tracker.offset != 11 {
+		t.Errorf("offset = %d, want 11", tracker.offset)
+	}
+}
+
+func TestInvoiceTracker_applyRequest(t *testing.T) {
+	iw := NewMixinInvoiceUserId(testInvoiceRecipient)
+	_ = iw.AddEntryIndex(testInvoiceTrace1, testInvoiceAssetBTC, decimal.NewFromFloat(0.01), "", nil)
+
+	tracker, err := NewInvoiceTracker(nil, iw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 第二个输出是找零, 不计入
+	tx := &mixinnet.Transaction{
+		Version: mixinnet.TxVersion,
+		Asset:   mixinnet.NewHash([]byte(testInvoiceAssetBTC)),
+		Outputs: []*mixinnet.Output{
+			{Type: mixinnet.OutputTypeScript, Amount: mixinnet.IntegerFromString("0.01")},
+			{Type: mixinnet.OutputTypeScript, Amount: mixinnet.IntegerFromString("5")},
+		},
+	}
+	raw, err := tx.Dump()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := mixinnet.NewHash([]byte("tx1"))
+	req := &mixin.SafeTransactionRequest{
+		RequestID:       testInvoiceTrace1,
+		TransactionHash: hash.String(),
+		RawTransaction:  raw,
+		Receivers: []*mixin.SafeTransactionReceiver{
+			{Members: []string{testInvoiceRecipient}, Threshold: 1},
+			{Members: []string{testInvoiceTrace3}, Threshold: 1},
+		},
+	}
+	if err := tracker.applyRequest(0, req); err != nil {
+		t.Fatal(err)
+	}
+
+	// 之后在收款人的 utxo 中看到同一个输出时不重复计入
+	tracker.apply([]*mixin.SafeUtxo{{
+		OutputID:        "output-1",
+		RequestID:       testInvoiceTrace1,
+		AssetID:         testInvoiceAssetBTC,
+		Amount:          decimal.NewFromFloat(0.01),
+		TransactionHash: hash,
+		OutputIndex:     0,
+	}})
+
+	e := tracker.Status().Entries[0]
+	if e.Status != InvoicePaymentPaid || !e.PaidAmount.Equal(decimal.NewFromFloat(0.01)) || len(e.TransactionHashes) != 1 {
+		t.Errorf("entry = %+v", e)
+	}
+}
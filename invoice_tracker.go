@@ -0,0 +1,282 @@
+package kit
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"github.com/shopspring/decimal"
+)
+
+type InvoicePaymentStatus string
+
+const (
+	InvoicePaymentUnpaid        InvoicePaymentStatus = "unpaid"
+	InvoicePaymentPartiallyPaid InvoicePaymentStatus = "partially_paid"
+	InvoicePaymentPaid          InvoicePaymentStatus = "paid"
+)
+
+const invoiceTrackerPageLimit = 500
+
+type InvoiceEntryStatus struct {
+	Index             int
+	TraceId           string
+	AssetId           string
+	Amount            decimal.Decimal
+	PaidAmount        decimal.Decimal
+	Status            InvoicePaymentStatus
+	TransactionHashes []string
+}
+
+type InvoiceStatus struct {
+	Status  InvoicePaymentStatus
+	Entries []InvoiceEntryStatus
+}
+
+// InvoiceTracker 根据发票条目的 trace id 跟踪发票的支付状态.
+// 只有机器人是发票收款人 (Recipient) 的成员时, 才能从自己的 utxo 列表中发现付款;
+// 否则每次 Poll 都要为每个未支付的条目调用一次 SafeReadTransactionRequest, 条目较多时应降低轮询频率
+type InvoiceTracker struct {
+	client  *ClientWrapper
+	invoice *MixinInvoiceWrapper
+
+	members   []string
+	threshold uint8
+
+	mu      sync.Mutex
+	offset  uint64 // 已扫描 utxo 的 sequence
+	entries []InvoiceEntryStatus
+	traces  map[string]int
+	outputs map[string]bool   // 已计入的输出, key 为 transaction hash:output index, 两种查询方式共用
+	kernels map[string]string // asset id -> kernel asset id
+}
+
+func NewInvoiceTracker(client *ClientWrapper, invoice *MixinInvoiceWrapper) (*InvoiceTracker, error) {
+	if err := invoice.Validate(); err != nil {
+		return nil, err
+	}
+
+	t := &InvoiceTracker{
+		client:    client,
+		invoice:   invoice,
+		members:   invoice.Invoice.Recipient.Members(),
+		threshold: invoice.Invoice.Recipient.Threshold,
+		traces:    make(map[string]int),
+		outputs:   make(map[string]bool),
+		kernels:   make(map[string]string),
+	}
+
+	for i, e := range invoice.Invoice.Entries {
+		amount, _ := decimal.NewFromString(e.Amount.String())
+		t.entries = append(t.entries, InvoiceEntryStatus{
+			Index:   i,
+			TraceId: e.TraceId,
+			AssetId: e.AssetId,
+			Amount:  amount,
+			Status:  InvoicePaymentUnpaid,
+		})
+		t.traces[e.TraceId] = i
+	}
+
+	return t, nil
+}
+
+// Poll 扫描收款人新的 utxos, 更新并返回发票的支付状态.
+// 仍未支付的条目再按 trace id 逐个查询交易, 见 InvoiceTracker
+func (t *InvoiceTracker) Poll(ctx context.Context) (*InvoiceStatus, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for {
+		utxos, err := t.client.SafeListUtxos(ctx, mixin.SafeListUtxoOption{
+			Members:   t.members,
+			Threshold: t.threshold,
+			Offset:    t.offset,
+			Limit:     invoiceTrackerPageLimit,
+			Order:     "ASC",
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		t.apply(utxos)
+
+		if len(utxos) < invoiceTrackerPageLimit {
+			break
+		}
+	}
+
+	// 收款人的 utxo 中找不到时, 再按 trace id 查询交易 (例如由本机器人支付的条目)
+	for i := range t.entries {
+		e := &t.entries[i]
+		if e.Status != InvoicePaymentUnpaid {
+			continue
+		}
+
+		req, err := t.client.SafeReadTransactionRequest(ctx, e.TraceId)
+		if err != nil {
+			if mixin.IsErrorCodes(err, mixin.EndpointNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		if req.State != mixin.SafeUtxoStateSpent {
+			continue
+		}
+		kernelAssetId, err := t.kernelAssetId(ctx, e.AssetId)
+		if err != nil {
+			return nil, err
+		}
+		if req.AssetID.String() != kernelAssetId {
+			continue
+		}
+		if err := t.applyRequest(i, req); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.status(), nil
+}
+
+func (t *InvoiceTracker) kernelAssetId(ctx context.Context, assetId string) (string, error) {
+	if id, ok := t.kernels[assetId]; ok {
+		return id, nil
+	}
+	asset, err := t.client.SafeReadAsset(ctx, assetId)
+	if err != nil {
+		return "", err
+	}
+	t.kernels[assetId] = asset.KernelAssetID
+	return asset.KernelAssetID, nil
+}
+
+// Wait 每隔 interval 轮询一次, 直到发票支付完成或 ctx 结束; ctx 结束时返回最近一次的状态和 ctx.Err()
+func (t *InvoiceTracker) Wait(ctx context.Context, interval time.Duration) (*InvoiceStatus, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := t.Poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return t.Status(), ctx.Err()
+			}
+			return nil, err
+		}
+		if status.Status == InvoicePaymentPaid {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status 返回最近一次轮询后的支付状态, 不会发起请求
+func (t *InvoiceTracker) Status() *InvoiceStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.status()
+}
+
+func (t *InvoiceTracker) apply(utxos []*mixin.SafeUtxo) {
+	for _, utxo := range utxos {
+		if utxo.Sequence > t.offset {
+			t.offset = utxo.Sequence
+		}
+
+		i, ok := t.traces[utxo.RequestID]
+		if !ok || utxo.AssetID != t.entries[i].AssetId {
+			continue
+		}
+		t.add(i, utxo.TransactionHash.String(), int(utxo.OutputIndex), utxo.Amount)
+	}
+}
+
+// applyRequest 计入 req 中发给收款人的输出, 金额从原始交易中读取
+func (t *InvoiceTracker) applyRequest(i int, req *mixin.SafeTransactionRequest) error {
+	tx, err := mixinnet.TransactionFromRaw(req.RawTransaction)
+	if err != nil {
+		return fmt.Errorf("decode request %s: %w", req.RequestID, err)
+	}
+
+	members := slices.Sorted(slices.Values(t.members))
+	for index, r := range req.Receivers {
+		if index >= len(tx.Outputs) || r.Threshold != t.threshold || !slices.Equal(slices.Sorted(slices.Values(r.Members)), members) {
+			continue
+		}
+		amount, err := decimal.NewFromString(tx.Outputs[index].Amount.String())
+		if err != nil {
+			return err
+		}
+		t.add(i, req.TransactionHash, index, amount)
+	}
+	return nil
+}
+
+// add 将交易 hash 的第 index 个输出计入条目 i, 已计入的输出忽略
+func (t *InvoiceTracker) add(i int, hash string, index int, amount decimal.Decimal) {
+	key := fmt.Sprintf("%s:%d", hash, index)
+	if t.outputs[key] {
+		return
+	}
+	t.outputs[key] = true
+
+	e := &t.entries[i]
+	e.PaidAmount = e.PaidAmount.Add(amount)
+	if !slices.Contains(e.TransactionHashes, hash) {
+		e.TransactionHashes = append(e.TransactionHashes, hash)
+	}
+	e.Status = entryPaymentStatus(e)
+}
+
+func (t *InvoiceTracker) status() *InvoiceStatus {
+	status := &InvoiceStatus{
+		Entries: make([]InvoiceEntryStatus, len(t.entries)),
+	}
+
+	paid := 0
+	started := false
+	for i, e := range t.entries {
+		e.TransactionHashes = append([]string(nil), e.TransactionHashes...)
+		status.Entries[i] = e
+
+		switch e.Status {
+		case InvoicePaymentPaid:
+			paid++
+			started = true
+		case InvoicePaymentPartiallyPaid:
+			started = true
+		}
+	}
+
+	switch {
+	case paid == len(t.entries):
+		status.Status = InvoicePaymentPaid
+	case started:
+		status.Status = InvoicePaymentPartiallyPaid
+	default:
+		status.Status = InvoicePaymentUnpaid
+	}
+	return status
+}
+
+func entryPaymentStatus(e *InvoiceEntryStatus) InvoicePaymentStatus {
+	switch {
+	case e.PaidAmount.GreaterThanOrEqual(e.Amount):
+		return InvoicePaymentPaid
+	case e.PaidAmount.IsPositive():
+		return InvoicePaymentPartiallyPaid
+	default:
+		return InvoicePaymentUnpaid
+	}
+}
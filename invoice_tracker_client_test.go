@@ -0,0 +1,126 @@
+package kit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+const testInvoiceAsset = "c6d0c728-2624-429b-8e0d-d9d19b6592fa"
+
+// newTestInvoice 收款人为 testPoolRecipient, 两个条目: testPoolAsset 1 和 testInvoiceAsset 2
+func newTestInvoice(t *testing.T) (*kit.MixinInvoiceWrapper, []string) {
+	t.Helper()
+
+	iw, err := kit.NewMixinInvoiceUserIdE(testPoolRecipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	traces := []string{mixin.RandomTraceID(), mixin.RandomTraceID()}
+	if err := iw.AddEntryIndex(traces[0], testPoolAsset, decimal.NewFromInt(1), "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := iw.AddEntryIndex(traces[1], testInvoiceAsset, decimal.NewFromInt(2), "", nil); err != nil {
+		t.Fatal(err)
+	}
+	return iw, traces
+}
+
+func payInvoiceEntry(t *testing.T, client *kit.ClientWrapper, traceId, assetId string, amount int64) {
+	t.Helper()
+
+	if _, err := client.TransferOne(context.Background(), &kit.TransferOneRequest{
+		RequestId: traceId,
+		AssetId:   assetId,
+		Member:    testPoolRecipient,
+		Amount:    decimal.NewFromInt(amount),
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInvoiceTracker_Poll(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	client := server.NewClientWrapper(t)
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+
+	// 另一个机器人支付第一个条目
+	payer := server.AddBot(t)
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10), payer.AppID)
+
+	iw, traces := newTestInvoice(t)
+	tracker, err := kit.NewInvoiceTracker(client, iw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	status, err := tracker.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != kit.InvoicePaymentUnpaid {
+		t.Errorf("status = %s, want unpaid", status.Status)
+	}
+
+	payInvoiceEntry(t, server.NewBotClientWrapper(t, payer), traces[0], testPoolAsset, 1)
+	// 第二个条目的 trace id 被用于其他资产的转账, 不计入
+	payInvoiceEntry(t, client, traces[1], testPoolAsset, 2)
+
+	for i := 0; i < 2; i++ {
+		if status, err = tracker.Poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if status.Status != kit.InvoicePaymentPartiallyPaid {
+		t.Errorf("status = %s, want partially paid", status.Status)
+	}
+	if e := status.Entries[0]; e.Status != kit.InvoicePaymentPaid || !e.PaidAmount.Equal(decimal.NewFromInt(1)) || len(e.TransactionHashes) != 1 {
+		t.Errorf("entry 0 = %+v", e)
+	}
+	if e := status.Entries[1]; e.Status != kit.InvoicePaymentUnpaid || !e.PaidAmount.IsZero() {
+		t.Errorf("entry 1 = %+v", e)
+	}
+}
+
+func TestInvoiceTracker_Wait(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	client := server.NewClientWrapper(t)
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+	server.Deposit(testInvoiceAsset, decimal.NewFromInt(10))
+
+	iw, traces := newTestInvoice(t)
+	tracker, err := kit.NewInvoiceTracker(client, iw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未支付完成时等到 ctx 结束
+	payInvoiceEntry(t, client, traces[0], testPoolAsset, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	status, err := tracker.Wait(ctx, 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) || status.Status != kit.InvoicePaymentPartiallyPaid {
+		t.Fatalf("Wait() = %v, %v, want partially paid and deadline exceeded", status, err)
+	}
+
+	payInvoiceEntry(t, client, traces[1], testInvoiceAsset, 2)
+	if status, err = tracker.Wait(context.Background(), 10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != kit.InvoicePaymentPaid {
+		t.Errorf("status = %s, want paid", status.Status)
+	}
+	// 本机器人支付的条目同时出现在收款人的 utxo 和交易请求中, 只计入一次
+	for i, e := range status.Entries {
+		if !e.PaidAmount.Equal(e.Amount) || len(e.TransactionHashes) != 1 {
+			t.Errorf("entry %d = %+v", i, e)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"filippo.io/edwards25519"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	ErrKeystorePassphrase  = errors.New("invalid keystore passphrase")
+	ErrUnsupportedKeystore = errors.New("unsupported keystore")
+)
+
+const (
+	KeystoreVersion = 1
+
+	KeystoreKDFScrypt   = "scrypt"
+	KeystoreKDFArgon2id = "argon2id"
+	KeystoreCipher      = "aes-256-gcm"
+
+	keystoreKeyLen  = 32
+	keystoreSaltLen = 16
+
+	// keystore 文件不可信, KDF 参数超过上限时拒绝, 避免打开时耗尽内存或 CPU
+	keystoreMaxScryptMemory = 1 << 30 // scrypt 占用 128*N*r 字节
+	keystoreMaxScryptCost   = 1 << 24 // N*r*p
+	keystoreMaxArgon2Memory = 1 << 20 // KiB
+	keystoreMaxArgon2Time   = 16
+	keystoreMaxArgon2Thread = 64
+)
+
+// EncryptedKeystore 加密的 keystore, app_id 等公开字段明文保存,
+// session_private_key 和 spend_key 使用口令派生的密钥 AES-GCM 加密
+type EncryptedKeystore struct {
+	Version         int            `json:"version"`
+	AppID           string         `json:"app_id"`
+	SessionID       string         `json:"session_id"`
+	ServerPublicKey string         `json:"server_public_key"`
+	Crypto          KeystoreCrypto `json:"crypto"`
+}
+
+type KeystoreCrypto struct {
+	KDF        string            `json:"kdf"`
+	KDFParams  KeystoreKDFParams `json:"kdf_params"`
+	Cipher     string            `json:"cipher"`
+	Nonce      string            `json:"nonce"`
+	Ciphertext string            `json:"ciphertext"`
+}
+
+// KeystoreKDFParams scrypt 使用 N, R, P; argon2id 使用 Time, Memory(KiB), Threads
+type KeystoreKDFParams struct {
+	Salt    string `json:"salt"`
+	N       int    `json:"n,omitempty"`
+	R       int    `json:"r,omitempty"`
+	P       int    `json:"p,omitempty"`
+	Time    uint32 `json:"time,omitempty"`
+	Memory  uint32 `json:"memory,omitempty"`
+	Threads uint8  `json:"threads,omitempty"`
+}
+
+type keystoreSecrets struct {
+	SessionPrivateKey string `json:"session_private_key"`
+	SpendKey          string `json:"spend_key"`
+}
+
+// KeystoreOption 定义 EncryptConfig 的 KDF 参数
+type KeystoreOption func(*KeystoreCrypto)
+
+// WithScrypt 使用 scrypt 派生密钥, 默认 N=1<<17, r=8, p=1
+func WithScrypt(n, r, p int) KeystoreOption {
+	return func(c *KeystoreCrypto) {
+		c.KDF = KeystoreKDFScrypt
+		c.KDFParams.N, c.KDFParams.R, c.KDFParams.P = n, r, p
+		c.KDFParams.Time, c.KDFParams.Memory, c.KDFParams.Threads = 0, 0, 0
+	}
+}
+
+// WithArgon2id 使用 argon2id 派生密钥, memory 单位为 KiB
+func WithArgon2id(time, memory uint32, threads uint8) KeystoreOption {
+	return func(c *KeystoreCrypto) {
+		c.KDF = KeystoreKDFArgon2id
+		c.KDFParams.Time, c.KDFParams.Memory, c.KDFParams.Threads = time, memory, threads
+		c.KDFParams.N, c.KDFParams.R, c.KDFParams.P = 0, 0, 0
+	}
+}
+
+// EncryptConfig 使用 passphrase 加密 config 中的 session_private_key 和 spend_key
+func EncryptConfig(config *Config, passphrase []byte, opts ...KeystoreOption) (*EncryptedKeystore, error) {
+	if config == nil {
+		return nil, ErrConfigNil
+	}
+
+	c := KeystoreCrypto{Cipher: KeystoreCipher}
+	WithScrypt(1<<17, 8, 1)(&c)
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	salt := make([]byte, keystoreSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	c.KDFParams.Salt = hex.EncodeToString(salt)
+
+	key, err := c.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key)
+
+	aead, err := newKeystoreAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := json.Marshal(keystoreSecrets{
+		SessionPrivateKey: config.SessionPrivateKey,
+		SpendKey:          config.SpendKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(plaintext)
+
+	ks := &EncryptedKeystore{
+		Version:         KeystoreVersion,
+		AppID:           config.AppID,
+		SessionID:       config.SessionID,
+		ServerPublicKey: config.ServerPublicKey,
+		Crypto:          c,
+	}
+	ks.Crypto.Nonce = hex.EncodeToString(nonce)
+	ks.Crypto.Ciphertext = hex.EncodeToString(aead.Seal(nil, nonce, plaintext, ks.additionalData()))
+	return ks, nil
+}
+
+// Decrypt 使用 passphrase 解密, 口令错误或内容被篡改时返回 ErrKeystorePassphrase
+func (ks *EncryptedKeystore) Decrypt(passphrase []byte) (*Config, error) {
+	plaintext, err := ks.decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(plaintext)
+
+	var secrets keystoreSecrets
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("decode keystore secrets: %w", err)
+	}
+
+	return &Config{
+		AppID:             ks.AppID,
+		SessionID:         ks.SessionID,
+		ServerPublicKey:   ks.ServerPublicKey,
+		SessionPrivateKey: secrets.SessionPrivateKey,
+		SpendKey:          secrets.SpendKey,
+	}, nil
+}
+
+// DecryptSession 与 Decrypt 相同, 但不解密 spend_key, 返回的 Config.SpendKey 为空;
+// 配合 WithEncryptedSpendKey 使用, spend key 不会以 string 的形式留在内存中
+func (ks *EncryptedKeystore) DecryptSession(passphrase []byte) (*Config, error) {
+	plaintext, err := ks.decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(plaintext)
+
+	var secrets struct {
+		SessionPrivateKey string `json:"session_private_key"`
+	}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("decode keystore secrets: %w", err)
+	}
+
+	return &Config{
+		AppID:             ks.AppID,
+		SessionID:         ks.SessionID,
+		ServerPublicKey:   ks.ServerPublicKey,
+		SessionPrivateKey: secrets.SessionPrivateKey,
+	}, nil
+}
+
+// DecryptSpendKey 解密并直接解析出 spend key, 使用 spendPublicKey 校验, 中间的明文和派生密钥在返回前清零.
+// 使用完毕后调用 ZeroKey 清除返回的 key
+func (ks *EncryptedKeystore) DecryptSpendKey(passphrase []byte, spendPublicKey string) (mixinnet.Key, error) {
+	plaintext, err := ks.decrypt(passphrase)
+	if err != nil {
+		return mixinnet.Key{}, err
+	}
+	defer zeroBytes(plaintext)
+
+	var secrets struct {
+		SpendKey json.RawMessage `json:"spend_key"`
+	}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return mixinnet.Key{}, fmt.Errorf("decode keystore secrets: %w", err)
+	}
+	defer zeroBytes(secrets.SpendKey)
+
+	raw := bytes.Trim(secrets.SpendKey, `"`)
+	return parseSpendKey(raw, spendPublicKey)
+}
+
+// parseSpendKey 与 mixinnet.ParseKeyWithPub 相同, 但不产生 string 形式的私钥
+func parseSpendKey(hexKey []byte, publicKey string) (mixinnet.Key, error) {
+	b := make([]byte, hex.DecodedLen(len(hexKey)))
+	defer zeroBytes(b)
+	if _, err := hex.Decode(b, hexKey); err != nil {
+		return mixinnet.Key{}, fmt.Errorf("invalid spend key: %w", err)
+	}
+
+	var candidates []mixinnet.Key
+	switch len(b) {
+	case 32:
+		candidates = append(candidates, mixinnet.Key(b), keyFromSeed(b))
+	case 64:
+		candidates = append(candidates, keyFromSeed(b[:32]))
+	default:
+		return mixinnet.Key{}, fmt.Errorf("invalid spend key size %d", len(b))
+	}
+
+	var found mixinnet.Key
+	for i := range candidates {
+		if !found.HasValue() && candidates[i].Public().String() == publicKey {
+			found = candidates[i]
+		}
+		ZeroKey(&candidates[i])
+	}
+	if !found.HasValue() {
+		return mixinnet.Key{}, errors.New("spend key does not match spend public key")
+	}
+	return found, nil
+}
+
+// keyFromSeed 见 mixinnet.KeyFromSeed
+func keyFromSeed(seed []byte) mixinnet.Key {
+	h := sha512.Sum512(seed[:32])
+	defer zeroBytes(h[:])
+
+	var wide [64]byte
+	defer zeroBytes(wide[:])
+	copy(wide[:], h[:32])
+	wide[0] &= 248
+	wide[31] &= 63
+	wide[31] |= 64
+
+	var key mixinnet.Key
+	s, err := edwards25519.NewScalar().SetUniformBytes(wide[:])
+	if err != nil {
+		return key
+	}
+	copy(key[:], s.Bytes())
+	return key
+}
+
+func (ks *EncryptedKeystore) decrypt(passphrase []byte) ([]byte, error) {
+	if ks.Version != KeystoreVersion || ks.Crypto.Cipher != KeystoreCipher {
+		return nil, fmt.Errorf("%w: version %d, cipher %q", ErrUnsupportedKeystore, ks.Version, ks.Crypto.Cipher)
+	}
+
+	key, err := ks.Crypto.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key)
+
+	aead, err := newKeystoreAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(ks.Crypto.Nonce)
+	if err != nil || len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrUnsupportedKeystore)
+	}
+	ciphertext, err := hex.DecodeString(ks.Crypto.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ciphertext", ErrUnsupportedKeystore)
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, ks.additionalData())
+	if err != nil {
+		return nil, ErrKeystorePassphrase
+	}
+	return plaintext, nil
+}
+
+// additionalData 明文字段参与认证, 防止被替换
+func (ks *EncryptedKeystore) additionalData() []byte {
+	return []byte(ks.AppID + ks.SessionID + ks.ServerPublicKey)
+}
+
+func (c *KeystoreCrypto) deriveKey(passphrase []byte) ([]byte, error) {
+	salt, err := hex.DecodeString(c.KDFParams.Salt)
+	if err != nil || len(salt) == 0 {
+		return nil, fmt.Errorf("%w: invalid salt", ErrUnsupportedKeystore)
+	}
+
+	p := c.KDFParams
+	switch c.KDF {
+	case KeystoreKDFScrypt:
+		// 分别检查后再相乘, 避免溢出
+		if p.N <= 1 || p.R <= 0 || p.P <= 0 || p.N > keystoreMaxScryptMemory/128 || p.P > keystoreMaxScryptCost ||
+			128*p.N > keystoreMaxScryptMemory/p.R || p.N*p.R > keystoreMaxScryptCost/p.P {
+			return nil, fmt.Errorf("%w: scrypt params n=%d r=%d p=%d out of range", ErrUnsupportedKeystore, p.N, p.R, p.P)
+		}
+		return scrypt.Key(passphrase, salt, p.N, p.R, p.P, keystoreKeyLen)
+	case KeystoreKDFArgon2id:
+		if p.Time == 0 || p.Memory == 0 || p.Threads == 0 ||
+			p.Time > keystoreMaxArgon2Time || p.Memory > keystoreMaxArgon2Memory || p.Threads > keystoreMaxArgon2Thread {
+			return nil, fmt.Errorf("%w: argon2id params time=%d memory=%d threads=%d out of range", ErrUnsupportedKeystore, p.Time, p.Memory, p.Threads)
+		}
+		return argon2.IDKey(passphrase, salt, p.Time, p.Memory, p.Threads, keystoreKeyLen), nil
+	default:
+		return nil, fmt.Errorf("%w: kdf %q", ErrUnsupportedKeystore, c.KDF)
+	}
+}
+
+func newKeystoreAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// LoadEncryptedKeystore 读取加密的 keystore 文件
+func LoadEncryptedKeystore(path string) (*EncryptedKeystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ks EncryptedKeystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("decode keystore: %w", err)
+	}
+	return &ks, nil
+}
+
+// WriteFile 以 0600 权限写入 path
+func (ks *EncryptedKeystore) WriteFile(path string) error {
+	data, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// WithEncryptedConfigFile 从加密的 keystore 文件加载, 口令从 passphrase 的 keystore_passphrase 读取.
+// 解密后的 spend key 保存在 Config.SpendKey (string) 中, 无法清零; 见 WithEncryptedSessionFile
+func WithEncryptedConfigFile(path string, passphrase SecretProvider) ConfigOption {
+	return withEncryptedFile(path, passphrase, (*EncryptedKeystore).Decrypt)
+}
+
+// WithEncryptedSessionFile 与 WithEncryptedConfigFile 相同, 但不加载 spend_key;
+// spend key 由 ClientWrapper 的 WithEncryptedSpendKey 在第一次使用时解密
+func WithEncryptedSessionFile(path string, passphrase SecretProvider) ConfigOption {
+	return withEncryptedFile(path, passphrase, (*EncryptedKeystore).DecryptSession)
+}
+
+func withEncryptedFile(path string, passphrase SecretProvider, decrypt func(*EncryptedKeystore, []byte) (*Config, error)) ConfigOption {
+	return func(l *configLoader) {
+		l.sources = append(l.sources, func(ctx context.Context, config *Config) error {
+			ks, err := LoadEncryptedKeystore(path)
+			if err != nil {
+				return err
+			}
+			secret, err := passphrase.Secret(ctx, "keystore_passphrase")
+			if err != nil {
+				return fmt.Errorf("load keystore passphrase: %w", err)
+			}
+			c, err := decrypt(ks, []byte(secret))
+			if err != nil {
+				return err
+			}
+			config.merge(c)
+			return nil
+		})
+	}
+}
+
+// ZeroKey 清除 key 的内容
+func ZeroKey(key *mixinnet.Key) {
+	zeroBytes(key[:])
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
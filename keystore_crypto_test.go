@@ -0,0 +1,198 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+)
+
+func TestEncryptConfig(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x03}, 32)
+	spendKey, err := mixinnet.KeyFromSeed(hex.EncodeToString(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &Config{
+		AppID:             "app",
+		SessionID:         "session",
+		ServerPublicKey:   "server",
+		SessionPrivateKey: "private",
+		SpendKey:          hex.EncodeToString(seed),
+	}
+	passphrase := []byte("correct horse battery staple")
+
+	tests := []struct {
+		name string
+		opt  KeystoreOption
+	}{
+		{"scrypt", WithScrypt(1<<10, 8, 1)},
+		{"argon2id", WithArgon2id(1, 1024, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := EncryptConfig(config, passphrase, tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ks.Crypto.KDF != tt.name {
+				t.Errorf("kdf = %s, want %s", ks.Crypto.KDF, tt.name)
+			}
+
+			path := filepath.Join(t.TempDir(), "keystore.json")
+			if err := ks.WriteFile(path); err != nil {
+				t.Fatal(err)
+			}
+			ks, err = LoadEncryptedKeystore(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ks.Decrypt(passphrase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != *config {
+				t.Errorf("Decrypt() = %+v, want %+v", *got, *config)
+			}
+
+			key, err := ks.DecryptSpendKey(passphrase, spendKey.Public().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key != spendKey {
+				t.Errorf("DecryptSpendKey() = %s, want %s", key, spendKey)
+			}
+			ZeroKey(&key)
+			if key.HasValue() {
+				t.Error("ZeroKey() did not clear key")
+			}
+
+			if _, err := ks.Decrypt([]byte("wrong")); !errors.Is(err, ErrKeystorePassphrase) {
+				t.Errorf("Decrypt(wrong) error = %v", err)
+			}
+
+			ks.AppID = "other"
+			if _, err := ks.Decrypt(passphrase); !errors.Is(err, ErrKeystorePassphrase) {
+				t.Errorf("Decrypt(tampered) error = %v", err)
+			}
+		})
+	}
+}
+
+func TestWithEncryptedConfigFile(t *testing.T) {
+	config := &Config{AppID: "app", SessionID: "session", SpendKey: "spend"}
+	ks, err := EncryptConfig(config, []byte("passphrase"), WithScrypt(1<<10, 8, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "keystore.enc.json")
+	if err := ks.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(prefix string) (*Config, error) {
+		return LoadConfig(context.Background(),
+			WithEncryptedConfigFile(path, EnvSecretProvider{Prefix: prefix}),
+			WithConfigEnv("TEST_MIXIN_"),
+		)
+	}
+	t.Setenv("TEST_MIXIN_KEYSTORE_PASSPHRASE", "passphrase")
+	t.Setenv("TEST_MIXIN_SESSION_ID", "session-from-env")
+	got, err := load("TEST_MIXIN_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 之后的来源覆盖 keystore 中的字段
+	if want := (Config{AppID: "app", SessionID: "session-from-env", SpendKey: "spend"}); *got != want {
+		t.Errorf("LoadConfig() = %+v, want %+v", *got, want)
+	}
+
+	t.Setenv("TEST_WRONG_KEYSTORE_PASSPHRASE", "wrong")
+	if _, err := load("TEST_WRONG_"); !errors.Is(err, ErrKeystorePassphrase) {
+		t.Errorf("LoadConfig(wrong passphrase) error = %v", err)
+	}
+	if _, err := load("TEST_MISSING_"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("LoadConfig(missing passphrase) error = %v", err)
+	}
+}
+
+func TestEncryptedKeystore_KDFLimits(t *testing.T) {
+	ks, err := EncryptConfig(&Config{AppID: "app", SpendKey: "spend"}, []byte("passphrase"), WithScrypt(1<<10, 8, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 来自不可信文件的参数超过上限时直接拒绝, 不会分配内存
+	tests := []struct {
+		name   string
+		kdf    string
+		params KeystoreKDFParams
+	}{
+		{"scrypt n", KeystoreKDFScrypt, KeystoreKDFParams{N: 1 << 30, R: 8, P: 1}},
+		{"scrypt memory", KeystoreKDFScrypt, KeystoreKDFParams{N: 1 << 20, R: 64, P: 1}},
+		{"scrypt cost", KeystoreKDFScrypt, KeystoreKDFParams{N: 1 << 17, R: 8, P: 1 << 10}},
+		{"argon2id memory", KeystoreKDFArgon2id, KeystoreKDFParams{Time: 1, Memory: 1 << 30, Threads: 1}},
+		{"argon2id time", KeystoreKDFArgon2id, KeystoreKDFParams{Time: 1 << 20, Memory: 1024, Threads: 1}},
+		{"argon2id threads", KeystoreKDFArgon2id, KeystoreKDFParams{Time: 1, Memory: 1024, Threads: 255}},
+	}
+	for _, tt := range tests {
+		bad := *ks
+		bad.Crypto.KDF = tt.kdf
+		tt.params.Salt = ks.Crypto.KDFParams.Salt
+		bad.Crypto.KDFParams = tt.params
+		if _, err := bad.Decrypt([]byte("passphrase")); !errors.Is(err, ErrUnsupportedKeystore) {
+			t.Errorf("%s: Decrypt() error = %v, want ErrUnsupportedKeystore", tt.name, err)
+		}
+	}
+}
+
+func TestWithEncryptedSpendKey(t *testing.T) {
+	server, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendKey := mixinnet.GenerateKey(rand.Reader)
+	ks, err := EncryptConfig(&Config{
+		AppID:             testRouteAppID,
+		SessionID:         "0a6d4c2e-1b2f-4f0e-8a77-0f6c7a1e5b3d",
+		ServerPublicKey:   hex.EncodeToString(server),
+		SessionPrivateKey: hex.EncodeToString(bytes.Repeat([]byte{1}, ed25519.SeedSize)),
+		SpendKey:          spendKey.String(),
+	}, []byte("passphrase"), WithScrypt(1<<10, 8, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "keystore.enc.json")
+	if err := ks.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEST_MIXIN_KEYSTORE_PASSPHRASE", "passphrase")
+	config, err := LoadConfig(context.Background(), WithEncryptedSessionFile(path, EnvSecretProvider{Prefix: "TEST_MIXIN_"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.SpendKey != "" || config.SessionPrivateKey == "" {
+		t.Fatalf("LoadConfig() = %+v, want session only", *config)
+	}
+
+	passphrase := []byte("passphrase")
+	c, err := NewMixinClientWrapper(config, WithEncryptedSpendKey(ks, passphrase), WithSpendPublicKey(spendKey.Public().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.SpendKey != spendKey || c.passphrase != nil || c.keystore != nil {
+		t.Errorf("SpendKey = %s, want %s; passphrase and keystore should be cleared", c.SpendKey, spendKey)
+	}
+
+	if _, err := NewMixinClientWrapper(config, WithEncryptedSpendKey(ks, []byte("wrong")), WithSpendPublicKey(spendKey.Public().String())); !errors.Is(err, ErrKeystorePassphrase) {
+		t.Errorf("NewMixinClientWrapper(wrong passphrase) error = %v", err)
+	}
+}
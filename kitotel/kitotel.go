@@ -0,0 +1,122 @@
+// Package kitotel 将 kit.Tracer 和 kit.Meter 接到 OpenTelemetry:
+//
+//	client, err := kit.NewMixinClientWrapper(config,
+//		kit.WithTracer(kitotel.NewTracer(otel.GetTracerProvider())),
+//		kit.WithMeter(kitotel.NewMeter(otel.GetMeterProvider())),
+//	)
+//
+// 单独的 module, 不使用时 mixin-kit-go 不依赖 OpenTelemetry.
+package kitotel
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName tracer 和 meter 的 instrumentation scope
+const ScopeName = "github.com/DomeLiquid/mixin-kit-go"
+
+// Tracer 实现 kit.Tracer
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer tp 为 nil 时使用 otel.GetTracerProvider()
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: tp.Tracer(ScopeName)}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...kit.Attr) (context.Context, kit.Span) {
+	ctx, s := t.tracer.Start(ctx, name, trace.WithAttributes(keyValues(attrs)...))
+	return ctx, span{s}
+}
+
+type span struct {
+	trace.Span
+}
+
+func (s span) SetAttributes(attrs ...kit.Attr) {
+	s.Span.SetAttributes(keyValues(attrs)...)
+}
+
+func (s span) End(err error) {
+	if err != nil {
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	}
+	s.Span.End()
+}
+
+// Meter 实现 kit.Meter, 计数器为 Float64Counter, 耗时为单位秒的 Float64Histogram; 指标名称不变
+type Meter struct {
+	meter metric.Meter
+
+	mu         sync.Mutex
+	counters   map[string]metric.Float64Counter
+	histograms map[string]metric.Float64Histogram
+}
+
+// NewMeter mp 为 nil 时使用 otel.GetMeterProvider()
+func NewMeter(mp metric.MeterProvider) *Meter {
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	return &Meter{
+		meter:      mp.Meter(ScopeName),
+		counters:   map[string]metric.Float64Counter{},
+		histograms: map[string]metric.Float64Histogram{},
+	}
+}
+
+func (m *Meter) AddCounter(ctx context.Context, name string, value float64, attrs ...kit.Attr) {
+	m.mu.Lock()
+	counter, ok := m.counters[name]
+	if !ok {
+		var err error
+		if counter, err = m.meter.Float64Counter(name); err != nil {
+			m.mu.Unlock()
+			otel.Handle(err)
+			return
+		}
+		m.counters[name] = counter
+	}
+	m.mu.Unlock()
+
+	counter.Add(ctx, value, metric.WithAttributes(keyValues(attrs)...))
+}
+
+func (m *Meter) ObserveDuration(ctx context.Context, name string, d time.Duration, attrs ...kit.Attr) {
+	m.mu.Lock()
+	histogram, ok := m.histograms[name]
+	if !ok {
+		var err error
+		if histogram, err = m.meter.Float64Histogram(name, metric.WithUnit("s")); err != nil {
+			m.mu.Unlock()
+			otel.Handle(err)
+			return
+		}
+		m.histograms[name] = histogram
+	}
+	m.mu.Unlock()
+
+	histogram.Record(ctx, d.Seconds(), metric.WithAttributes(keyValues(attrs)...))
+}
+
+func keyValues(attrs []kit.Attr) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, len(attrs))
+	for i, a := range attrs {
+		kvs[i] = attribute.String(a.Key, a.Value)
+	}
+	return kvs
+}
@@ -0,0 +1,90 @@
+package kitotel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	_ kit.Tracer = (*Tracer)(nil)
+	_ kit.Meter  = (*Meter)(nil)
+)
+
+func TestTracer(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	ctx, parent := tracer.Start(context.Background(), "mixin.transfer", kit.Attr{Key: "asset_id", Value: "xin"})
+	_, child := tracer.Start(ctx, "mixin.submit")
+	child.SetAttributes(kit.Attr{Key: "request_id", Value: "r1"})
+	child.End(errors.New("timeout"))
+	parent.End(nil)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	submit, transfer := spans[0], spans[1]
+	if submit.Name != "mixin.submit" || submit.Parent.SpanID() != transfer.SpanContext.SpanID() {
+		t.Errorf("submit span = %s, parent %s", submit.Name, submit.Parent.SpanID())
+	}
+	if submit.Status.Code != codes.Error || submit.Status.Description != "timeout" || len(submit.Events) != 1 {
+		t.Errorf("submit status = %+v, events = %d", submit.Status, len(submit.Events))
+	}
+	if len(submit.Attributes) != 1 || submit.Attributes[0] != attribute.String("request_id", "r1") {
+		t.Errorf("submit attributes = %v", submit.Attributes)
+	}
+	if transfer.Status.Code != codes.Unset || len(transfer.Attributes) != 1 || transfer.Attributes[0] != attribute.String("asset_id", "xin") {
+		t.Errorf("transfer span = %+v, attributes = %v", transfer.Status, transfer.Attributes)
+	}
+}
+
+func TestMeter(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := NewMeter(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	ctx := context.Background()
+	ok := kit.Attr{Key: "status", Value: kit.StatusOK}
+	meter.AddCounter(ctx, kit.MetricTransfers, 1, ok)
+	meter.AddCounter(ctx, kit.MetricTransfers, 2, ok)
+	meter.AddCounter(ctx, kit.MetricTransfers, 1, kit.Attr{Key: "status", Value: kit.StatusError})
+	meter.ObserveDuration(ctx, kit.MetricOperationDuration, 1500*time.Millisecond, kit.Attr{Key: "operation", Value: kit.OpSubmit})
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatal(err)
+	}
+	if len(rm.ScopeMetrics) != 1 || rm.ScopeMetrics[0].Scope.Name != ScopeName {
+		t.Fatalf("scope metrics = %+v", rm.ScopeMetrics)
+	}
+	metrics := map[string]metricdata.Metrics{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+
+	sum, _ := metrics[kit.MetricTransfers].Data.(metricdata.Sum[float64])
+	values := map[string]float64{}
+	for _, p := range sum.DataPoints {
+		status, _ := p.Attributes.Value("status")
+		values[status.AsString()] = p.Value
+	}
+	if !sum.IsMonotonic || values[kit.StatusOK] != 3 || values[kit.StatusError] != 1 {
+		t.Errorf("%s = %v", kit.MetricTransfers, values)
+	}
+
+	m := metrics[kit.MetricOperationDuration]
+	histogram, _ := m.Data.(metricdata.Histogram[float64])
+	if m.Unit != "s" || len(histogram.DataPoints) != 1 || histogram.DataPoints[0].Sum != 1.5 || histogram.DataPoints[0].Count != 1 {
+		t.Errorf("%s = %+v", kit.MetricOperationDuration, m)
+	}
+}
@@ -0,0 +1,499 @@
+// Package kittest 提供用于离线测试的 Mixin Route / Safe API 模拟服务
+package kittest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/shopspring/decimal"
+)
+
+// RouteSignatureWindow 允许的 MR-ACCESS-TIMESTAMP 与服务端时间的最大偏差
+const RouteSignatureWindow = 5 * time.Minute
+
+// QuoteFunc 自定义 /web3/quote 的报价
+type QuoteFunc func(req kit.QuoteRequest) (kit.QuoteResponseView, error)
+
+// RecordedRequest 服务端收到的请求
+type RecordedRequest struct {
+	AppID  string
+	Method string
+	URI    string
+	Body   []byte
+}
+
+type routeFailure struct {
+	status      int
+	code        int
+	description string
+}
+
+type routeOrder struct {
+	order  kit.SwapOrder
+	states []kit.SwapOrderState
+}
+
+// RouteServer 基于 httptest 的 Mixin Route 模拟服务, 实现
+//
+//	GET  /web3/tokens
+//	GET  /web3/quote
+//	POST /web3/swap
+//	GET  /web3/swap/orders/:id
+//	GET  /markets, /markets/:id, /markets/:id/price-history
+//
+// 所有请求都会校验 MR-ACCESS-SIGN, 机器人需要先通过 NewBot 或 AddBot 注册.
+type RouteServer struct {
+	*httptest.Server
+
+	key ed25519.PrivateKey
+	now func() time.Time
+
+	mu          sync.Mutex
+	bots        map[string]*kit.RouteSigner
+	tokens      []kit.TokenView
+	markets     []kit.MarketAssetInfo
+	histories   map[string]kit.HistoricalPrice
+	quote       QuoteFunc
+	quotes      map[string]kit.QuoteResponseView
+	orders      map[string]*routeOrder
+	progression []kit.SwapOrderState
+	failures    map[string][]routeFailure
+	handlers    map[string]http.HandlerFunc
+	requests    []RecordedRequest
+}
+
+// NewRouteServer 启动模拟服务, 测试结束时自动关闭
+func NewRouteServer(tb testing.TB) *RouteServer {
+	tb.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	s := &RouteServer{
+		key:         key,
+		now:         time.Now,
+		bots:        map[string]*kit.RouteSigner{},
+		histories:   map[string]kit.HistoricalPrice{},
+		quotes:      map[string]kit.QuoteResponseView{},
+		orders:      map[string]*routeOrder{},
+		progression: []kit.SwapOrderState{kit.SwapOrderStatePending, kit.SwapOrderStateSuccess},
+		failures:    map[string][]routeFailure{},
+		handlers:    map[string]http.HandlerFunc{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// PublicKey Route 机器人的 session 公钥, 客户端用于计算签名
+func (s *RouteServer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// AddBot 注册机器人的 session 公钥
+func (s *RouteServer) AddBot(appID string, sessionPublicKey ed25519.PublicKey) error {
+	verifier, err := kit.NewRouteSigner(appID, hex.EncodeToString(s.key.Seed()), kit.StaticRoutePublicKey(sessionPublicKey))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bots[appID] = verifier
+	return nil
+}
+
+// NewBot 为 appID 生成 session 密钥并注册, 返回可用于 kit.WithRouteSigner 的签名器
+func (s *RouteServer) NewBot(tb testing.TB, appID string) *kit.RouteSigner {
+	tb.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	signer, err := kit.NewRouteSigner(appID, hex.EncodeToString(key.Seed()), kit.StaticRoutePublicKey(s.PublicKey()))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := s.AddBot(appID, signer.PublicKey()); err != nil {
+		tb.Fatal(err)
+	}
+	return signer
+}
+
+// Web3Client 返回指向模拟服务的 kit.Web3Client
+func (s *RouteServer) Web3Client(signer *kit.RouteSigner, opts ...kit.Web3ClientOption) kit.Web3Client {
+	opts = append([]kit.Web3ClientOption{kit.WithBaseURL(s.URL), kit.WithRouteSigner(signer)}, opts...)
+	return kit.NewWeb3Client(nil, opts...)
+}
+
+func (s *RouteServer) SetTokens(tokens ...kit.TokenView) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = tokens
+}
+
+// SetMarkets 设置行情, /markets/:id 按 coin_id 或 asset_ids 查找; 默认报价也使用其中的 current_price
+func (s *RouteServer) SetMarkets(markets ...kit.MarketAssetInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.markets = markets
+}
+
+// SetPriceHistory 设置 /markets/:id/price-history 的返回, id 为 coin_id 或 asset id
+func (s *RouteServer) SetPriceHistory(id string, history kit.HistoricalPrice) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.histories[id+":"+history.Type] = history
+}
+
+// SetQuote 自定义报价, 为 nil 时按行情价格换算, 没有行情时 1:1
+func (s *RouteServer) SetQuote(fn QuoteFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quote = fn
+}
+
+// SetOrderProgression 设置新订单的状态变化, 订单创建时为 created,
+// 每次 GET /web3/swap/orders/:id 返回当前状态后前进到下一个状态
+func (s *RouteServer) SetOrderProgression(states ...kit.SwapOrderState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progression = states
+}
+
+// SetOrderStates 修改已有订单的后续状态, 下一次查询返回 states[0]
+func (s *RouteServer) SetOrderStates(orderId string, states ...kit.SwapOrderState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderId]
+	if !ok || len(states) == 0 {
+		return fmt.Errorf("order %s not found", orderId)
+	}
+	o.order.State = states[0]
+	o.states = states[1:]
+	return nil
+}
+
+// Order 返回订单当前的状态, 不会推进状态
+func (s *RouteServer) Order(orderId string) (kit.SwapOrder, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderId]
+	if !ok {
+		return kit.SwapOrder{}, false
+	}
+	return o.order, true
+}
+
+// FailNext 下一次 method path 请求返回错误; status 为 200 时错误放在 200 响应的 error 中
+func (s *RouteServer) FailNext(method, path string, status, code int, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := method + " " + path
+	s.failures[key] = append(s.failures[key], routeFailure{status: status, code: code, description: description})
+}
+
+// Handle 用自定义 handler 替换 method path 的处理, 签名校验仍然生效
+func (s *RouteServer) Handle(method, path string, h http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method+" "+path] = h
+}
+
+// Requests 返回通过签名校验的请求
+func (s *RouteServer) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+func (s *RouteServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeRouteError(w, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	appID, err := s.verify(r, body)
+	if err != nil {
+		writeRouteError(w, http.StatusUnauthorized, 401, err.Error())
+		return
+	}
+
+	key := r.Method + " " + r.URL.Path
+
+	s.mu.Lock()
+	s.requests = append(s.requests, RecordedRequest{AppID: appID, Method: r.Method, URI: r.RequestURI, Body: body})
+	var failure *routeFailure
+	if q := s.failures[key]; len(q) > 0 {
+		failure, s.failures[key] = &q[0], q[1:]
+	}
+	handler := s.handlers[key]
+	s.mu.Unlock()
+
+	if failure != nil {
+		writeRouteError(w, failure.status, failure.code, failure.description)
+		return
+	}
+	if handler != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		handler(w, r)
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && path == "/web3/tokens":
+		s.mu.Lock()
+		tokens := append([]kit.TokenView{}, s.tokens...)
+		s.mu.Unlock()
+		writeRouteData(w, tokens)
+	case r.Method == http.MethodGet && path == "/web3/quote":
+		s.handleQuote(w, r)
+	case r.Method == http.MethodPost && path == "/web3/swap":
+		s.handleSwap(w, body, appID)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/web3/swap/orders/"):
+		s.handleOrder(w, strings.TrimPrefix(path, "/web3/swap/orders/"))
+	case r.Method == http.MethodGet && path == "/markets":
+		s.handleMarkets(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/markets/"):
+		s.handleMarket(w, r, strings.TrimPrefix(path, "/markets/"))
+	default:
+		writeRouteError(w, http.StatusNotFound, 404, "not found")
+	}
+}
+
+// verify 校验签名并返回签名中的 app id
+func (s *RouteServer) verify(r *http.Request, body []byte) (string, error) {
+	ts, err := strconv.ParseInt(r.Header.Get(kit.HeaderAccessTimestamp), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s", kit.HeaderAccessTimestamp)
+	}
+	if d := s.now().Sub(time.Unix(ts, 0)); d > RouteSignatureWindow || d < -RouteSignatureWindow {
+		return "", fmt.Errorf("%s expired", kit.HeaderAccessTimestamp)
+	}
+
+	signature := r.Header.Get(kit.HeaderAccessSign)
+	b, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || len(b) <= 32 {
+		return "", fmt.Errorf("invalid %s", kit.HeaderAccessSign)
+	}
+	appID := string(b[:len(b)-32])
+
+	s.mu.Lock()
+	verifier, ok := s.bots[appID]
+	s.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("unknown app %s", appID)
+	}
+
+	if !verifier.Verify(r.Context(), ts, r.Method, r.RequestURI, body, signature) {
+		return "", fmt.Errorf("invalid %s", kit.HeaderAccessSign)
+	}
+	return appID, nil
+}
+
+func (s *RouteServer) marketPrice(assetId string) (decimal.Decimal, bool) {
+	for _, m := range s.markets {
+		if m.CoinID == assetId || slices.Contains(m.AssetIDS, assetId) {
+			return m.CurrentPrice, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+func (s *RouteServer) handleQuote(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	amount, err := decimal.NewFromString(q.Get("amount"))
+	if err != nil || !amount.IsPositive() {
+		writeRouteError(w, http.StatusBadRequest, 400, "invalid amount")
+		return
+	}
+	req := kit.QuoteRequest{
+		InputMint:  q.Get("inputMint"),
+		OutputMint: q.Get("outputMint"),
+		Amount:     amount,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var view kit.QuoteResponseView
+	if s.quote != nil {
+		if view, err = s.quote(req); err != nil {
+			writeRouteError(w, http.StatusBadRequest, 400, err.Error())
+			return
+		}
+	} else {
+		view = kit.QuoteResponseView{
+			InputMint:  req.InputMint,
+			InAmount:   req.Amount,
+			OutputMint: req.OutputMint,
+			OutAmount:  req.Amount,
+		}
+		inPrice, ok1 := s.marketPrice(req.InputMint)
+		outPrice, ok2 := s.marketPrice(req.OutputMint)
+		if ok1 && ok2 && outPrice.IsPositive() {
+			view.OutAmount = req.Amount.Mul(inPrice).DivRound(outPrice, 8)
+		}
+	}
+
+	if view.Payload == "" {
+		view.Payload = kit.GenUuidFromStrings("quote", strconv.Itoa(len(s.quotes)))
+	}
+	s.quotes[view.Payload] = view
+	writeRouteData(w, view)
+}
+
+func (s *RouteServer) handleSwap(w http.ResponseWriter, body []byte, appID string) {
+	var req kit.SwapRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeRouteError(w, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quote, ok := s.quotes[req.Payload]
+	if !ok {
+		writeRouteError(w, http.StatusBadRequest, 400, "invalid payload")
+		return
+	}
+	if req.InputMint != quote.InputMint || req.OutputMint != quote.OutputMint || !req.InputAmount.IsPositive() {
+		writeRouteError(w, http.StatusBadRequest, 400, "request does not match quote")
+		return
+	}
+
+	receive := quote.OutAmount
+	if !req.InputAmount.Equal(quote.InAmount) && quote.InAmount.IsPositive() {
+		receive = quote.OutAmount.Mul(req.InputAmount).DivRound(quote.InAmount, 8)
+	}
+
+	seq := strconv.Itoa(len(s.orders))
+	payer := req.Payer
+	if payer == "" {
+		payer = appID
+	}
+	order := kit.SwapOrder{
+		OrderId:        kit.GenUuidFromStrings("order", seq),
+		UserId:         payer,
+		AssetId:        req.InputMint,
+		ReceiveAssetId: req.OutputMint,
+		Amount:         req.InputAmount,
+		ReceiveAmount:  receive,
+		PaymentTraceId: kit.GenUuidFromStrings("payment", seq),
+		ReceiveTraceId: kit.GenUuidFromStrings("receive", seq),
+		State:          kit.SwapOrderStateCreated,
+		CreatedAt:      s.now().UTC(),
+	}
+	s.orders[order.OrderId] = &routeOrder{
+		order:  order,
+		states: append([]kit.SwapOrderState(nil), s.progression...),
+	}
+
+	query := url.Values{}
+	query.Set("asset", order.AssetId)
+	query.Set("amount", order.Amount.String())
+	query.Set("memo", order.OrderId)
+	query.Set("trace", order.PaymentTraceId)
+
+	quote.InAmount, quote.OutAmount = req.InputAmount, receive
+	writeRouteData(w, kit.SwapResponseView{
+		Tx:    "mixin://mixin.one/pay/" + kit.MixinRouteClientID + "?" + query.Encode(),
+		Quote: quote,
+	})
+}
+
+func (s *RouteServer) handleOrder(w http.ResponseWriter, orderId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[orderId]
+	if !ok {
+		writeRouteError(w, http.StatusNotFound, 404, "order not found")
+		return
+	}
+
+	order := o.order
+	if len(o.states) > 0 {
+		o.order.State, o.states = o.states[0], o.states[1:]
+	}
+	writeRouteData(w, order)
+}
+
+func (s *RouteServer) handleMarkets(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	page := []kit.MarketAssetInfo{}
+	if offset >= 0 && offset < len(s.markets) {
+		end := min(offset+limit, len(s.markets))
+		page = append(page, s.markets[offset:end]...)
+	}
+	writeRouteData(w, page)
+}
+
+func (s *RouteServer) handleMarket(w http.ResponseWriter, r *http.Request, rest string) {
+	id, sub, _ := strings.Cut(rest, "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch sub {
+	case "":
+		for _, m := range s.markets {
+			if m.CoinID == id || slices.Contains(m.AssetIDS, id) {
+				writeRouteData(w, m)
+				return
+			}
+		}
+	case "price-history":
+		if h, ok := s.histories[id+":"+r.URL.Query().Get("type")]; ok {
+			writeRouteData(w, h)
+			return
+		}
+	}
+	writeRouteError(w, http.StatusNotFound, 404, "market not found")
+}
+
+func writeRouteData(w http.ResponseWriter, data any) {
+	w.Header().Set(kit.HeaderContentType, kit.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+func writeRouteError(w http.ResponseWriter, status, code int, description string) {
+	w.Header().Set(kit.HeaderContentType, kit.ContentTypeJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": kit.Web3ErrorBody{Status: status, Code: code, Description: description},
+	})
+}
@@ -0,0 +1,119 @@
+package kittest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	testAppID    = "7fa6d0c1-2c7a-4d3e-9b1a-0c3f4e5d6a7b"
+	testAssetBTC = "c6d0c728-2624-429b-8e0d-d9d19b6592fa"
+	testAssetSOL = "64692c23-8971-4cf4-84a7-4dd1271dd887"
+)
+
+func TestRouteServer_Swap(t *testing.T) {
+	srv := NewRouteServer(t)
+	srv.SetMarkets(
+		kit.MarketAssetInfo{CoinID: "bitcoin", AssetIDS: []string{testAssetBTC}, CurrentPrice: decimal.NewFromInt(60000)},
+		kit.MarketAssetInfo{CoinID: "solana", AssetIDS: []string{testAssetSOL}, CurrentPrice: decimal.NewFromInt(150)},
+	)
+	c := srv.Web3Client(srv.NewBot(t, testAppID))
+	ctx := context.Background()
+
+	quote, err := kit.GetJSON[kit.QuoteResponseView](ctx, c, "/web3/quote", kit.QuoteRequest{
+		InputMint:  testAssetBTC,
+		OutputMint: testAssetSOL,
+		Amount:     decimal.NewFromInt(1),
+	}.ToQuery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !quote.OutAmount.Equal(decimal.NewFromInt(400)) {
+		t.Errorf("OutAmount = %s, want 400", quote.OutAmount)
+	}
+
+	swap, err := kit.PostJSON[kit.SwapResponseView](ctx, c, "/web3/swap", kit.SwapRequest{
+		Payer:       testAppID,
+		InputMint:   testAssetBTC,
+		InputAmount: decimal.RequireFromString("0.5"),
+		OutputMint:  testAssetSOL,
+		Payload:     quote.Payload,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := swap.DecodeTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Payee != kit.MixinRouteClientID || !tx.Amount.Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("DecodeTx() = %+v", tx)
+	}
+
+	for _, want := range []kit.SwapOrderState{kit.SwapOrderStateCreated, kit.SwapOrderStatePending, kit.SwapOrderStateSuccess, kit.SwapOrderStateSuccess} {
+		order, err := kit.GetJSON[kit.SwapOrder](ctx, c, "/web3/swap/orders/"+tx.OrderId, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if order.State != want {
+			t.Errorf("State = %s, want %s", order.State, want)
+		}
+	}
+}
+
+func TestRouteServer_Errors(t *testing.T) {
+	srv := NewRouteServer(t)
+	ctx := context.Background()
+
+	// 未注册的机器人签名不被接受
+	other := NewRouteServer(t)
+	var apiErr *kit.MixinOracleAPIError
+	_, err := kit.GetJSON[[]kit.TokenView](ctx, srv.Web3Client(other.NewBot(t, testAppID)), "/web3/tokens", "")
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unsigned request error = %v, want 401", err)
+	}
+
+	c := srv.Web3Client(srv.NewBot(t, testAppID))
+	srv.FailNext(http.MethodGet, "/web3/tokens", http.StatusOK, 10001, "scripted")
+	if _, err := kit.GetJSON[[]kit.TokenView](ctx, c, "/web3/tokens", ""); !errors.As(err, &apiErr) || apiErr.Code != 10001 {
+		t.Errorf("scripted error = %v, want code 10001", err)
+	}
+	if _, err := kit.GetJSON[[]kit.TokenView](ctx, c, "/web3/tokens", ""); err != nil {
+		t.Errorf("second request error = %v", err)
+	}
+	if got := len(srv.Requests()); got != 2 {
+		t.Errorf("len(Requests()) = %d, want 2", got)
+	}
+}
+
+func TestRouteServer_Markets(t *testing.T) {
+	srv := NewRouteServer(t)
+	srv.SetMarkets(
+		kit.MarketAssetInfo{CoinID: "bitcoin", AssetIDS: []string{testAssetBTC}},
+		kit.MarketAssetInfo{CoinID: "solana", AssetIDS: []string{testAssetSOL}},
+		kit.MarketAssetInfo{CoinID: "ethereum"},
+	)
+	market := kit.NewMarketClient(srv.Web3Client(srv.NewBot(t, testAppID)), kit.WithMarketPageLimit(2))
+	ctx := context.Background()
+
+	markets, err := market.ListAllMarkets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != 3 {
+		t.Errorf("len(ListAllMarkets()) = %d, want 3", len(markets))
+	}
+
+	info, err := market.GetAssetInfo(ctx, testAssetSOL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.CoinID != "solana" {
+		t.Errorf("CoinID = %s, want solana", info.CoinID)
+	}
+}
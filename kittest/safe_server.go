@@ -0,0 +1,663 @@
+package kittest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"filippo.io/edwards25519"
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"github.com/shopspring/decimal"
+)
+
+const safeDefaultListLimit = 500
+
+type safeBot struct {
+	user        *mixin.User
+	spendPublic mixinnet.Key
+}
+
+type safeRequest struct {
+	request *mixin.SafeTransactionRequest
+	hash    mixinnet.Hash
+	inputs  []*mixin.SafeUtxo
+}
+
+// SafeServer 基于 httptest 的 Mixin Safe API 模拟服务, 维护一个内存中的 UTXO 账本, 实现
+//
+//	GET  /me
+//	GET  /safe/assets/:id
+//	GET  /safe/outputs
+//	GET  /safe/snapshots
+//	POST /safe/keys
+//	POST /safe/transaction/requests
+//	POST /safe/transactions
+//	GET  /safe/transactions/:id
+//
+// 提交的交易会校验每个输入的签名, 输入被其他交易占用时返回 mixin.InputLocked.
+// 请求按 Authorization 中的 uid 区分机器人, AddBot 可以添加更多机器人.
+type SafeServer struct {
+	*httptest.Server
+
+	Config *kit.Config
+	User   *mixin.User
+
+	now func() time.Time
+
+	mu       sync.Mutex
+	sequence uint64
+	outputs  []*mixin.SafeUtxo
+	assets   map[mixinnet.Hash]string       // kernel asset id -> asset id
+	ghosts   map[string][]string            // ghost mask -> receivers
+	requests map[string]*safeRequest        // request id -> request
+	hashes   map[mixinnet.Hash]*safeRequest // transaction hash -> request
+	bots     map[string]*safeBot            // user id -> bot
+	failures map[string][]routeFailure
+}
+
+// NewSafeServer 启动模拟服务并生成一个机器人, 机器人的 keystore 在 Config 中, 测试结束时自动关闭
+func NewSafeServer(tb testing.TB) *SafeServer {
+	tb.Helper()
+
+	s := &SafeServer{
+		now:      time.Now,
+		assets:   map[mixinnet.Hash]string{},
+		ghosts:   map[string][]string{},
+		requests: map[string]*safeRequest{},
+		hashes:   map[mixinnet.Hash]*safeRequest{},
+		failures: map[string][]routeFailure{},
+		bots:     map[string]*safeBot{},
+	}
+	s.Config = s.AddBot(tb)
+	s.User = s.bots[s.Config.AppID].user
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// AddBot 生成一个新的机器人并返回它的 keystore
+func (s *SafeServer) AddBot(tb testing.TB) *kit.Config {
+	tb.Helper()
+
+	_, session, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	server, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	spendKey := mixinnet.GenerateKey(rand.Reader)
+
+	appID := mixin.RandomTraceID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.bots[appID] = &safeBot{
+		user: &mixin.User{
+			UserID:         appID,
+			FullName:       "kittest",
+			HasSafe:        true,
+			SpendPublicKey: spendKey.Public().String(),
+		},
+		spendPublic: spendKey.Public(),
+	}
+	return &kit.Config{
+		AppID:             appID,
+		SessionID:         mixin.RandomTraceID(),
+		ServerPublicKey:   hex.EncodeToString(server),
+		SessionPrivateKey: hex.EncodeToString(session.Seed()),
+		SpendKey:          spendKey.String(),
+	}
+}
+
+// UseApiHost 将 mixin-sdk-go 的全局 API 地址指向模拟服务, 测试结束时恢复.
+// mixin-sdk-go 使用全局的 http client, 因此使用 SafeServer 的测试不能并行执行.
+func (s *SafeServer) UseApiHost(tb testing.TB) {
+	tb.Helper()
+
+	client := mixin.GetRestyClient()
+	prev := client.BaseURL
+	client.SetBaseURL(s.URL)
+	tb.Cleanup(func() { client.SetBaseURL(prev) })
+}
+
+// NewClientWrapper 创建指向模拟服务的 ClientWrapper, 见 UseApiHost
+func (s *SafeServer) NewClientWrapper(tb testing.TB, opts ...kit.ClientWrapperOption) *kit.ClientWrapper {
+	tb.Helper()
+
+	return s.NewBotClientWrapper(tb, s.Config, opts...)
+}
+
+// NewBotClientWrapper 使用 AddBot 返回的 keystore 创建指向模拟服务的 ClientWrapper
+func (s *SafeServer) NewBotClientWrapper(tb testing.TB, config *kit.Config, opts ...kit.ClientWrapperOption) *kit.ClientWrapper {
+	tb.Helper()
+
+	s.UseApiHost(tb)
+	c, err := kit.NewMixinClientWrapper(config, opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return c
+}
+
+// KernelAssetID 模拟服务中 asset id 对应的 kernel asset id
+func KernelAssetID(assetId string) mixinnet.Hash {
+	return mixinnet.NewHash([]byte(assetId))
+}
+
+// Deposit 向 members 充值一个 UTXO, members 为空时充值给机器人
+func (s *SafeServer) Deposit(assetId string, amount decimal.Decimal, members ...string) *mixin.SafeUtxo {
+	if len(members) == 0 {
+		members = []string{s.User.UserID}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hash := mixinnet.NewHash([]byte(mixin.RandomTraceID()))
+	return s.addOutput(hash, 0, assetId, amount, members, 1, nil, "")
+}
+
+func (s *SafeServer) addOutput(hash mixinnet.Hash, index uint8, assetId string, amount decimal.Decimal, receivers []string, threshold uint8, senders []string, extra string) *mixin.SafeUtxo {
+	receivers = slices.Clone(receivers)
+	sort.Strings(receivers)
+
+	kernelAssetID := KernelAssetID(assetId)
+	s.assets[kernelAssetID] = assetId
+	s.sequence++
+
+	receiversHash, _ := mixinnet.HashFromString(mixinnet.HashMembers(slices.Clone(receivers)))
+	utxo := &mixin.SafeUtxo{
+		OutputID:           kit.GenUuidFromStrings(hash.String(), strconv.Itoa(int(index))),
+		TransactionHash:    hash,
+		OutputIndex:        index,
+		KernelAssetID:      kernelAssetID,
+		AssetID:            assetId,
+		Amount:             amount,
+		Senders:            senders,
+		SendersThreshold:   uint8(len(senders)),
+		ReceiversHash:      receiversHash,
+		ReceiversThreshold: threshold,
+		Receivers:          receivers,
+		Extra:              extra,
+		State:              mixin.SafeUtxoStateUnspent,
+		Sequence:           s.sequence,
+		CreatedAt:          s.now().UTC(),
+		UpdatedAt:          s.now().UTC(),
+	}
+	s.outputs = append(s.outputs, utxo)
+	return utxo
+}
+
+// Outputs 返回 members 的所有 UTXO (包括已花费的), members 为空时返回机器人的
+func (s *SafeServer) Outputs(members ...string) []mixin.SafeUtxo {
+	if len(members) == 0 {
+		members = []string{s.User.UserID}
+	}
+	hash := mixinnet.HashMembers(slices.Clone(members))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var outputs []mixin.SafeUtxo
+	for _, utxo := range s.outputs {
+		if utxo.ReceiversHash.String() == hash {
+			outputs = append(outputs, *utxo)
+		}
+	}
+	return outputs
+}
+
+// Balance 返回 members 未花费的 assetId 余额, members 为空时返回机器人的
+func (s *SafeServer) Balance(assetId string, members ...string) decimal.Decimal {
+	balance := decimal.Zero
+	for _, utxo := range s.Outputs(members...) {
+		if utxo.AssetID == assetId && utxo.State == mixin.SafeUtxoStateUnspent {
+			balance = balance.Add(utxo.Amount)
+		}
+	}
+	return balance
+}
+
+// FailNext 下一次 method path 请求返回 Mixin 错误
+func (s *SafeServer) FailNext(method, path string, code int, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := method + " " + path
+	s.failures[key] = append(s.failures[key], routeFailure{status: http.StatusAccepted, code: code, description: description})
+}
+
+func (s *SafeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// mixin-sdk-go 会校验响应的 X-Request-Id
+	w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		writeSafeError(w, http.StatusUnauthorized, mixin.Unauthorized, "unauthorized")
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeSafeError(w, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	key := r.Method + " " + path
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bot, ok := s.bots[tokenUserID(token)]
+	if !ok {
+		writeSafeError(w, http.StatusUnauthorized, mixin.Unauthorized, "unauthorized")
+		return
+	}
+
+	if q := s.failures[key]; len(q) > 0 {
+		s.failures[key] = q[1:]
+		writeSafeError(w, q[0].status, q[0].code, q[0].description)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && path == "/me":
+		writeSafeData(w, bot.user)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/safe/assets/"):
+		assetId := strings.TrimPrefix(path, "/safe/assets/")
+		writeSafeData(w, &mixin.SafeAsset{AssetID: assetId, KernelAssetID: KernelAssetID(assetId).String()})
+	case r.Method == http.MethodGet && path == "/safe/outputs":
+		s.handleListOutputs(w, r)
+	case r.Method == http.MethodGet && path == "/safe/snapshots":
+		s.handleListSnapshots(w, bot, r)
+	case r.Method == http.MethodPost && path == "/safe/keys":
+		s.handleGhostKeys(w, body)
+	case r.Method == http.MethodPost && path == "/safe/transaction/requests":
+		s.handleCreateRequests(w, bot, body)
+	case r.Method == http.MethodPost && path == "/safe/transactions":
+		s.handleSubmitRequests(w, bot, body)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/safe/transactions/"):
+		s.handleReadRequest(w, strings.TrimPrefix(path, "/safe/transactions/"))
+	default:
+		writeSafeError(w, http.StatusNotFound, mixin.EndpointNotFound, "not found")
+	}
+}
+
+func (s *SafeServer) handleListOutputs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	offset, _ := strconv.ParseUint(q.Get("offset"), 10, 64)
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 || limit > safeDefaultListLimit {
+		limit = safeDefaultListLimit
+	}
+	threshold, _ := strconv.Atoi(q.Get("threshold"))
+
+	outputs := make([]*mixin.SafeUtxo, 0)
+	for _, utxo := range s.outputs {
+		switch {
+		case utxo.ReceiversHash.String() != q.Get("members"):
+		case threshold > 0 && int(utxo.ReceiversThreshold) != threshold:
+		case q.Get("asset") != "" && utxo.AssetID != q.Get("asset"):
+		case q.Get("state") != "" && string(utxo.State) != q.Get("state"):
+		case utxo.Sequence < offset:
+		default:
+			outputs = append(outputs, utxo)
+		}
+	}
+
+	if q.Get("order") != "ASC" {
+		slices.Reverse(outputs)
+	}
+	if len(outputs) > limit {
+		outputs = outputs[:limit]
+	}
+	writeSafeData(w, outputs)
+}
+
+// handleListSnapshots 由机器人收到的 UTXO 生成 snapshot, 没有 sender 的 UTXO 视为充值
+func (s *SafeServer) handleListSnapshots(w http.ResponseWriter, bot *safeBot, r *http.Request) {
+	q := r.URL.Query()
+	offset, _ := time.Parse(time.RFC3339Nano, q.Get("offset"))
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 || limit > safeDefaultListLimit {
+		limit = safeDefaultListLimit
+	}
+
+	snapshots := make([]*mixin.SafeSnapshot, 0)
+	for _, utxo := range s.outputs {
+		switch {
+		case !slices.Equal(utxo.Receivers, []string{bot.user.UserID}):
+		case q.Get("asset") != "" && utxo.AssetID != q.Get("asset"):
+		case utxo.CreatedAt.Before(offset):
+		default:
+			hash := utxo.TransactionHash
+			snapshot := &mixin.SafeSnapshot{
+				SnapshotID:      utxo.OutputID,
+				UserID:          bot.user.UserID,
+				TransactionHash: &hash,
+				AssetID:         utxo.AssetID,
+				KernelAssetID:   utxo.KernelAssetID.String(),
+				Amount:          utxo.Amount,
+				CreatedAt:       utxo.CreatedAt,
+			}
+			switch len(utxo.Senders) {
+			case 0:
+				snapshot.Deposit = &mixin.SafeSnapshotDeposit{DepositHash: hash.String()}
+			case 1:
+				snapshot.OpponentID = utxo.Senders[0]
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+
+	if q.Get("order") != "ASC" {
+		slices.Reverse(snapshots)
+	}
+	if len(snapshots) > limit {
+		snapshots = snapshots[:limit]
+	}
+	writeSafeData(w, snapshots)
+}
+
+func (s *SafeServer) handleGhostKeys(w http.ResponseWriter, body []byte) {
+	var inputs []*mixin.GhostInput
+	if err := json.Unmarshal(body, &inputs); err != nil {
+		var req struct {
+			Keys []*mixin.GhostInput `json:"keys"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeSafeError(w, http.StatusBadRequest, 400, err.Error())
+			return
+		}
+		inputs = req.Keys
+	}
+
+	keys := make([]*mixin.GhostKeys, len(inputs))
+	for i, input := range inputs {
+		if len(input.Receivers) == 0 {
+			writeSafeError(w, http.StatusAccepted, mixin.InvalidReceivers, "invalid receivers")
+			return
+		}
+
+		mask := mixinnet.GenerateKey(rand.Reader).Public()
+		k := &mixin.GhostKeys{Mask: mask, Keys: make([]mixinnet.Key, len(input.Receivers))}
+		for j := range k.Keys {
+			k.Keys[j] = mixinnet.GenerateKey(rand.Reader).Public()
+		}
+		s.ghosts[mask.String()] = slices.Clone(input.Receivers)
+		keys[i] = k
+	}
+	writeSafeData(w, keys)
+}
+
+func (s *SafeServer) handleCreateRequests(w http.ResponseWriter, bot *safeBot, body []byte) {
+	var inputs []*mixin.SafeTransactionRequestInput
+	if err := json.Unmarshal(body, &inputs); err != nil {
+		writeSafeError(w, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	requests := make([]*mixin.SafeTransactionRequest, 0, len(inputs))
+	for _, input := range inputs {
+		req, code, err := s.createRequest(bot, input)
+		if err != nil {
+			writeSafeError(w, http.StatusAccepted, code, err.Error())
+			return
+		}
+		requests = append(requests, req.request)
+	}
+	writeSafeData(w, requests)
+}
+
+func (s *SafeServer) createRequest(bot *safeBot, input *mixin.SafeTransactionRequestInput) (*safeRequest, int, error) {
+	tx, err := mixinnet.TransactionFromRaw(input.RawTransaction)
+	if err != nil {
+		return nil, 400, fmt.Errorf("invalid raw transaction: %w", err)
+	}
+	hash, err := tx.TransactionHash()
+	if err != nil {
+		return nil, 400, err
+	}
+
+	if req, ok := s.requests[input.RequestID]; ok {
+		if req.hash != hash || req.request.UserID != bot.user.UserID {
+			return nil, mixin.InvalidTraceID, fmt.Errorf("request id %s already used", input.RequestID)
+		}
+		return req, 0, nil
+	}
+
+	var inputs []*mixin.SafeUtxo
+	for _, in := range tx.Inputs {
+		utxo := s.findOutput(*in.Hash, in.Index)
+		if utxo == nil || !slices.Contains(utxo.Receivers, bot.user.UserID) {
+			return nil, mixin.InvalidOutputKey, fmt.Errorf("input %s:%d not found", in.Hash, in.Index)
+		}
+		if utxo.State != mixin.SafeUtxoStateUnspent {
+			return nil, mixin.InputLocked, fmt.Errorf("input %s:%d is %s", in.Hash, in.Index, utxo.State)
+		}
+		if utxo.KernelAssetID != tx.Asset {
+			return nil, 400, fmt.Errorf("input %s:%d asset not matched", in.Hash, in.Index)
+		}
+		inputs = append(inputs, utxo)
+	}
+
+	amount := decimal.Zero
+	var receivers []*mixin.SafeTransactionReceiver
+	for _, out := range tx.Outputs {
+		members, ok := s.ghosts[out.Mask.String()]
+		if !ok {
+			return nil, mixin.InvalidOutputKey, fmt.Errorf("unknown output mask %s", out.Mask)
+		}
+		receivers = append(receivers, &mixin.SafeTransactionReceiver{Members: members, Threshold: outputThreshold(out)})
+		if !slices.Equal(members, []string{bot.user.UserID}) {
+			amount = amount.Add(decimal.RequireFromString(out.Amount.String()))
+		}
+	}
+
+	views := make([]mixinnet.Key, len(tx.Inputs))
+	for i := range views {
+		views[i] = mixinnet.GenerateKey(rand.Reader)
+	}
+
+	req := &safeRequest{
+		hash:   hash,
+		inputs: inputs,
+		request: &mixin.SafeTransactionRequest{
+			RequestID:        input.RequestID,
+			TransactionHash:  hash.String(),
+			UserID:           bot.user.UserID,
+			KernelAssetID:    tx.Asset,
+			AssetID:          tx.Asset,
+			Asset:            tx.Asset,
+			Amount:           amount,
+			CreatedAt:        s.now().UTC(),
+			UpdatedAt:        s.now().UTC(),
+			Extra:            hex.EncodeToString(tx.Extra),
+			Receivers:        receivers,
+			Senders:          []string{bot.user.UserID},
+			SendersThreshold: 1,
+			State:            mixin.SafeUtxoStateUnspent,
+			RawTransaction:   input.RawTransaction,
+			Views:            views,
+		},
+	}
+	s.requests[input.RequestID] = req
+	s.hashes[hash] = req
+	return req, 0, nil
+}
+
+func (s *SafeServer) findOutput(hash mixinnet.Hash, index uint8) *mixin.SafeUtxo {
+	for _, utxo := range s.outputs {
+		if utxo.TransactionHash == hash && utxo.OutputIndex == index {
+			return utxo
+		}
+	}
+	return nil
+}
+
+func outputThreshold(out *mixinnet.Output) uint8 {
+	if len(out.Script) == 3 {
+		return out.Script[2]
+	}
+	return 1
+}
+
+func (s *SafeServer) handleSubmitRequests(w http.ResponseWriter, bot *safeBot, body []byte) {
+	var inputs []*mixin.SafeTransactionRequestInput
+	if err := json.Unmarshal(body, &inputs); err != nil {
+		writeSafeError(w, http.StatusBadRequest, 400, err.Error())
+		return
+	}
+
+	requests := make([]*mixin.SafeTransactionRequest, 0, len(inputs))
+	for _, input := range inputs {
+		req, code, err := s.submitRequest(bot, input)
+		if err != nil {
+			writeSafeError(w, http.StatusAccepted, code, err.Error())
+			return
+		}
+		requests = append(requests, req.request)
+	}
+	writeSafeData(w, requests)
+}
+
+func (s *SafeServer) submitRequest(bot *safeBot, input *mixin.SafeTransactionRequestInput) (*safeRequest, int, error) {
+	req, ok := s.requests[input.RequestID]
+	if !ok || req.request.UserID != bot.user.UserID {
+		return nil, mixin.EndpointNotFound, fmt.Errorf("request %s not found", input.RequestID)
+	}
+	if req.request.State == mixin.SafeUtxoStateSpent {
+		return req, 0, nil
+	}
+
+	tx, err := mixinnet.TransactionFromRaw(input.RawTransaction)
+	if err != nil {
+		return nil, 400, fmt.Errorf("invalid raw transaction: %w", err)
+	}
+	if hash, err := tx.TransactionHash(); err != nil || hash != req.hash {
+		return nil, 400, fmt.Errorf("transaction not matched with request %s", input.RequestID)
+	}
+
+	if err := verifySignatures(bot.spendPublic, tx, req); err != nil {
+		return nil, mixin.InvalidSignature, err
+	}
+
+	for _, utxo := range req.inputs {
+		if utxo.State != mixin.SafeUtxoStateUnspent {
+			return nil, mixin.InputLocked, fmt.Errorf("input %s:%d is %s", utxo.TransactionHash, utxo.OutputIndex, utxo.State)
+		}
+	}
+
+	now := s.now().UTC()
+	for _, utxo := range req.inputs {
+		utxo.State = mixin.SafeUtxoStateSpent
+		utxo.SignedBy = req.hash.String()
+		utxo.SignedAt, utxo.SpentAt = &now, &now
+		utxo.UpdatedAt = now
+	}
+
+	assetId := s.assets[tx.Asset]
+	for i, out := range tx.Outputs {
+		members := s.ghosts[out.Mask.String()]
+		amount := decimal.RequireFromString(out.Amount.String())
+		utxo := s.addOutput(req.hash, uint8(i), assetId, amount, members, outputThreshold(out), []string{bot.user.UserID}, hex.EncodeToString(tx.Extra))
+		utxo.RequestID = input.RequestID
+	}
+
+	req.request.State = mixin.SafeUtxoStateSpent
+	req.request.RawTransaction = input.RawTransaction
+	req.request.SnapshotHash = mixinnet.NewHash([]byte("snapshot:" + req.hash.String())).String()
+	req.request.SnapshotAt = &now
+	req.request.UpdatedAt = now
+	return req, 0, nil
+}
+
+// verifySignatures 每个输入的签名公钥为 view*G + spend public key
+func verifySignatures(spendPublic mixinnet.Key, tx *mixinnet.Transaction, req *safeRequest) error {
+	if len(tx.Signatures) != len(req.request.Views) {
+		return fmt.Errorf("expect %d signatures, got %d", len(req.request.Views), len(tx.Signatures))
+	}
+
+	spend, err := spendPublic.ToPoint()
+	if err != nil {
+		return err
+	}
+
+	for i, view := range req.request.Views {
+		v, err := view.Public().ToPoint()
+		if err != nil {
+			return err
+		}
+		var pub mixinnet.Key
+		copy(pub[:], edwards25519.NewIdentityPoint().Add(v, spend).Bytes())
+
+		sig := tx.Signatures[i][0]
+		if sig == nil || !pub.VerifyHash(req.hash, *sig) {
+			return fmt.Errorf("invalid signature of input %d", i)
+		}
+	}
+	return nil
+}
+
+func (s *SafeServer) handleReadRequest(w http.ResponseWriter, id string) {
+	req, ok := s.requests[id]
+	if !ok {
+		if hash, err := mixinnet.HashFromString(id); err == nil {
+			req, ok = s.hashes[hash]
+		}
+	}
+	if !ok {
+		writeSafeError(w, http.StatusNotFound, mixin.EndpointNotFound, "transaction not found")
+		return
+	}
+	writeSafeData(w, req.request)
+}
+
+// tokenUserID 读取 JWT 中的 uid, 不校验签名
+func tokenUserID(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		UID string `json:"uid"`
+	}
+	_ = json.Unmarshal(payload, &claims)
+	return claims.UID
+}
+
+func writeSafeData(w http.ResponseWriter, data any) {
+	w.Header().Set(kit.HeaderContentType, kit.ContentTypeJSON)
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+}
+
+// writeSafeError 与 Mixin API 一致, 错误时 HTTP 状态码为 202, 实际状态在 error.status 中
+func writeSafeError(w http.ResponseWriter, status, code int, description string) {
+	w.Header().Set(kit.HeaderContentType, kit.ContentTypeJSON)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": mixin.Error{Status: status, Code: code, Description: description},
+	})
+}
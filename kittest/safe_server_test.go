@@ -0,0 +1,119 @@
+package kittest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+const testRecipient = "e9e5b807-fa8b-455a-8dfa-b189d28310ff"
+
+func TestSafeServer_TransferOne(t *testing.T) {
+	srv := NewSafeServer(t)
+	srv.Deposit(testAssetBTC, decimal.NewFromInt(1))
+	srv.Deposit(testAssetBTC, decimal.NewFromInt(2))
+	c := srv.NewClientWrapper(t)
+	ctx := context.Background()
+
+	req, err := c.TransferOne(ctx, &kit.TransferOneRequest{
+		RequestId: mixin.RandomTraceID(),
+		AssetId:   testAssetBTC,
+		Member:    testRecipient,
+		Amount:    decimal.RequireFromString("2.5"),
+		Memo:      "kittest",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.State != mixin.SafeUtxoStateSpent {
+		t.Errorf("State = %s, want spent", req.State)
+	}
+
+	if got := srv.Balance(testAssetBTC, testRecipient); !got.Equal(decimal.RequireFromString("2.5")) {
+		t.Errorf("recipient balance = %s, want 2.5", got)
+	}
+	if got := srv.Balance(testAssetBTC); !got.Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("change = %s, want 0.5", got)
+	}
+
+	_, err = c.TransferOne(ctx, &kit.TransferOneRequest{
+		RequestId: mixin.RandomTraceID(),
+		AssetId:   testAssetBTC,
+		Member:    testRecipient,
+		Amount:    decimal.NewFromInt(1),
+	})
+	if !errors.Is(err, kit.ErrNotEnoughUtxos) {
+		t.Errorf("TransferOne() error = %v, want %v", err, kit.ErrNotEnoughUtxos)
+	}
+}
+
+func TestSafeServer_DoubleSpend(t *testing.T) {
+	srv := NewSafeServer(t)
+	srv.Deposit(testAssetBTC, decimal.NewFromInt(1))
+	c := srv.NewClientWrapper(t)
+	ctx := context.Background()
+
+	utxos, err := c.SafeListUtxos(ctx, mixin.SafeListUtxoOption{State: mixin.SafeUtxoStateUnspent})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build := func(requestId string) (*mixin.SafeTransactionRequest, string) {
+		b := mixin.NewSafeTransactionBuilder(utxos)
+		tx, err := c.MakeTransaction(ctx, b, []*mixin.TransactionOutput{
+			{Address: mixin.RequireNewMixAddress([]string{testRecipient}, 1), Amount: decimal.NewFromInt(1)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := tx.Dump()
+		req, err := c.SafeCreateTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{RequestID: requestId, RawTransaction: raw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := mixin.SafeSignTransaction(tx, c.SpendKey, req.Views, 0); err != nil {
+			t.Fatal(err)
+		}
+		signed, _ := tx.Dump()
+		return req, signed
+	}
+
+	first, firstRaw := build(mixin.RandomTraceID())
+	second, secondRaw := build(mixin.RandomTraceID())
+
+	if _, err := c.SafeSubmitTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{RequestID: first.RequestID, RawTransaction: firstRaw}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.SafeSubmitTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{RequestID: second.RequestID, RawTransaction: secondRaw})
+	if !mixin.IsErrorCodes(err, mixin.InputLocked) {
+		t.Errorf("second submit error = %v, want InputLocked", err)
+	}
+	if got := srv.Balance(testAssetBTC, testRecipient); !got.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("recipient balance = %s, want 1", got)
+	}
+}
+
+func TestSafeServer_LazyUser(t *testing.T) {
+	srv := NewSafeServer(t)
+	srv.Deposit(testAssetBTC, decimal.NewFromInt(1))
+	srv.FailNext("GET", "/me", 500, "flaky")
+
+	// 构造时不请求 /me, 失败留给第一次转账
+	c := srv.NewClientWrapper(t, kit.WithLazyUser())
+	req := &kit.TransferOneRequest{
+		RequestId: mixin.RandomTraceID(),
+		AssetId:   testAssetBTC,
+		Member:    testRecipient,
+		Amount:    decimal.NewFromInt(1),
+	}
+	if _, err := c.TransferOne(context.Background(), req); !mixin.IsErrorCodes(err, 500) {
+		t.Fatalf("TransferOne() error = %v, want code 500", err)
+	}
+	if _, err := c.TransferOne(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,143 @@
+package kit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const defaultMarketPageLimit = 100
+
+// MarketClient Mixin Route 行情接口, 请求经过 Web3Client 签名, 错误解析为 MixinOracleAPIError
+type MarketClient struct {
+	web3 Web3Client
+
+	pageLimit    int
+	assetCache   *ttlCache[string, MarketAssetInfo]
+	historyCache *ttlCache[string, HistoricalPrice]
+}
+
+// MarketClientOption 定义 MarketClient 选项
+type MarketClientOption func(*MarketClient)
+
+// WithMarketCache 缓存资产行情和历史价格 ttl 时长, ttl <= 0 时不缓存
+func WithMarketCache(ttl time.Duration) MarketClientOption {
+	return func(c *MarketClient) {
+		if ttl <= 0 {
+			c.assetCache, c.historyCache = nil, nil
+			return
+		}
+		c.assetCache = newTTLCache[string, MarketAssetInfo](ttl)
+		c.historyCache = newTTLCache[string, HistoricalPrice](ttl)
+	}
+}
+
+// WithMarketPageLimit 设置 ListAllMarkets 每页的数量
+func WithMarketPageLimit(limit int) MarketClientOption {
+	return func(c *MarketClient) {
+		if limit > 0 {
+			c.pageLimit = limit
+		}
+	}
+}
+
+func NewMarketClient(web3 Web3Client, opts ...MarketClientOption) *MarketClient {
+	c := &MarketClient{
+		web3:      web3,
+		pageLimit: defaultMarketPageLimit,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+/*
+GET /markets/:coin_id
+coin_id: coin_id from GET /markets, or mixin asset id
+*/
+func (c *MarketClient) GetAssetInfo(ctx context.Context, assetId string) (*MarketAssetInfo, error) {
+	if c.assetCache != nil {
+		if info, ok := c.assetCache.Get(assetId); ok {
+			return &info, nil
+		}
+	}
+
+	info, err := GetJSON[MarketAssetInfo](ctx, c.web3, "/markets/"+url.PathEscape(assetId), "")
+	if err != nil {
+		return nil, err
+	}
+
+	if c.assetCache != nil {
+		c.assetCache.Set(assetId, info)
+	}
+	return &info, nil
+}
+
+/*
+GET /markets/:coin_id/price-history?type=${type}
+coin_id: coin_id from GET /markets, or mixin asset id
+type: 1D, 1W, 1M, YTD, ALL
+*/
+func (c *MarketClient) GetPriceHistory(ctx context.Context, assetId string, t HistoryPriceType) (*HistoricalPrice, error) {
+	if InvalidPriceHistoryType(uint8(t)) {
+		return nil, fmt.Errorf("invalid price history type: %d", t)
+	}
+
+	key := assetId + ":" + t.String()
+	if c.historyCache != nil {
+		if history, ok := c.historyCache.Get(key); ok {
+			return &history, nil
+		}
+	}
+
+	history, err := GetJSON[HistoricalPrice](ctx, c.web3, "/markets/"+url.PathEscape(assetId)+"/price-history", "type="+t.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if c.historyCache != nil {
+		c.historyCache.Set(key, history)
+	}
+	return &history, nil
+}
+
+/*
+GET /markets?offset=${offset}&limit=${limit}
+*/
+func (c *MarketClient) ListMarkets(ctx context.Context, offset, limit int) ([]MarketAssetInfo, error) {
+	markets, _, err := GetPage(ctx, c.web3, "/markets", nil, OffsetPager[MarketAssetInfo](limit), strconv.Itoa(offset))
+	return markets, err
+}
+
+// ListAllMarkets 翻页获取所有行情, 结果同时写入资产缓存
+func (c *MarketClient) ListAllMarkets(ctx context.Context) ([]MarketAssetInfo, error) {
+	markets, err := ListJSON(ctx, c.web3, "/markets", nil, OffsetPager[MarketAssetInfo](c.pageLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.assetCache != nil {
+		for _, m := range markets {
+			c.assetCache.Set(m.CoinID, m)
+			for _, assetId := range m.AssetIDS {
+				c.assetCache.Set(assetId, m)
+			}
+		}
+	}
+	return markets, nil
+}
+
+// PurgeCache 清空缓存
+func (c *MarketClient) PurgeCache() {
+	if c.assetCache != nil {
+		c.assetCache.Purge()
+	}
+	if c.historyCache != nil {
+		c.historyCache.Purge()
+	}
+}
@@ -3,6 +3,7 @@ package kit
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"sort"
 	"strconv"
@@ -12,7 +13,6 @@ import (
 	bot "github.com/MixinNetwork/bot-api-go-client/v3"
 	"github.com/fox-one/mixin-sdk-go/v2"
 	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
-	"github.com/go-resty/resty/v2"
 	"github.com/gofrs/uuid/v5"
 	"github.com/shopspring/decimal"
 )
@@ -31,21 +31,35 @@ const (
 )
 
 type Config struct {
-	AppID             string `json:"app_id"`
-	SessionID         string `json:"session_id"`
-	ServerPublicKey   string `json:"server_public_key"`
-	SessionPrivateKey string `json:"session_private_key"`
-	SpendKey          string `json:"spend_key"`
+	AppID             string `json:"app_id" toml:"app_id" yaml:"app_id"`
+	SessionID         string `json:"session_id" toml:"session_id" yaml:"session_id"`
+	ServerPublicKey   string `json:"server_public_key" toml:"server_public_key" yaml:"server_public_key"`
+	SessionPrivateKey string `json:"session_private_key" toml:"session_private_key" yaml:"session_private_key"`
+	SpendKey          string `json:"spend_key" toml:"spend_key" yaml:"spend_key"`
 }
 
 type ClientWrapper struct {
 	*mixin.Client
+	// Bot 不包含 SpendPrivateKey, 交易由 TransactionSigner 或 SpendKey 签名
 	Bot *bot.SafeUser
 	Web3Client
 
-	user     *mixin.User
+	Market *MarketClient
+
+	// SpendKey 使用 WithLazyUser 时在第一次转账前才会设置
 	SpendKey mixinnet.Key
-	client   *resty.Client
+
+	spendKeyStr    string
+	spendKeyOpt    mixinnet.Key
+	keystore       *EncryptedKeystore // WithEncryptedSpendKey, 解密后清除
+	passphrase     []byte
+	spendPublicKey string
+	user           *mixin.User
+	userMutex      sync.Mutex
+	policy         *SpendingPolicy
+	signer         TransactionSigner
+	audit          *AuditLog
+	obs            observer
 
 	transferMutex sync.Mutex
 }
@@ -59,7 +73,20 @@ func GenUuidFromStrings(strs ...string) string {
 	return uuid.NewV5(uuid.NamespaceOID, str).String()
 }
 
-func NewMixinClientWrapper(config *Config) (*ClientWrapper, error) {
+// NewMixinClientWrapper 创建客户端, 默认会请求 /me 获取 spend 公钥以校验 spend key;
+// 使用 WithUser, WithSpendPublicKey 或 WithLazyUser 时构造过程不访问网络.
+// Mixin API 的地址和超时是进程级的, 见 SetGlobalSafeApiHost; 只有 Route 和行情客户端可以按客户端设置.
+// config 不合法时返回 ConfigErrors, 见 Config.Validate
+func NewMixinClientWrapper(config *Config, opts ...ClientWrapperOption) (*ClientWrapper, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	var o clientWrapperOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	client, err := mixin.NewFromKeystore(&mixin.Keystore{
 		AppID:             config.AppID,
 		SessionID:         config.SessionID,
@@ -69,135 +96,286 @@ func NewMixinClientWrapper(config *Config) (*ClientWrapper, error) {
 	if err != nil {
 		return nil, err
 	}
-	user, err := client.UserMe(context.Background())
-	if err != nil {
-		return nil, err
-	}
 
-	spendKeyStr := config.SpendKey
-	spendKey, err := mixinnet.ParseKeyWithPub(spendKeyStr, user.SpendPublicKey)
-	if err != nil {
-		return nil, err
-	}
 	safeUser := &bot.SafeUser{
 		UserId:            config.AppID,
 		SessionId:         config.SessionID,
 		SessionPrivateKey: config.SessionPrivateKey,
 		ServerPublicKey:   config.ServerPublicKey,
-		SpendPrivateKey:   spendKey.String(),
 	}
 
-	logger := slog.Default()
+	logger := o.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
 	botCli := bot.NewDefaultClient(safeUser, logger)
 
+	web3Options := o.web3Options
+	if o.logger != nil {
+		web3Options = append([]Web3ClientOption{WithMiddleware(LoggingMiddleware(o.logger))}, web3Options...)
+	}
+	if o.tracer != nil || o.meter != nil {
+		// 放在最内层, 每次重试单独记录
+		web3Options = append(web3Options, WithMiddleware(ObservabilityMiddleware(o.tracer, o.meter)))
+	}
+	web3Client := NewWeb3Client(botCli, web3Options...)
+
 	clientWrapper := &ClientWrapper{
-		Bot:        safeUser,
-		Web3Client: NewWeb3Client(botCli),
-		client: resty.New().
-			SetHeader("Content-Type", "application/json").
-			SetBaseURL(MixinRouteApiPrefix).
-			SetTimeout(10 * time.Second),
-		Client:        client,
-		SpendKey:      spendKey,
-		user:          user,
-		transferMutex: sync.Mutex{},
+		Bot:            safeUser,
+		Web3Client:     web3Client,
+		Market:         NewMarketClient(web3Client, o.marketOptions...),
+		Client:         client,
+		spendKeyStr:    config.SpendKey,
+		spendKeyOpt:    o.spendKey,
+		keystore:       o.keystore,
+		passphrase:     o.passphrase,
+		spendPublicKey: o.spendPublicKey,
+		user:           o.user,
+		policy:         o.policy,
+		signer:         o.signer,
+		audit:          o.audit,
+		obs:            newObserver(o.tracer, o.meter),
+	}
+
+	if !o.lazyUser {
+		if err := clientWrapper.loadSpendKey(context.Background()); err != nil {
+			return nil, err
+		}
 	}
 
 	return clientWrapper, nil
 }
 
-type Web3Response[T any] struct {
-	Data T `json:"data"`
+// Me 返回机器人的用户信息, 未提供时请求 /me 并缓存
+func (m *ClientWrapper) Me(ctx context.Context) (*mixin.User, error) {
+	m.userMutex.Lock()
+	defer m.userMutex.Unlock()
+
+	return m.me(ctx)
 }
 
-/*
-GET /markets/:coin_idcoin_id: string, coin_id from GET /markets. OR mixin asset idresponse:
-*/
-func (m *ClientWrapper) GetAssetInfo(ctx context.Context, assetId string) (*MarketAssetInfo, error) {
-	var response Web3Response[MarketAssetInfo]
-	_, err := m.client.R().SetContext(ctx).SetPathParams(map[string]string{
-		"coin_id": assetId,
-	}).SetResult(&response).Get("/markets/{coin_id}")
+func (m *ClientWrapper) me(ctx context.Context) (*mixin.User, error) {
+	if m.user != nil {
+		return m.user, nil
+	}
+
+	user, err := m.UserMe(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return &response.Data, nil
+	m.user = user
+	return user, nil
 }
 
-/*
-GET /markets/:coin_id/price-history?type=${type}paramdescriptioncoin_idcoin_id from GET /markets, or mixin asset idtype1D, 1W, 1M, YTD, ALLresponse:
-*/
-func (m *ClientWrapper) GetPriceHistory(ctx context.Context, assetId string, t HistoryPriceType) (*HistoricalPrice, error) {
-	var response Web3Response[HistoricalPrice]
+// signTransaction 使用 TransactionSigner 签名, 未设置时使用内存中的 SpendKey
+func (m *ClientWrapper) signTransaction(ctx context.Context, tx *mixinnet.Transaction, views []mixinnet.Key) error {
+	if m.signer != nil {
+		return m.signer.SignTransaction(ctx, tx, views, 0)
+	}
+	return SpendKeySigner{Key: m.SpendKey}.SignTransaction(ctx, tx, views, 0)
+}
 
-	_, err := m.client.R().
-		SetContext(ctx).
-		SetPathParams(map[string]string{
-			"coin_id": assetId,
-		}).
-		SetQueryParam("type", t.String()).
-		SetResult(&response).
-		Get("/markets/{coin_id}/price-history")
+// signAndSubmit 签名并提交交易. 设置了 AuditLog 时, 签名前先写入审计记录, 写入失败则不签名;
+// 提交后再记录结果. sent 为 true 表示已经发出提交请求, 即使返回错误 (如超时) 交易也可能已经上链
+func (m *ClientWrapper) signAndSubmit(ctx context.Context, tx *mixinnet.Transaction, request *mixin.SafeTransactionRequest, entry *AuditEntry) (sent bool, err error) {
+	entry.TransactionHash = request.TransactionHash
+	if m.audit != nil {
+		entry.Event = AuditEventSign
+		if err := m.audit.Append(ctx, entry); err != nil {
+			return false, err
+		}
+	}
+
+	signCtx, span, end := m.obs.start(ctx, OpSign, Attr{"request_id", request.RequestID})
+	span.SetAttributes(Attr{"transaction_hash", request.TransactionHash})
+	err = m.signTransaction(signCtx, tx, request.Views)
+	end(err)
+	if err == nil {
+		var signedRaw string
+		if signedRaw, err = tx.Dump(); err == nil {
+			sent = true
+			submitCtx, end := m.obs.api(ctx, OpSubmit, Attr{"request_id", request.RequestID})
+			_, err = m.SafeSubmitTransactionRequest(submitCtx, &mixin.SafeTransactionRequestInput{
+				RequestID:      request.RequestID,
+				RawTransaction: signedRaw,
+			})
+			end(err)
+		}
+	}
+
+	if m.audit != nil {
+		result := *entry
+		result.Event = AuditEventSubmitted
+		if err != nil {
+			result.Event, result.Error = AuditEventFailed, err.Error()
+		}
+		if aerr := m.audit.Append(ctx, &result); aerr != nil {
+			return sent, errors.Join(err, aerr)
+		}
+	}
+	return sent, err
+}
+
+// resumeRequest 签名并提交已创建但没有提交 (unspent) 的请求, 用于进程在提交前退出后使用同一个 request id 重试.
+// 使用创建时的 raw transaction, 不重新选择 utxo; 审计日志中的输入不含金额.
+// authorize 为 false 时调用方已经通过 SpendingPolicy; sent 见 signAndSubmit
+func (m *ClientWrapper) resumeRequest(ctx context.Context, request *mixin.SafeTransactionRequest, assetId, memo string, authorize bool) (_ *mixin.SafeTransactionRequest, sent bool, err error) {
+	if err = m.loadSpendKey(ctx); err != nil {
+		return nil, false, err
+	}
 
+	tx, err := mixinnet.TransactionFromRaw(request.RawTransaction)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if len(tx.Outputs) != len(request.Receivers) {
+		return nil, false, fmt.Errorf("request %s: %d outputs, %d receivers", request.RequestID, len(tx.Outputs), len(request.Receivers))
+	}
+
+	spend := &Spend{RequestId: request.RequestID, AssetId: assetId, Amount: decimal.Zero, Memo: memo}
+	var outputs []*mixin.TransactionOutput
+	for i, r := range request.Receivers {
+		// 找零
+		if r.Threshold == 1 && len(r.Members) == 1 && r.Members[0] == m.ClientID {
+			continue
+		}
+		amount, err := decimal.NewFromString(tx.Outputs[i].Amount.String())
+		if err != nil {
+			return nil, false, err
+		}
+		outputs = append(outputs, &mixin.TransactionOutput{
+			Address: mixin.RequireNewMixAddress(r.Members, r.Threshold),
+			Amount:  amount,
+		})
+		spend.Amount = spend.Amount.Add(amount)
+		spend.Recipients = append(spend.Recipients, r.Members...)
+	}
+
+	release := func() {}
+	if authorize {
+		if release, err = m.authorizeSpend(ctx, spend); err != nil {
+			return nil, false, err
+		}
+	}
+	defer func() {
+		if !sent {
+			release()
+		}
+	}()
+
+	// 与 TransferOne 等使用同一把锁, 避免并发签名
+	m.transferMutex.Lock()
+	defer m.transferMutex.Unlock()
+
+	entry := newAuditEntry(m.ClientID, request.RequestID, assetId, memo, nil, outputs)
+	for _, in := range tx.Inputs {
+		entry.Inputs = append(entry.Inputs, AuditInput{TransactionHash: in.Hash.String(), OutputIndex: uint8(in.Index)})
 	}
-	return &response.Data, nil
+	if sent, err = m.signAndSubmit(ctx, tx, request, entry); err != nil {
+		return nil, sent, err
+	}
+
+	request, err = m.readRequest(ctx, request.RequestID)
+	return request, true, err
 }
 
-func (m *ClientWrapper) Web3Tokens(ctx context.Context) (tokens []TokenView, err error) {
-	var response Web3Response[[]TokenView]
+func (m *ClientWrapper) listUtxos(ctx context.Context, opt mixin.SafeListUtxoOption) (utxos []*mixin.SafeUtxo, err error) {
+	ctx, end := m.obs.api(ctx, OpListUtxos, Attr{"asset_id", opt.Asset})
+	defer func() { end(err) }()
+	return m.SafeListUtxos(ctx, opt)
+}
 
-	err = m.Web3Client.Get(
-		ctx,
-		"/web3/tokens",
-		"source=mixin",
-		&response,
-	)
+func (m *ClientWrapper) createRequest(ctx context.Context, input *mixin.SafeTransactionRequestInput) (request *mixin.SafeTransactionRequest, err error) {
+	ctx, end := m.obs.api(ctx, OpCreateRequest, Attr{"request_id", input.RequestID})
+	defer func() { end(err) }()
+	return m.SafeCreateTransactionRequest(ctx, input)
+}
 
-	return response.Data, err
+func (m *ClientWrapper) readRequest(ctx context.Context, requestId string) (request *mixin.SafeTransactionRequest, err error) {
+	ctx, end := m.obs.api(ctx, OpReadRequest, Attr{"request_id", requestId})
+	defer func() { end(err) }()
+	return m.SafeReadTransactionRequest(ctx, requestId)
 }
 
-func (m *ClientWrapper) Web3Quote(ctx context.Context, req QuoteRequest) (resp QuoteResponseView, err error) {
-	var response Web3Response[QuoteResponseView]
+// loadSpendKey 使用 spend 公钥解析并校验 spend key (WithSpendKey 的 key 同样校验), 已设置或使用 TransactionSigner 时直接返回
+func (m *ClientWrapper) loadSpendKey(ctx context.Context) error {
+	m.userMutex.Lock()
+	defer m.userMutex.Unlock()
+
+	if m.signer != nil || m.SpendKey.HasValue() {
+		return nil
+	}
 
-	err = m.Web3Client.DoRequest(
-		ctx,
-		"GET",
-		"/web3/quote",
-		req.ToQuery(),
-		nil,
-		&response,
-	)
+	publicKey := m.spendPublicKey
+	if publicKey == "" {
+		user, err := m.me(ctx)
+		if err != nil {
+			return err
+		}
+		publicKey = user.SpendPublicKey
+	}
 
-	return response.Data, err
+	if m.spendKeyOpt.HasValue() {
+		if m.spendKeyOpt.Public().String() != publicKey {
+			var errs ConfigErrors
+			errs.add("spend_key", "does not match spend public key %s", publicKey)
+			return errs.err()
+		}
+		m.SpendKey, m.spendKeyOpt = m.spendKeyOpt, mixinnet.Key{}
+		m.spendKeyStr = ""
+		return nil
+	}
+
+	if m.keystore != nil {
+		key, err := m.keystore.DecryptSpendKey(m.passphrase, publicKey)
+		if err != nil {
+			return err
+		}
+		m.SpendKey = key
+		ZeroKey(&key)
+		zeroBytes(m.passphrase)
+		m.keystore, m.passphrase = nil, nil
+		m.spendKeyStr = ""
+		return nil
+	}
+
+	spendKey, err := mixinnet.ParseKeyWithPub(m.spendKeyStr, publicKey)
+	if err != nil {
+		if verr := (&Config{SpendKey: m.spendKeyStr}).ValidateSpendKey(publicKey); verr != nil {
+			return verr
+		}
+		return err
+	}
+	m.SpendKey = spendKey
+	// 解析后不再保留原始的 spend key
+	m.spendKeyStr = ""
+	return nil
 }
 
-func (m *ClientWrapper) Web3Swap(ctx context.Context, req SwapRequest) (resp SwapResponseView, err error) {
-	var response Web3Response[SwapResponseView]
-	err = m.Web3Client.Post(
-		ctx,
-		"/web3/swap",
-		req,
-		&response,
-	)
+// GetAssetInfo 见 MarketClient.GetAssetInfo
+func (m *ClientWrapper) GetAssetInfo(ctx context.Context, assetId string) (*MarketAssetInfo, error) {
+	return m.Market.GetAssetInfo(ctx, assetId)
+}
 
-	return response.Data, err
+// GetPriceHistory 见 MarketClient.GetPriceHistory
+func (m *ClientWrapper) GetPriceHistory(ctx context.Context, assetId string, t HistoryPriceType) (*HistoricalPrice, error) {
+	return m.Market.GetPriceHistory(ctx, assetId, t)
 }
 
-func (m *ClientWrapper) GetWeb3SwapOrder(ctx context.Context, orderId string) (order SwapOrder, err error) {
-	var result struct {
-		Data SwapOrder `json:"data"`
-	}
+func (m *ClientWrapper) Web3Tokens(ctx context.Context) ([]TokenView, error) {
+	return GetJSON[[]TokenView](ctx, m.Web3Client, "/web3/tokens", "source=mixin")
+}
 
-	err = m.Web3Client.Get(
-		ctx,
-		"/web3/swap/orders/"+orderId,
-		"",
-		&result,
-	)
+func (m *ClientWrapper) Web3Quote(ctx context.Context, req QuoteRequest) (QuoteResponseView, error) {
+	return GetJSON[QuoteResponseView](ctx, m.Web3Client, "/web3/quote", req.ToQuery())
+}
+
+func (m *ClientWrapper) Web3Swap(ctx context.Context, req SwapRequest) (SwapResponseView, error) {
+	return PostJSON[SwapResponseView](ctx, m.Web3Client, "/web3/swap", req)
+}
 
-	return result.Data, err
+func (m *ClientWrapper) GetWeb3SwapOrder(ctx context.Context, orderId string) (SwapOrder, error) {
+	return GetJSON[SwapOrder](ctx, m.Web3Client, "/web3/swap/orders/"+orderId, "")
 }
 
 type TransferOneRequest struct {
@@ -210,8 +388,9 @@ type TransferOneRequest struct {
 }
 
 type MemberAmount struct {
-	Member []string
-	Amount decimal.Decimal
+	Member    []string
+	Threshold uint8 // 为 0 时为 len(Member)
+	Amount    decimal.Decimal
 }
 
 type TransferManyRequest struct {
@@ -233,6 +412,13 @@ type InscriptionTransferRequest struct {
 
 // 主动聚合utxos 至 utxo 数量不超过 255 个
 func (c *ClientWrapper) SyncArrgegateUtxos(ctx context.Context, assetId string) (utxos []*mixin.SafeUtxo, err error) {
+	ctx, _, end := c.obs.start(ctx, OpConsolidate, Attr{"asset_id", assetId})
+	defer func() { end(err) }()
+
+	if err = c.loadSpendKey(ctx); err != nil {
+		return nil, err
+	}
+
 	c.transferMutex.Lock()
 	defer c.transferMutex.Unlock()
 
@@ -240,7 +426,7 @@ func (c *ClientWrapper) SyncArrgegateUtxos(ctx context.Context, assetId string)
 
 	for {
 		requestId := mixin.RandomTraceID()
-		utxos, err = c.SafeListUtxos(ctx, mixin.SafeListUtxoOption{
+		utxos, err = c.listUtxos(ctx, mixin.SafeListUtxoOption{
 			Asset:     assetId,
 			State:     mixin.SafeUtxoStateUnspent,
 			Threshold: 1,
@@ -269,13 +455,14 @@ func (c *ClientWrapper) SyncArrgegateUtxos(ctx context.Context, assetId string)
 		// 2: build transaction
 		b := mixin.NewSafeTransactionBuilder(utxoSlice)
 		b.Memo = AGGREGRATE_UTXO_MEMO
-		var tx *mixinnet.Transaction
-		tx, err = c.MakeTransaction(ctx, b, []*mixin.TransactionOutput{
+		outputs := []*mixin.TransactionOutput{
 			{
 				Address: mixin.RequireNewMixAddress([]string{c.ClientID}, 1),
 				Amount:  utxoSliceAmount,
 			},
-		})
+		}
+		var tx *mixinnet.Transaction
+		tx, err = c.MakeTransaction(ctx, b, outputs)
 		if err != nil {
 			return nil, err
 		}
@@ -287,7 +474,7 @@ func (c *ClientWrapper) SyncArrgegateUtxos(ctx context.Context, assetId string)
 
 		// 3. create transaction
 		var request *mixin.SafeTransactionRequest
-		request, err = c.SafeCreateTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{
+		request, err = c.createRequest(ctx, &mixin.SafeTransactionRequestInput{
 			RequestID:      requestId,
 			RawTransaction: raw,
 		})
@@ -295,31 +482,12 @@ func (c *ClientWrapper) SyncArrgegateUtxos(ctx context.Context, assetId string)
 			return
 		}
 
-		// 4. sign transaction
-		err = mixin.SafeSignTransaction(
-			tx,
-			c.SpendKey,
-			request.Views,
-			0,
-		)
-		if err != nil {
-			return
-		}
-
-		var signedRaw string
-		signedRaw, err = tx.Dump()
-		if err != nil {
-			return
-		}
-
-		// 5. submit transaction
-		_, err = c.SafeSubmitTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{
-			RequestID:      requestId,
-			RawTransaction: signedRaw,
-		})
+		// 4. sign and submit transaction
+		_, err = c.signAndSubmit(ctx, tx, request, newAuditEntry(c.ClientID, requestId, assetId, AGGREGRATE_UTXO_MEMO, utxoSlice, outputs))
 		if err != nil {
 			return
 		}
+		c.obs.meter.AddCounter(ctx, MetricConsolidations, 1, Attr{"asset_id", assetId})
 
 		// 重试读取交易状态
 		const defaultMaxRetryTimes = 3
@@ -331,7 +499,7 @@ func (c *ClientWrapper) SyncArrgegateUtxos(ctx context.Context, assetId string)
 
 			retryTimes++
 			time.Sleep(time.Second * time.Duration(retryTimes))
-			_, err = c.SafeReadTransactionRequest(ctx, requestId)
+			_, err = c.readRequest(ctx, requestId)
 			if err != nil {
 				return
 			} else {
@@ -345,8 +513,33 @@ func (c *ClientWrapper) SyncArrgegateUtxos(ctx context.Context, assetId string)
 	return
 }
 
-func (c *ClientWrapper) TransferOne(ctx context.Context, req *TransferOneRequest) (*mixin.SafeTransactionRequest, error) {
-	var err error
+func (c *ClientWrapper) TransferOne(ctx context.Context, req *TransferOneRequest) (request *mixin.SafeTransactionRequest, err error) {
+	ctx, _, end := c.obs.start(ctx, OpTransfer, Attr{"request_id", req.RequestId}, Attr{"asset_id", req.AssetId}, Attr{"kind", "one"})
+	defer func() {
+		end(err)
+		c.obs.transfer(ctx, "one", req.AssetId, req.Amount, err)
+	}()
+	return c.transferOne(ctx, req)
+}
+
+func (c *ClientWrapper) transferOne(ctx context.Context, req *TransferOneRequest) (*mixin.SafeTransactionRequest, error) {
+	release, err := c.authorizeSpend(ctx, &Spend{
+		RequestId:  req.RequestId,
+		AssetId:    req.AssetId,
+		Amount:     req.Amount,
+		Recipients: []string{req.Member},
+		Memo:       req.Memo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	submitted := false
+	defer func() {
+		if !submitted {
+			release()
+		}
+	}()
+
 	var utxos []*mixin.SafeUtxo
 
 	utxos, err = c.SyncArrgegateUtxos(ctx, req.AssetId)
@@ -358,7 +551,7 @@ func (c *ClientWrapper) TransferOne(ctx context.Context, req *TransferOneRequest
 	defer c.transferMutex.Unlock()
 
 	for i := 0; i < 3 && len(utxos) == 0; i++ {
-		utxos, _ = c.SafeListUtxos(ctx, mixin.SafeListUtxoOption{
+		utxos, _ = c.listUtxos(ctx, mixin.SafeListUtxoOption{
 			Asset:     req.AssetId,
 			State:     mixin.SafeUtxoStateUnspent,
 			Threshold: 1,
@@ -426,39 +619,21 @@ func (c *ClientWrapper) TransferOne(ctx context.Context, req *TransferOneRequest
 	}
 
 	// 3. create transaction
-	request, err := c.SafeCreateTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{
+	request, err := c.createRequest(ctx, &mixin.SafeTransactionRequestInput{
 		RequestID:      req.RequestId,
 		RawTransaction: raw,
 	})
 	if err != nil {
 		return nil, err
 	}
-	// 4. sign transaction
-	err = mixin.SafeSignTransaction(
-		tx,
-		c.SpendKey,
-		request.Views,
-		0,
-	)
-	if err != nil {
-		return nil, err
-	}
-	signedRaw, err := tx.Dump()
-	if err != nil {
-		return nil, err
-	}
-
-	// 5. submit transaction
-	_, err = c.SafeSubmitTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{
-		RequestID:      req.RequestId,
-		RawTransaction: signedRaw,
-	})
+	// 4. sign and submit transaction
+	submitted, err = c.signAndSubmit(ctx, tx, request, newAuditEntry(c.ClientID, req.RequestId, req.AssetId, req.Memo, useUtxos, []*mixin.TransactionOutput{txOutout}))
 	if err != nil {
 		return nil, err
 	}
 
 	// 6. read transaction
-	req1, err := c.SafeReadTransactionRequest(ctx, req.RequestId)
+	req1, err := c.readRequest(ctx, req.RequestId)
 	if err != nil {
 		return nil, err
 	}
@@ -466,26 +641,38 @@ func (c *ClientWrapper) TransferOne(ctx context.Context, req *TransferOneRequest
 }
 
 /* req.MemberAmount length No limit */
+// TransferManyN 按总额经过一次 SpendingPolicy (审批只调用一次), 之后分批转账, 各批次不再单独检查;
+// 有批次已经发出提交请求后失败时, 总额仍计入累计金额
 func (m *ClientWrapper) TransferManyN(ctx context.Context, req *TransferManyRequest) error {
+	release, err := m.authorizeSpend(ctx, req.spend())
+	if err != nil {
+		return err
+	}
+	sent := false
+	defer func() {
+		if !sent {
+			release()
+		}
+	}()
+
 	if len(req.MemberAmount) < MAX_UTXO_NUM {
-		_, err := m.TransferMany(ctx, req)
-		if err != nil {
-			return err
+		_, sent, err = m.transferManyObserved(ctx, req, false)
+		return err
+	}
+
+	memberAmountArray := buildTransferMany(req.MemberAmount)
+	for i, memberAmount := range memberAmountArray {
+		req := &TransferManyRequest{
+			RequestId:    GenUuidFromStrings(req.RequestId, strconv.Itoa(i)),
+			AssetId:      req.AssetId,
+			MemberAmount: memberAmount,
+			Memo:         req.Memo,
 		}
-	} else {
-		memberAmountArray := buildTransferMany(req.MemberAmount)
-		for i, memberAmount := range memberAmountArray {
-			req := &TransferManyRequest{
-				RequestId:    GenUuidFromStrings(req.RequestId, strconv.Itoa(i)),
-				AssetId:      req.AssetId,
-				MemberAmount: memberAmount,
-				Memo:         req.Memo,
-			}
 
-			_, err := m.TransferMany(ctx, req)
-			if err != nil {
-				return err
-			}
+		_, batchSent, err := m.transferManyObserved(ctx, req, false)
+		sent = sent || batchSent
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -493,29 +680,53 @@ func (m *ClientWrapper) TransferManyN(ctx context.Context, req *TransferManyRequ
 
 // req.MemberAmount max 255
 func (m *ClientWrapper) TransferMany(ctx context.Context, req *TransferManyRequest) (*mixin.SafeTransactionRequest, error) {
+	request, _, err := m.transferManyObserved(ctx, req, true)
+	return request, err
+}
+
+// transferManyObserved authorize 为 false 时调用方已经按总额通过 SpendingPolicy
+func (m *ClientWrapper) transferManyObserved(ctx context.Context, req *TransferManyRequest, authorize bool) (request *mixin.SafeTransactionRequest, sent bool, err error) {
+	ctx, _, end := m.obs.start(ctx, OpTransfer, Attr{"request_id", req.RequestId}, Attr{"asset_id", req.AssetId}, Attr{"kind", "many"})
+	defer func() {
+		end(err)
+		m.obs.transfer(ctx, "many", req.AssetId, req.spend().Amount, err)
+	}()
+	return m.transferMany(ctx, req, authorize)
+}
+
+// transferMany sent 为 true 表示已经发出提交请求, 见 signAndSubmit
+func (m *ClientWrapper) transferMany(ctx context.Context, req *TransferManyRequest, authorize bool) (request *mixin.SafeTransactionRequest, sent bool, err error) {
 	if len(req.MemberAmount) > MAX_UTXO_NUM {
-		return nil, ErrMaxUtxoExceeded
+		return nil, false, ErrMaxUtxoExceeded
+	}
+
+	spend := req.spend()
+	totalAmount := spend.Amount
+	release := func() {}
+	if authorize {
+		if release, err = m.authorizeSpend(ctx, spend); err != nil {
+			return nil, false, err
+		}
 	}
+	defer func() {
+		if !sent {
+			release()
+		}
+	}()
 
 	var utxos []*mixin.SafeUtxo
-	var err error
 	utxos, err = m.SyncArrgegateUtxos(ctx, req.AssetId)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	m.transferMutex.Lock()
 	defer m.transferMutex.Unlock()
 
-	totalAmount := decimal.Zero
-	for _, item := range req.MemberAmount {
-		totalAmount = totalAmount.Add(item.Amount)
-	}
-
 	retryCount := 0
 	for len(utxos) == 0 && retryCount < 3 {
 		// 1. 将utxos聚合
-		utxos, _ = m.SafeListUtxos(ctx, mixin.SafeListUtxoOption{
+		utxos, _ = m.listUtxos(ctx, mixin.SafeListUtxoOption{
 			Asset:     req.AssetId,
 			State:     mixin.SafeUtxoStateUnspent,
 			Threshold: 1,
@@ -525,7 +736,7 @@ func (m *ClientWrapper) TransferMany(ctx context.Context, req *TransferManyReque
 		retryCount++
 	}
 	if len(utxos) == 0 {
-		return nil, ErrNotEnoughUtxos
+		return nil, false, ErrNotEnoughUtxos
 	}
 
 	// 1: select utxos
@@ -550,7 +761,7 @@ func (m *ClientWrapper) TransferMany(ctx context.Context, req *TransferManyReque
 	}
 
 	if useAmount.LessThan(totalAmount) {
-		return nil, ErrNotEnoughUtxos
+		return nil, false, ErrNotEnoughUtxos
 	}
 
 	// 2: build transaction
@@ -560,59 +771,67 @@ func (m *ClientWrapper) TransferMany(ctx context.Context, req *TransferManyReque
 	txOutout := make([]*mixin.TransactionOutput, len(req.MemberAmount))
 	for i := 0; i < len(req.MemberAmount); i++ {
 		txOutout[i] = &mixin.TransactionOutput{
-			Address: mixin.RequireNewMixAddress(req.MemberAmount[i].Member, byte(len(req.MemberAmount[i].Member))),
+			Address: mixin.RequireNewMixAddress(req.MemberAmount[i].Member, req.MemberAmount[i].threshold()),
 			Amount:  req.MemberAmount[i].Amount,
 		}
 	}
 
 	tx, err := m.MakeTransaction(ctx, b, txOutout)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	raw, err := tx.Dump()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// 3. create transaction
-	request, err := m.SafeCreateTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{
+	request, err = m.createRequest(ctx, &mixin.SafeTransactionRequestInput{
 		RequestID:      req.RequestId,
 		RawTransaction: raw,
 	})
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	// 4. sign transaction
-	err = mixin.SafeSignTransaction(
-		tx,
-		m.SpendKey,
-		request.Views,
-		0,
-	)
+	// 4. sign and submit transaction
+	sent, err = m.signAndSubmit(ctx, tx, request, newAuditEntry(m.ClientID, req.RequestId, req.AssetId, req.Memo, useUtxos, txOutout))
 	if err != nil {
-		return nil, err
+		return nil, sent, err
 	}
-	signedRaw, err := tx.Dump()
-	if err != nil {
-		return nil, err
+
+	// 6. read transaction
+	request, err = m.readRequest(ctx, req.RequestId)
+	return request, true, err
+}
+
+func (req *TransferManyRequest) spend() *Spend {
+	spend := &Spend{
+		RequestId: req.RequestId,
+		AssetId:   req.AssetId,
+		Amount:    decimal.Zero,
+		Memo:      req.Memo,
+	}
+	for _, item := range req.MemberAmount {
+		spend.Amount = spend.Amount.Add(item.Amount)
+		spend.Recipients = append(spend.Recipients, item.Member...)
 	}
+	return spend
+}
 
-	// 5. submit transaction
-	_, err = m.SafeSubmitTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{
-		RequestID:      req.RequestId,
-		RawTransaction: signedRaw,
-	})
-	if err != nil {
-		return nil, err
+// authorizeSpend 签名前使用 SpendingPolicy 检查转出, 未设置时直接通过
+func (m *ClientWrapper) authorizeSpend(ctx context.Context, spend *Spend) (func(), error) {
+	if m.policy == nil {
+		return func() {}, nil
 	}
+	return m.policy.Authorize(ctx, spend)
+}
 
-	// 6. read transaction
-	req1, err := m.SafeReadTransactionRequest(ctx, req.RequestId)
-	if err != nil {
-		return nil, err
+func (m MemberAmount) threshold() uint8 {
+	if m.Threshold > 0 {
+		return m.Threshold
 	}
-	return req1, nil
+	return uint8(len(m.Member))
 }
 
 // 一个功能函数，将一个数组中的多个元素切分成 n个数组，每个数组长度最多不超过255个
@@ -630,8 +849,18 @@ func buildTransferMany(memberAmounts []MemberAmount) [][]MemberAmount {
 }
 
 func (m *ClientWrapper) InscriptionTransfer(ctx context.Context, req *InscriptionTransferRequest) (req1 *mixin.SafeTransactionRequest, err error) {
+	ctx, _, end := m.obs.start(ctx, OpTransfer, Attr{"request_id", req.RequestId}, Attr{"asset_id", req.AssetId}, Attr{"kind", "inscription"})
+	defer func() {
+		end(err)
+		m.obs.transfer(ctx, "inscription", req.AssetId, decimal.Zero, err)
+	}()
+
+	if err = m.loadSpendKey(ctx); err != nil {
+		return
+	}
+
 	var utxos []*mixin.SafeUtxo
-	utxos, err = m.SafeListUtxos(ctx, mixin.SafeListUtxoOption{
+	utxos, err = m.listUtxos(ctx, mixin.SafeListUtxoOption{
 		Asset:     req.AssetId,
 		State:     mixin.SafeUtxoStateUnspent,
 		Threshold: 1,
@@ -657,6 +886,24 @@ func (m *ClientWrapper) InscriptionTransfer(ctx context.Context, req *Inscriptio
 		return
 	}
 
+	var release func()
+	release, err = m.authorizeSpend(ctx, &Spend{
+		RequestId:  req.RequestId,
+		AssetId:    req.AssetId,
+		Amount:     utxos[0].Amount,
+		Recipients: []string{req.Member},
+		Memo:       req.Memo,
+	})
+	if err != nil {
+		return
+	}
+	submitted := false
+	defer func() {
+		if !submitted {
+			release()
+		}
+	}()
+
 	b := mixin.NewSafeTransactionBuilder(utxos)
 	b.Memo = req.Memo
 
@@ -676,38 +923,56 @@ func (m *ClientWrapper) InscriptionTransfer(ctx context.Context, req *Inscriptio
 	}
 
 	// 3. create transaction
-	request, err := m.SafeCreateTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{
+	request, err := m.createRequest(ctx, &mixin.SafeTransactionRequestInput{
 		RequestID:      req.RequestId,
 		RawTransaction: raw,
 	})
 	if err != nil {
 		return
 	}
-	// 4. sign transaction
-	err = mixin.SafeSignTransaction(
-		tx,
-		m.SpendKey,
-		request.Views,
-		0,
-	)
-	if err != nil {
-		return
-	}
-	signedRaw, err := tx.Dump()
-	if err != nil {
-		return
-	}
-
-	// 5. submit transaction
-	_, err = m.SafeSubmitTransactionRequest(ctx, &mixin.SafeTransactionRequestInput{
-		RequestID:      req.RequestId,
-		RawTransaction: signedRaw,
-	})
+	// 4. sign and submit transaction
+	submitted, err = m.signAndSubmit(ctx, tx, request, newAuditEntry(m.ClientID, req.RequestId, req.AssetId, req.Memo, utxos, []*mixin.TransactionOutput{txOutout}))
 	if err != nil {
 		return
 	}
 
 	// 6. read transaction
-	req1, err = m.SafeReadTransactionRequest(ctx, req.RequestId)
+	req1, err = m.readRequest(ctx, req.RequestId)
 	return
 }
+
+// Balances 统计机器人未花费的 utxo 余额 (不含铭文), 返回 asset id -> 余额; assetId 为空时统计所有资产
+func (m *ClientWrapper) Balances(ctx context.Context, assetId string) (map[string]decimal.Decimal, error) {
+	balances := make(map[string]decimal.Decimal)
+
+	var cursor uint64
+	for {
+		utxos, err := m.listUtxos(ctx, mixin.SafeListUtxoOption{
+			Asset:     assetId,
+			State:     mixin.SafeUtxoStateUnspent,
+			Threshold: 1,
+			Offset:    cursor,
+			Limit:     500,
+			Order:     "ASC",
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		next := cursor
+		for _, utxo := range utxos {
+			if utxo.Sequence <= cursor && cursor > 0 {
+				continue
+			}
+			if !utxo.InscriptionHash.HasValue() {
+				balances[utxo.AssetID] = balances[utxo.AssetID].Add(utxo.Amount)
+			}
+			next = max(next, utxo.Sequence)
+		}
+
+		if len(utxos) < 500 || next == cursor {
+			return balances, nil
+		}
+		cursor = next
+	}
+}
@@ -1,10 +1,15 @@
 package kit
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"reflect"
 	"testing"
 
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
 	"github.com/shopspring/decimal"
 )
 
@@ -66,3 +71,57 @@ func Test_buildTransferMany(t *testing.T) {
 		})
 	}
 }
+
+func TestNewMixinClientWrapper_Offline(t *testing.T) {
+	server, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spendKey := mixinnet.GenerateKey(rand.Reader)
+	config := &Config{
+		AppID:             testRouteAppID,
+		SessionID:         "0a6d4c2e-1b2f-4f0e-8a77-0f6c7a1e5b3d",
+		ServerPublicKey:   hex.EncodeToString(server),
+		SessionPrivateKey: hex.EncodeToString(bytes.Repeat([]byte{1}, ed25519.SeedSize)),
+		SpendKey:          spendKey.String(),
+	}
+
+	c, err := NewMixinClientWrapper(config, WithSpendPublicKey(spendKey.Public().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.SpendKey != spendKey {
+		t.Errorf("SpendKey = %s, want %s", c.SpendKey, spendKey)
+	}
+	if c.Bot.SpendPrivateKey != "" {
+		t.Error("Bot.SpendPrivateKey should not be set")
+	}
+
+	c, err = NewMixinClientWrapper(&Config{AppID: config.AppID, SessionID: config.SessionID, ServerPublicKey: config.ServerPublicKey, SessionPrivateKey: config.SessionPrivateKey},
+		WithSpendKey(spendKey), WithSpendPublicKey(spendKey.Public().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.SpendKey != spendKey || c.Bot.SpendPrivateKey != "" {
+		t.Errorf("WithSpendKey: SpendKey = %s, Bot.SpendPrivateKey = %q", c.SpendKey, c.Bot.SpendPrivateKey)
+	}
+
+	c, err = NewMixinClientWrapper(config, WithLazyUser())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.SpendKey.HasValue() {
+		t.Error("SpendKey should not be loaded with WithLazyUser")
+	}
+
+	other := mixinnet.GenerateKey(rand.Reader)
+	if _, err := NewMixinClientWrapper(config, WithSpendPublicKey(other.Public().String())); err == nil {
+		t.Error("NewMixinClientWrapper() with mismatched spend public key should fail")
+	}
+	if _, err := NewMixinClientWrapper(config, WithSpendKey(other), WithSpendPublicKey(spendKey.Public().String())); err == nil {
+		t.Error("NewMixinClientWrapper() with mismatched WithSpendKey should fail")
+	}
+	if _, err := NewMixinClientWrapper(nil); err != ErrConfigNil {
+		t.Errorf("NewMixinClientWrapper(nil) error = %v, want %v", err, ErrConfigNil)
+	}
+}
@@ -0,0 +1,33 @@
+package kit
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+)
+
+// mixin-sdk-go 的所有 Client 共用一个包级的 http client, Mixin API 的地址, 超时和 Transport
+// 无法按客户端单独设置, 因此这里不提供对应的 ClientWrapperOption.
+// SetGlobalSafe* 修改这个共享的 client, 影响进程内所有 ClientWrapper 和 mixin.Client,
+// 应在创建客户端前调用一次, 不要并发调用; kittest.SafeServer.UseApiHost 修改的也是这个 client.
+
+// SetGlobalSafeApiHost 设置全局的 Mixin API 地址, 如 https://api.mixin.one
+func SetGlobalSafeApiHost(host string) {
+	mixin.GetRestyClient().SetBaseURL(host)
+}
+
+// SetGlobalSafeTimeout 设置全局的 Mixin API 请求超时
+func SetGlobalSafeTimeout(timeout time.Duration) {
+	mixin.GetRestyClient().SetTimeout(timeout)
+}
+
+// SetGlobalSafeHTTPClient 全局使用 client 的 Transport 和 Timeout 发送 Mixin API 请求
+func SetGlobalSafeHTTPClient(client *http.Client) {
+	if client.Transport != nil {
+		mixin.GetRestyClient().SetTransport(client.Transport)
+	}
+	if client.Timeout > 0 {
+		mixin.GetRestyClient().SetTimeout(client.Timeout)
+	}
+}
@@ -0,0 +1,190 @@
+package kit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/shopspring/decimal"
+)
+
+// 操作名称, span 名称为 "mixin." + 操作名称
+const (
+	OpTransfer      = "transfer"
+	OpConsolidate   = "consolidate"
+	OpListUtxos     = "list_utxos"
+	OpCreateRequest = "create_request"
+	OpSign          = "sign"
+	OpSubmit        = "submit"
+	OpReadRequest   = "read_request"
+	OpWeb3Request   = "web3_request"
+)
+
+// 指标名称
+const (
+	// MetricOperationDuration 各操作的耗时, 标签 operation, status
+	MetricOperationDuration = "mixin_operation_duration_seconds"
+	// MetricAPIRequests API 请求数, 标签 api (safe / web3), endpoint, status (safe 为 ok / error, web3 为 HTTP 状态码或 error)
+	MetricAPIRequests = "mixin_api_requests_total"
+	// MetricAPIDuration API 请求耗时, 标签 api, endpoint
+	MetricAPIDuration = "mixin_api_request_duration_seconds"
+	// MetricTransfers 转账笔数, 标签 asset_id, kind (one / many / inscription), status
+	MetricTransfers = "mixin_transfers_total"
+	// MetricTransferAmount 成功转出的金额, 标签 asset_id
+	MetricTransferAmount = "mixin_transfer_amount_total"
+	// MetricConsolidations 提交的 utxo 聚合交易数, 标签 asset_id
+	MetricConsolidations = "mixin_consolidations_total"
+)
+
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Attr span 属性或指标标签
+type Attr struct {
+	Key   string
+	Value string
+}
+
+// Span 一次操作, End 时 err 不为 nil 表示失败
+type Span interface {
+	SetAttributes(attrs ...Attr)
+	End(err error)
+}
+
+// Tracer 创建 span, 返回的 ctx 携带新的 span. 接入 OpenTelemetry 见 kitotel
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span)
+}
+
+// TracerFunc 函数形式的 Tracer
+type TracerFunc func(ctx context.Context, name string, attrs ...Attr) (context.Context, Span)
+
+func (f TracerFunc) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span) {
+	return f(ctx, name, attrs...)
+}
+
+// Meter 记录指标, 如 PrometheusRegistry 或 kitotel.Meter
+type Meter interface {
+	AddCounter(ctx context.Context, name string, value float64, attrs ...Attr)
+	ObserveDuration(ctx context.Context, name string, d time.Duration, attrs ...Attr)
+}
+
+// NopTracer 默认的 Tracer, 不做任何事
+type NopTracer struct{}
+
+func (NopTracer) Start(ctx context.Context, name string, attrs ...Attr) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(attrs ...Attr) {}
+func (nopSpan) End(err error)               {}
+
+// NopMeter 默认的 Meter, 不做任何事
+type NopMeter struct{}
+
+func (NopMeter) AddCounter(ctx context.Context, name string, value float64, attrs ...Attr) {}
+func (NopMeter) ObserveDuration(ctx context.Context, name string, d time.Duration, attrs ...Attr) {
+}
+
+type observer struct {
+	tracer Tracer
+	meter  Meter
+}
+
+func newObserver(tracer Tracer, meter Meter) observer {
+	if tracer == nil {
+		tracer = NopTracer{}
+	}
+	if meter == nil {
+		meter = NopMeter{}
+	}
+	return observer{tracer: tracer, meter: meter}
+}
+
+func statusOf(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusOK
+}
+
+// start 开始一个操作, 结束时调用返回的函数记录 span 和耗时
+func (o observer) start(ctx context.Context, op string, attrs ...Attr) (context.Context, Span, func(err error)) {
+	begin := time.Now()
+	ctx, span := o.tracer.Start(ctx, "mixin."+op, attrs...)
+	return ctx, span, func(err error) {
+		span.End(err)
+		o.meter.ObserveDuration(ctx, MetricOperationDuration, time.Since(begin), Attr{"operation", op}, Attr{"status", statusOf(err)})
+	}
+}
+
+// api 开始一次 Mixin API 请求, 在 start 的基础上记录请求数和耗时
+func (o observer) api(ctx context.Context, op string, attrs ...Attr) (context.Context, func(err error)) {
+	begin := time.Now()
+	ctx, _, end := o.start(ctx, op, attrs...)
+	return ctx, func(err error) {
+		end(err)
+		o.meter.AddCounter(ctx, MetricAPIRequests, 1, Attr{"api", "safe"}, Attr{"endpoint", op}, Attr{"status", statusOf(err)})
+		o.meter.ObserveDuration(ctx, MetricAPIDuration, time.Since(begin), Attr{"api", "safe"}, Attr{"endpoint", op})
+	}
+}
+
+// transfer 记录一笔转账的结果
+func (o observer) transfer(ctx context.Context, kind, assetId string, amount decimal.Decimal, err error) {
+	o.meter.AddCounter(ctx, MetricTransfers, 1, Attr{"asset_id", assetId}, Attr{"kind", kind}, Attr{"status", statusOf(err)})
+	if err == nil {
+		o.meter.AddCounter(ctx, MetricTransferAmount, amount.InexactFloat64(), Attr{"asset_id", assetId})
+	}
+}
+
+// ObservabilityMiddleware 为每次 Route 请求 (包括重试) 创建 span, 并记录请求数和耗时.
+// 路径中的 uuid 等 id 替换为 :id, 避免指标标签过多
+func ObservabilityMiddleware(tracer Tracer, meter Meter) Web3Middleware {
+	o := newObserver(tracer, meter)
+	return func(next Web3Handler) Web3Handler {
+		return func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+			endpoint := req.Method + " " + web3Route(req.Path)
+			begin := time.Now()
+			ctx, span := o.tracer.Start(ctx, "mixin."+OpWeb3Request,
+				Attr{"http.method", req.Method},
+				Attr{"http.route", endpoint},
+				Attr{"attempt", strconv.Itoa(req.Attempt)},
+			)
+
+			resp, err := next(ctx, req)
+
+			status := StatusError
+			if err == nil {
+				status = strconv.Itoa(resp.StatusCode)
+				span.SetAttributes(Attr{"http.status_code", status})
+			}
+			failed := err
+			if err == nil && resp.StatusCode >= http.StatusBadRequest {
+				failed = newMixinOracleAPIError(resp.StatusCode, resp.Body)
+			}
+			span.End(failed)
+
+			o.meter.AddCounter(ctx, MetricAPIRequests, 1, Attr{"api", "web3"}, Attr{"endpoint", endpoint}, Attr{"status", status})
+			o.meter.ObserveDuration(ctx, MetricAPIDuration, time.Since(begin), Attr{"api", "web3"}, Attr{"endpoint", endpoint})
+			return resp, err
+		}
+	}
+}
+
+// web3Route 将路径中的 id 段替换为 :id, 如 /web3/swap/orders/{uuid} -> /web3/swap/orders/:id
+func web3Route(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if _, err := uuid.FromString(s); err == nil || len(s) >= 32 {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
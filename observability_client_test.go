@@ -0,0 +1,57 @@
+package kit_test
+
+import (
+	"context"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+func TestClientWrapper_Observability(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+
+	var spans []string
+	tracer := kit.TracerFunc(func(ctx context.Context, name string, attrs ...kit.Attr) (context.Context, kit.Span) {
+		spans = append(spans, name)
+		return kit.NopTracer{}.Start(ctx, name, attrs...)
+	})
+	registry := kit.NewPrometheusRegistry()
+	client := server.NewClientWrapper(t, kit.WithTracer(tracer), kit.WithMeter(registry))
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+
+	ctx := context.Background()
+	if _, err := client.TransferOne(ctx, &kit.TransferOneRequest{
+		RequestId: mixin.RandomTraceID(),
+		AssetId:   testPoolAsset,
+		Member:    testPoolRecipient,
+		Amount:    decimal.NewFromInt(4),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{}
+	for _, op := range []string{kit.OpTransfer, kit.OpConsolidate, kit.OpListUtxos, kit.OpCreateRequest, kit.OpSign, kit.OpSubmit, kit.OpReadRequest} {
+		want["mixin."+op] = true
+	}
+	for _, name := range spans {
+		delete(want, name)
+	}
+	if len(want) > 0 {
+		t.Errorf("missing spans %v, got %v", want, spans)
+	}
+
+	asset := kit.Attr{Key: "asset_id", Value: testPoolAsset}
+	if got := registry.Counter(kit.MetricTransfers, asset, kit.Attr{Key: "kind", Value: "one"}, kit.Attr{Key: "status", Value: kit.StatusOK}); got != 1 {
+		t.Errorf("%s = %v, want 1", kit.MetricTransfers, got)
+	}
+	if got := registry.Counter(kit.MetricTransferAmount, asset); got != 4 {
+		t.Errorf("%s = %v, want 4", kit.MetricTransferAmount, got)
+	}
+	submit := []kit.Attr{{Key: "api", Value: "safe"}, {Key: "endpoint", Value: kit.OpSubmit}, {Key: "status", Value: kit.StatusOK}}
+	if got := registry.Counter(kit.MetricAPIRequests, submit...); got != 1 {
+		t.Errorf("%s{endpoint=submit} = %v, want 1", kit.MetricAPIRequests, got)
+	}
+}
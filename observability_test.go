@@ -0,0 +1,98 @@
+package kit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordedSpan struct {
+	name  string
+	attrs []Attr
+	err   error
+	ended bool
+}
+
+func (s *recordedSpan) SetAttributes(attrs ...Attr) { s.attrs = append(s.attrs, attrs...) }
+func (s *recordedSpan) End(err error)               { s.err, s.ended = err, true }
+
+func (s *recordedSpan) attr(key string) string {
+	for _, a := range s.attrs {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// recordingTracer 记录所有 span, 供测试使用
+func recordingTracer(spans *[]*recordedSpan) Tracer {
+	return TracerFunc(func(ctx context.Context, name string, attrs ...Attr) (context.Context, Span) {
+		s := &recordedSpan{name: name, attrs: attrs}
+		*spans = append(*spans, s)
+		return ctx, s
+	})
+}
+
+func TestObservabilityMiddleware(t *testing.T) {
+	var spans []*recordedSpan
+	registry := NewPrometheusRegistry()
+
+	h := func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+		if req.Attempt == 0 {
+			return &Web3RawResponse{StatusCode: http.StatusBadGateway}, nil
+		}
+		return &Web3RawResponse{StatusCode: http.StatusOK}, nil
+	}
+	mws := []Web3Middleware{RetryMiddleware(1, time.Millisecond), ObservabilityMiddleware(recordingTracer(&spans), registry)}
+	if _, err := chainWeb3Middlewares(h, mws)(context.Background(), &Web3Request{Method: http.MethodGet, Path: "/web3/swap/orders/c6d0c728-2624-429b-8e0d-d9d19b6592fa"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	if spans[0].err == nil || spans[1].err != nil || spans[1].attr("http.status_code") != "200" {
+		t.Errorf("spans = %+v, %+v", spans[0], spans[1])
+	}
+
+	endpoint := Attr{"endpoint", "GET /web3/swap/orders/:id"}
+	for _, status := range []string{"502", "200"} {
+		if got := registry.Counter(MetricAPIRequests, Attr{"api", "web3"}, endpoint, Attr{"status", status}); got != 1 {
+			t.Errorf("%s{status=%s} = %v, want 1", MetricAPIRequests, status, got)
+		}
+	}
+}
+
+func TestPrometheusRegistry(t *testing.T) {
+	ctx := context.Background()
+	r := NewPrometheusRegistry(WithPrometheusBuckets(time.Second, 100*time.Millisecond))
+	r.AddCounter(ctx, MetricTransfers, 1, Attr{"status", "ok"}, Attr{"asset_id", "a"})
+	r.AddCounter(ctx, MetricTransfers, 2, Attr{"asset_id", "a"}, Attr{"status", "ok"})
+	r.AddCounter(ctx, "custom_total", 1, Attr{"http.route", "say \"hi\"\n"})
+	r.ObserveDuration(ctx, MetricAPIDuration, 50*time.Millisecond, Attr{"api", "safe"})
+	r.ObserveDuration(ctx, MetricAPIDuration, 2*time.Second, Attr{"api", "safe"})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	for _, want := range []string{
+		"# TYPE mixin_transfers_total counter\n",
+		`mixin_transfers_total{asset_id="a",status="ok"} 3` + "\n",
+		`custom_total{http_route="say \"hi\"\n"} 1` + "\n",
+		"# TYPE mixin_api_request_duration_seconds histogram\n",
+		`mixin_api_request_duration_seconds_bucket{api="safe",le="0.1"} 1` + "\n",
+		`mixin_api_request_duration_seconds_bucket{api="safe",le="1"} 1` + "\n",
+		`mixin_api_request_duration_seconds_bucket{api="safe",le="+Inf"} 2` + "\n",
+		`mixin_api_request_duration_seconds_sum{api="safe"} 2.05` + "\n",
+		`mixin_api_request_duration_seconds_count{api="safe"} 2` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package kit
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+)
+
+const (
+	defaultListenerInterval = 3 * time.Second
+	defaultListenerLimit    = 500
+)
+
+// Payment 收到的一笔 utxo 及其解码后的 memo
+type Payment struct {
+	*mixin.SafeUtxo
+	Memo string
+}
+
+// DecodeUtxoMemo 解码 utxo 的 extra 字段 (hex 编码), 非 hex 时原样返回
+func DecodeUtxoMemo(extra string) string {
+	b, err := hex.DecodeString(extra)
+	if err != nil {
+		return extra
+	}
+	return string(b)
+}
+
+type PaymentHandler func(ctx context.Context, p *Payment) error
+
+// CursorStore 持久化 PaymentListener 已处理的 utxo sequence
+type CursorStore interface {
+	Load(ctx context.Context) (uint64, error)
+	Save(ctx context.Context, sequence uint64) error
+}
+
+// MemoryCursorStore 进程内的 CursorStore, 重启后从 0 开始
+type MemoryCursorStore struct {
+	mu       sync.Mutex
+	sequence uint64
+}
+
+func (s *MemoryCursorStore) Load(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sequence, nil
+}
+
+func (s *MemoryCursorStore) Save(ctx context.Context, sequence uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sequence = sequence
+	return nil
+}
+
+// FileCursorStore 将 sequence 写入文件, 先写临时文件再 rename 保证原子性
+type FileCursorStore struct {
+	Path string
+}
+
+func (s *FileCursorStore) Load(ctx context.Context) (uint64, error) {
+	b, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+}
+
+func (s *FileCursorStore) Save(ctx context.Context, sequence uint64) error {
+	f, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(strconv.FormatUint(sequence, 10)); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.Path)
+}
+
+type memoPrefixHandler struct {
+	prefix  string
+	handler PaymentHandler
+}
+
+// PaymentListener 按 sequence 顺序跟踪机器人收到的 utxos, 并分发给注册的 handler
+//
+// 每处理完一个 utxo 就保存一次游标, handler 返回错误时游标不前进, 下次轮询会重新分发该 utxo.
+type PaymentListener struct {
+	client      *ClientWrapper
+	store       CursorStore
+	interval    time.Duration
+	limit       int
+	includeSelf bool
+	logger      *slog.Logger
+
+	mu             sync.RWMutex
+	assetHandlers  map[string]PaymentHandler
+	prefixHandlers []memoPrefixHandler
+	defaultHandler PaymentHandler
+}
+
+// PaymentListenerOption 定义 PaymentListener 选项
+type PaymentListenerOption func(*PaymentListener)
+
+// WithListenerInterval 设置轮询间隔
+func WithListenerInterval(interval time.Duration) PaymentListenerOption {
+	return func(l *PaymentListener) {
+		l.interval = interval
+	}
+}
+
+// WithListenerLimit 设置每次拉取的 utxo 数量, limit < 2 时忽略;
+// 服务端的 offset 可能包含游标所在的 utxo, 每页至少要能拿到一个新的 utxo
+func WithListenerLimit(limit int) PaymentListenerOption {
+	return func(l *PaymentListener) {
+		if limit < 2 {
+			return
+		}
+		l.limit = limit
+	}
+}
+
+// WithListenerIncludeSelf 设置是否分发机器人自己转给自己的 utxo (例如找零、聚合)
+func WithListenerIncludeSelf(include bool) PaymentListenerOption {
+	return func(l *PaymentListener) {
+		l.includeSelf = include
+	}
+}
+
+func WithListenerLogger(logger *slog.Logger) PaymentListenerOption {
+	return func(l *PaymentListener) {
+		l.logger = logger
+	}
+}
+
+func NewPaymentListener(client *ClientWrapper, store CursorStore, opts ...PaymentListenerOption) *PaymentListener {
+	if store == nil {
+		store = &MemoryCursorStore{}
+	}
+
+	l := &PaymentListener{
+		client:        client,
+		store:         store,
+		interval:      defaultListenerInterval,
+		limit:         defaultListenerLimit,
+		logger:        slog.Default(),
+		assetHandlers: make(map[string]PaymentHandler),
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// HandleAsset 注册某个资产的 handler
+func (l *PaymentListener) HandleAsset(assetId string, h PaymentHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.assetHandlers[assetId] = h
+}
+
+// HandleMemoPrefix 注册 memo 前缀的 handler, 多个前缀匹配时最长的优先, 且优先于资产 handler
+func (l *PaymentListener) HandleMemoPrefix(prefix string, h PaymentHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range l.prefixHandlers {
+		if l.prefixHandlers[i].prefix == prefix {
+			l.prefixHandlers[i].handler = h
+			return
+		}
+	}
+
+	l.prefixHandlers = append(l.prefixHandlers, memoPrefixHandler{prefix: prefix, handler: h})
+	sort.SliceStable(l.prefixHandlers, func(i, j int) bool {
+		return len(l.prefixHandlers[i].prefix) > len(l.prefixHandlers[j].prefix)
+	})
+}
+
+// HandleDefault 注册未匹配任何前缀和资产时的 handler
+func (l *PaymentListener) HandleDefault(h PaymentHandler) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.defaultHandler = h
+}
+
+func (l *PaymentListener) handler(p *Payment) PaymentHandler {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, ph := range l.prefixHandlers {
+		if strings.HasPrefix(p.Memo, ph.prefix) {
+			return ph.handler
+		}
+	}
+
+	if h, ok := l.assetHandlers[p.AssetID]; ok {
+		return h
+	}
+
+	return l.defaultHandler
+}
+
+// Run 持续轮询直到 ctx 结束
+func (l *PaymentListener) Run(ctx context.Context) error {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := l.Poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			l.logger.Error("payment listener poll", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll 拉取并分发游标之后的 utxos, 返回本次处理的数量
+func (l *PaymentListener) Poll(ctx context.Context) (int, error) {
+	cursor, err := l.store.Load(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("load cursor: %w", err)
+	}
+
+	processed := 0
+	for {
+		start := cursor
+		// 不按状态过滤: 收到的 utxo 可能在轮询前就已经被转账花掉
+		utxos, err := l.client.SafeListUtxos(ctx, mixin.SafeListUtxoOption{
+			Offset: cursor,
+			Limit:  l.limit,
+			Order:  "ASC",
+		})
+		if err != nil {
+			return processed, err
+		}
+
+		for _, utxo := range utxos {
+			// offset 是否包含边界由服务端决定, 这里统一跳过已处理的 sequence
+			if utxo.Sequence <= cursor {
+				continue
+			}
+
+			if err := l.dispatch(ctx, utxo); err != nil {
+				return processed, fmt.Errorf("handle output %s: %w", utxo.OutputID, err)
+			}
+
+			cursor = utxo.Sequence
+			if err := l.store.Save(ctx, cursor); err != nil {
+				return processed, fmt.Errorf("save cursor: %w", err)
+			}
+			processed++
+		}
+
+		// 游标没有前进时再请求只会拿到同一页
+		if len(utxos) < l.limit || cursor == start {
+			return processed, nil
+		}
+	}
+}
+
+func (l *PaymentListener) dispatch(ctx context.Context, utxo *mixin.SafeUtxo) error {
+	if !l.includeSelf && l.isSelfPayment(utxo) {
+		return nil
+	}
+
+	p := &Payment{
+		SafeUtxo: utxo,
+		Memo:     DecodeUtxoMemo(utxo.Extra),
+	}
+
+	h := l.handler(p)
+	if h == nil {
+		l.logger.Warn("payment listener: no handler", "output_id", utxo.OutputID, "asset_id", utxo.AssetID, "memo", p.Memo)
+		return nil
+	}
+
+	return h(ctx, p)
+}
+
+func (l *PaymentListener) isSelfPayment(utxo *mixin.SafeUtxo) bool {
+	if len(utxo.Senders) == 0 {
+		return false
+	}
+	for _, sender := range utxo.Senders {
+		if sender != l.client.ClientID {
+			return false
+		}
+	}
+	return true
+}
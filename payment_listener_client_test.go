@@ -0,0 +1,54 @@
+package kit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/shopspring/decimal"
+)
+
+func TestPaymentListener_PollLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+	}{
+		{name: "zero", limit: 0},
+		{name: "negative", limit: -1},
+		{name: "one", limit: 1},
+		{name: "two", limit: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := kittest.NewSafeServer(t)
+			client := server.NewClientWrapper(t)
+			for i := 0; i < 3; i++ {
+				server.Deposit(testPoolAsset, decimal.NewFromInt(1))
+			}
+
+			var got int
+			l := kit.NewPaymentListener(client, nil, kit.WithListenerLimit(tt.limit))
+			l.HandleDefault(func(ctx context.Context, p *kit.Payment) error {
+				got++
+				return nil
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			n, err := l.Poll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 3 || got != 3 {
+				t.Errorf("processed %d, handled %d, want 3", n, got)
+			}
+
+			n, err = l.Poll(ctx)
+			if err != nil || n != 0 {
+				t.Errorf("second Poll = %d, %v, want 0", n, err)
+			}
+		})
+	}
+}
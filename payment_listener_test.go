@@ -0,0 +1,72 @@
+package kit
+
+import (
+	"context"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+)
+
+func TestPaymentListener_handler(t *testing.T) {
+	var got string
+	handlerNamed := func(name string) PaymentHandler {
+		return func(ctx context.Context, p *Payment) error {
+			got = name
+			return nil
+		}
+	}
+
+	l := NewPaymentListener(nil, nil)
+	l.HandleAsset(testInvoiceAssetBTC, handlerNamed("btc"))
+	l.HandleMemoPrefix("swap:", handlerNamed("swap"))
+	l.HandleMemoPrefix("swap:btc:", handlerNamed("swap-btc"))
+	l.HandleDefault(handlerNamed("default"))
+
+	tests := []struct {
+		name    string
+		assetId string
+		memo    string
+		want    string
+	}{
+		{name: "longest prefix", assetId: testInvoiceAssetSOL, memo: "swap:btc:1", want: "swap-btc"},
+		{name: "prefix before asset", assetId: testInvoiceAssetBTC, memo: "swap:sol", want: "swap"},
+		{name: "asset", assetId: testInvoiceAssetBTC, memo: "hello", want: "btc"},
+		{name: "default", assetId: testInvoiceAssetSOL, memo: "hello", want: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = ""
+			p := &Payment{
+				SafeUtxo: &mixin.SafeUtxo{AssetID: tt.assetId, Extra: hex.EncodeToString([]byte(tt.memo))},
+				Memo:     tt.memo,
+			}
+			if err := l.handler(p)(context.Background(), p); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("handler = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileCursorStore(t *testing.T) {
+	ctx := context.Background()
+	store := &FileCursorStore{Path: filepath.Join(t.TempDir(), "cursor")}
+
+	seq, err := store.Load(ctx)
+	if err != nil || seq != 0 {
+		t.Fatalf("Load() = %d, %v, want 0, nil", seq, err)
+	}
+
+	if err := store.Save(ctx, 42); err != nil {
+		t.Fatal(err)
+	}
+
+	seq, err = store.Load(ctx)
+	if err != nil || seq != 42 {
+		t.Fatalf("Load() = %d, %v, want 42, nil", seq, err)
+	}
+}
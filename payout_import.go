@@ -0,0 +1,682 @@
+package kit
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"github.com/gofrs/uuid/v5"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidPayout       = errors.New("invalid payout")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
+// DefaultPayoutPrecision Mixin 资产金额最多 8 位小数
+const DefaultPayoutPrecision = 8
+
+// PayoutRow 批量转账文件中的一行, Line 从 1 开始 (csv 包括表头)
+type PayoutRow struct {
+	Line      int             `json:"line"`
+	AssetId   string          `json:"asset_id,omitempty"` // 为空时使用 WithPayoutAsset
+	Members   []string        `json:"members"`
+	Threshold uint8           `json:"threshold,omitempty"` // 为 0 时为 len(Members)
+	Amount    decimal.Decimal `json:"amount"`
+	Memo      string          `json:"memo,omitempty"`
+}
+
+// PayoutRowError 第 Line 行 Field 字段的问题
+type PayoutRowError struct {
+	Line    int
+	Field   string
+	Problem string
+}
+
+func (e PayoutRowError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Problem)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Problem)
+}
+
+// PayoutErrors 读取或校验时发现的所有问题, errors.Is(err, ErrInvalidPayout) 为 true
+type PayoutErrors []PayoutRowError
+
+func (e PayoutErrors) Error() string {
+	problems := make([]string, len(e))
+	for i, p := range e {
+		problems[i] = p.Error()
+	}
+	return ErrInvalidPayout.Error() + ": " + strings.Join(problems, "; ")
+}
+
+func (e PayoutErrors) Is(target error) bool {
+	return target == ErrInvalidPayout
+}
+
+func (e *PayoutErrors) add(line int, field, format string, args ...any) {
+	*e = append(*e, PayoutRowError{Line: line, Field: field, Problem: fmt.Sprintf(format, args...)})
+}
+
+func (e PayoutErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ReadPayoutCSV 读取带表头的 csv, 列名 (不区分大小写):
+// member 或 members (多个成员用 ; 分隔), threshold, amount, memo, asset_id 或 asset; 其中 member 和 amount 必须存在.
+// 兼容旧的没有表头的 member,amount 格式: 第一行的第二列是数字时视为数据行
+func ReadPayoutCSV(r io.Reader) ([]PayoutRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidPayout)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		switch name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))); name {
+		case "member", "members":
+			columns["members"] = i
+		case "asset", "asset_id":
+			columns["asset_id"] = i
+		case "threshold", "amount", "memo":
+			columns[name] = i
+		}
+	}
+	// 没有表头的 member,amount 文件, 第一行也是数据
+	var pending []string
+	if len(columns) == 0 && len(header) == 2 {
+		if _, err := decimal.NewFromString(strings.TrimSpace(header[1])); err == nil {
+			columns = map[string]int{"members": 0, "amount": 1}
+			pending = header
+		}
+	}
+	for _, name := range []string{"members", "amount"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: csv header has no %s column, add a header line such as \"member,amount,memo\"", ErrInvalidPayout, name)
+		}
+	}
+
+	var rows []PayoutRow
+	var errs PayoutErrors
+	for {
+		record := pending
+		if pending != nil {
+			pending = nil
+		} else if record, err = cr.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		row := PayoutRow{Line: line, AssetId: field("asset_id"), Memo: field("memo")}
+		for _, m := range strings.Split(field("members"), ";") {
+			if m = strings.TrimSpace(m); m != "" {
+				row.Members = append(row.Members, m)
+			}
+		}
+		if s := field("threshold"); s != "" {
+			t, err := strconv.ParseUint(s, 10, 8)
+			if err != nil {
+				errs.add(line, "threshold", "invalid number %q", s)
+				continue
+			}
+			row.Threshold = uint8(t)
+		}
+		if row.Amount, err = decimal.NewFromString(field("amount")); err != nil {
+			errs.add(line, "amount", "invalid number %q", field("amount"))
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, errs.err()
+}
+
+type payoutJSONRow struct {
+	AssetId   string          `json:"asset_id"`
+	Member    string          `json:"member"`
+	Members   []string        `json:"members"`
+	Threshold uint8           `json:"threshold"`
+	Amount    decimal.Decimal `json:"amount"`
+	Memo      string          `json:"memo"`
+}
+
+// ReadPayoutJSONL 读取每行一个 JSON 对象的文件, 字段 member 或 members, threshold, amount, memo, asset_id
+func ReadPayoutJSONL(r io.Reader) ([]PayoutRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []PayoutRow
+	var errs PayoutErrors
+	for line := 1; scanner.Scan(); line++ {
+		b := scanner.Bytes()
+		if len(strings.TrimSpace(string(b))) == 0 {
+			continue
+		}
+
+		var v payoutJSONRow
+		if err := json.Unmarshal(b, &v); err != nil {
+			errs.add(line, "", "invalid json: %v", err)
+			continue
+		}
+		members := v.Members
+		if v.Member != "" {
+			members = append([]string{v.Member}, members...)
+		}
+		rows = append(rows, PayoutRow{
+			Line:      line,
+			AssetId:   v.AssetId,
+			Members:   members,
+			Threshold: v.Threshold,
+			Amount:    v.Amount,
+			Memo:      v.Memo,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, errs.err()
+}
+
+// ReadPayoutFile 按扩展名 (.csv, .jsonl, .ndjson) 读取批量转账文件
+func ReadPayoutFile(path string) ([]PayoutRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		return ReadPayoutCSV(f)
+	case ".jsonl", ".ndjson":
+		return ReadPayoutJSONL(f)
+	default:
+		return nil, fmt.Errorf("%w: unsupported payout file %q", ErrInvalidPayout, ext)
+	}
+}
+
+// Payout 合并后的一笔转出, 收款人, 门限, 资产和 memo 都相同的行合并为一笔, Lines 为原始行号
+type Payout struct {
+	AssetId   string
+	Members   []string
+	Threshold uint8
+	Amount    decimal.Decimal
+	Memo      string
+	Lines     []int
+}
+
+func (p *Payout) key() string {
+	return strings.Join([]string{p.AssetId, strconv.Itoa(int(p.Threshold)), p.Memo, strings.Join(p.Members, ",")}, "|")
+}
+
+// PayoutPlan 校验和合并后的转账计划
+type PayoutPlan struct {
+	Payouts  []Payout
+	Rows     []PayoutRow
+	Totals   map[string]decimal.Decimal // asset id -> 合计金额
+	Balances map[string]decimal.Decimal // asset id -> 当前余额, 未检查时为空
+}
+
+// InsufficientBalanceError AssetId 的余额不足以完成计划
+type InsufficientBalanceError struct {
+	AssetId  string
+	Balance  decimal.Decimal
+	Required decimal.Decimal
+}
+
+func (e *InsufficientBalanceError) Error() string {
+	return fmt.Sprintf("%s: asset %s has %s, requires %s", ErrInsufficientBalance, e.AssetId, e.Balance, e.Required)
+}
+
+func (e *InsufficientBalanceError) Is(target error) bool { return target == ErrInsufficientBalance }
+
+const (
+	PayoutStatusSubmitted = "submitted"
+	PayoutStatusFailed    = "failed"
+	PayoutStatusSkipped   = "skipped"
+)
+
+// PayoutResult 一行的执行结果, 合并的行共用同一笔交易
+type PayoutResult struct {
+	Line            int             `json:"line"`
+	AssetId         string          `json:"asset_id"`
+	Members         []string        `json:"members"`
+	Threshold       uint8           `json:"threshold"`
+	Amount          decimal.Decimal `json:"amount"`
+	Memo            string          `json:"memo,omitempty"`
+	RequestId       string          `json:"request_id,omitempty"`
+	TransactionHash string          `json:"transaction_hash,omitempty"`
+	Status          string          `json:"status"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// PayoutProgress 每个批次完成后的进度
+type PayoutProgress struct {
+	Batch     int // 已完成的批次数
+	Batches   int
+	Rows      int // 已提交的行数
+	TotalRows int
+	AssetId   string
+	RequestId string
+}
+
+// PayoutImporter 读取批量转账文件, 校验, 合并, 检查余额后使用 TransferMany 分批转出
+type PayoutImporter struct {
+	client    *ClientWrapper
+	assetId   string
+	memo      string
+	precision int32
+	progress  func(PayoutProgress)
+}
+
+// PayoutOption 定义 PayoutImporter 选项
+type PayoutOption func(*PayoutImporter)
+
+// WithPayoutAsset 设置没有 asset_id 列的行使用的资产
+func WithPayoutAsset(assetId string) PayoutOption {
+	return func(p *PayoutImporter) {
+		p.assetId = assetId
+	}
+}
+
+// WithPayoutMemo 设置没有 memo 的行使用的 memo
+func WithPayoutMemo(memo string) PayoutOption {
+	return func(p *PayoutImporter) {
+		p.memo = memo
+	}
+}
+
+// WithPayoutPrecision 设置金额允许的小数位数, 默认 DefaultPayoutPrecision
+func WithPayoutPrecision(precision int32) PayoutOption {
+	return func(p *PayoutImporter) {
+		p.precision = precision
+	}
+}
+
+// WithPayoutProgress 每个批次完成后调用 fn
+func WithPayoutProgress(fn func(PayoutProgress)) PayoutOption {
+	return func(p *PayoutImporter) {
+		p.progress = fn
+	}
+}
+
+// NewPayoutImporter client 为 nil 时只能用于 Validate 和 Merge
+func NewPayoutImporter(client *ClientWrapper, opts ...PayoutOption) *PayoutImporter {
+	p := &PayoutImporter{
+		client:    client,
+		precision: DefaultPayoutPrecision,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Validate 校验所有行, 有问题时返回 PayoutErrors
+func (p *PayoutImporter) Validate(rows []PayoutRow) error {
+	var errs PayoutErrors
+	for _, row := range rows {
+		assetId := row.AssetId
+		if assetId == "" {
+			assetId = p.assetId
+		}
+		if assetId == "" {
+			errs.add(row.Line, "asset_id", "is required")
+		} else if !validPayoutUUID(assetId) {
+			errs.add(row.Line, "asset_id", "%q is not a uuid", assetId)
+		}
+
+		if len(row.Members) == 0 {
+			errs.add(row.Line, "members", "is required")
+		}
+		seen := map[string]bool{}
+		for _, m := range row.Members {
+			switch {
+			case !validPayoutUUID(m):
+				errs.add(row.Line, "members", "%q is not a uuid", m)
+			case seen[m]:
+				errs.add(row.Line, "members", "duplicate member %s", m)
+			}
+			seen[m] = true
+		}
+		if int(row.Threshold) > len(row.Members) {
+			errs.add(row.Line, "threshold", "%d exceeds %d members", row.Threshold, len(row.Members))
+		}
+
+		memo := row.Memo
+		if memo == "" {
+			memo = p.memo
+		}
+		if len(memo) > mixinnet.ExtraSizeGeneralLimit {
+			errs.add(row.Line, "memo", "is %d bytes, longer than %d", len(memo), mixinnet.ExtraSizeGeneralLimit)
+		}
+
+		switch {
+		case !row.Amount.IsPositive():
+			errs.add(row.Line, "amount", "must be positive")
+		case !row.Amount.Equal(row.Amount.Truncate(p.precision)):
+			errs.add(row.Line, "amount", "%s has more than %d decimal places", row.Amount, p.precision)
+		}
+	}
+	return errs.err()
+}
+
+// validPayoutUUID 只接受小写的标准格式
+func validPayoutUUID(s string) bool {
+	id, err := uuid.FromString(s)
+	return err == nil && id.String() == s
+}
+
+// Merge 合并资产, 收款人 (不计顺序), 门限和 memo 都相同的行, 按首次出现的顺序返回
+func (p *PayoutImporter) Merge(rows []PayoutRow) []Payout {
+	var payouts []Payout
+	index := map[string]int{}
+	for _, row := range rows {
+		payout := Payout{
+			AssetId:   row.AssetId,
+			Members:   append([]string(nil), row.Members...),
+			Threshold: MemberAmount{Member: row.Members, Threshold: row.Threshold}.threshold(),
+			Amount:    row.Amount,
+			Memo:      row.Memo,
+			Lines:     []int{row.Line},
+		}
+		if payout.AssetId == "" {
+			payout.AssetId = p.assetId
+		}
+		if payout.Memo == "" {
+			payout.Memo = p.memo
+		}
+		sort.Strings(payout.Members)
+
+		key := payout.key()
+		if i, ok := index[key]; ok {
+			payouts[i].Amount = payouts[i].Amount.Add(row.Amount)
+			payouts[i].Lines = append(payouts[i].Lines, row.Line)
+			continue
+		}
+		index[key] = len(payouts)
+		payouts = append(payouts, payout)
+	}
+	return payouts
+}
+
+// Plan 校验并合并 rows, 统计每个资产的合计并检查余额.
+// 余额不足时同时返回计划和 InsufficientBalanceError
+func (p *PayoutImporter) Plan(ctx context.Context, rows []PayoutRow) (*PayoutPlan, error) {
+	if err := p.Validate(rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no payout rows", ErrInvalidPayout)
+	}
+
+	plan := &PayoutPlan{
+		Payouts: p.Merge(rows),
+		Rows:    rows,
+		Totals:  map[string]decimal.Decimal{},
+	}
+	for _, payout := range plan.Payouts {
+		plan.Totals[payout.AssetId] = plan.Totals[payout.AssetId].Add(payout.Amount)
+	}
+	if p.client == nil {
+		return plan, nil
+	}
+
+	assets := make([]string, 0, len(plan.Totals))
+	for assetId := range plan.Totals {
+		assets = append(assets, assetId)
+	}
+	sort.Strings(assets)
+
+	var errs []error
+	plan.Balances = map[string]decimal.Decimal{}
+	for _, assetId := range assets {
+		balances, err := p.client.Balances(ctx, assetId)
+		if err != nil {
+			return nil, err
+		}
+		balance := balances[assetId]
+		plan.Balances[assetId] = balance
+		if required := plan.Totals[assetId]; balance.LessThan(required) {
+			errs = append(errs, &InsufficientBalanceError{AssetId: assetId, Balance: balance, Required: required})
+		}
+	}
+	return plan, errors.Join(errs...)
+}
+
+type payoutBatch struct {
+	assetId   string
+	memo      string
+	requestId string
+	payouts   []Payout
+}
+
+// batches 按资产和 memo 分组, 每组按 MAX_UTXO_NUM 分批, 请求 id 由 requestId 派生, 重新执行时不会重复转账
+func (plan *PayoutPlan) batches(requestId string) []payoutBatch {
+	var groups [][]Payout
+	index := map[string]int{}
+	for _, payout := range plan.Payouts {
+		key := payout.AssetId + "|" + payout.Memo
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], payout)
+	}
+
+	var batches []payoutBatch
+	for _, group := range groups {
+		for i := 0; i < len(group); i += MAX_UTXO_NUM {
+			end := min(i+MAX_UTXO_NUM, len(group))
+			batches = append(batches, payoutBatch{
+				assetId:   group[0].AssetId,
+				memo:      group[0].Memo,
+				requestId: payoutBatchRequestId(requestId, group[0].AssetId, group[0].Memo, i/MAX_UTXO_NUM),
+				payouts:   group[i:end],
+			})
+		}
+	}
+	return batches
+}
+
+// payoutBatchRequestId 对 JSON 编码后的各部分取 uuid, GenUuidFromStrings 直接拼接,
+// memo "x" 的第 12 批和 memo "x1" 的第 2 批会得到相同的请求 id
+func payoutBatchRequestId(requestId, assetId, memo string, batch int) string {
+	b, _ := json.Marshal([]any{requestId, assetId, memo, batch})
+	return GenUuidFromStrings(string(b))
+}
+
+// Run 按计划分批转账, 返回每一行的结果 (按行号排序). 某一批失败时停止, 之后的行标记为 skipped;
+// 使用相同的 requestId 重新执行时跳过已提交的批次, 已创建但没有提交的批次继续签名提交, 不会重复转出
+func (p *PayoutImporter) Run(ctx context.Context, plan *PayoutPlan, requestId string) ([]PayoutResult, error) {
+	rows := make(map[int]PayoutRow, len(plan.Rows))
+	for _, row := range plan.Rows {
+		rows[row.Line] = row
+	}
+
+	// 每个资产按总额经过一次 SpendingPolicy, 审批只调用一次, 各批次不再单独检查
+	releases, err := p.authorize(ctx, plan, requestId)
+	if err != nil {
+		return nil, err
+	}
+	sent := map[string]bool{}
+	defer func() {
+		for assetId, release := range releases {
+			if !sent[assetId] {
+				release()
+			}
+		}
+	}()
+
+	batches := plan.batches(requestId)
+	var results []PayoutResult
+	var runErr error
+	done := 0
+	for i, batch := range batches {
+		status, txHash, errMsg := PayoutStatusSkipped, "", ""
+		if runErr == nil {
+			req := &TransferManyRequest{
+				RequestId:    batch.requestId,
+				AssetId:      batch.assetId,
+				MemberAmount: make([]MemberAmount, len(batch.payouts)),
+				Memo:         batch.memo,
+			}
+			for j, payout := range batch.payouts {
+				req.MemberAmount[j] = MemberAmount{Member: payout.Members, Threshold: payout.Threshold, Amount: payout.Amount}
+			}
+
+			request, batchSent, err := p.send(ctx, req)
+			sent[batch.assetId] = sent[batch.assetId] || batchSent
+			if err != nil {
+				status, errMsg = PayoutStatusFailed, err.Error()
+				runErr = fmt.Errorf("payout batch %d/%d (request %s): %w", i+1, len(batches), batch.requestId, err)
+			} else {
+				status, txHash = PayoutStatusSubmitted, request.TransactionHash
+			}
+		}
+
+		for _, payout := range batch.payouts {
+			for _, line := range payout.Lines {
+				row := rows[line]
+				results = append(results, PayoutResult{
+					Line:            line,
+					AssetId:         payout.AssetId,
+					Members:         row.Members,
+					Threshold:       payout.Threshold,
+					Amount:          row.Amount,
+					Memo:            payout.Memo,
+					RequestId:       batch.requestId,
+					TransactionHash: txHash,
+					Status:          status,
+					Error:           errMsg,
+				})
+				if status == PayoutStatusSubmitted {
+					done++
+				}
+			}
+		}
+
+		if status == PayoutStatusSubmitted && p.progress != nil {
+			p.progress(PayoutProgress{
+				Batch:     i + 1,
+				Batches:   len(batches),
+				Rows:      done,
+				TotalRows: len(plan.Rows),
+				AssetId:   batch.assetId,
+				RequestId: batch.requestId,
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+	return results, runErr
+}
+
+// authorize 按资产合计通过 SpendingPolicy, 返回每个资产的 release; 有资产被拒绝时撤销之前的授权
+func (p *PayoutImporter) authorize(ctx context.Context, plan *PayoutPlan, requestId string) (map[string]func(), error) {
+	spends := map[string]*Spend{}
+	var assets []string
+	for _, payout := range plan.Payouts {
+		spend, ok := spends[payout.AssetId]
+		if !ok {
+			spend = &Spend{RequestId: GenUuidFromStrings(requestId, payout.AssetId), AssetId: payout.AssetId, Amount: decimal.Zero}
+			spends[payout.AssetId] = spend
+			assets = append(assets, payout.AssetId)
+		}
+		spend.Amount = spend.Amount.Add(payout.Amount)
+		spend.Recipients = append(spend.Recipients, payout.Members...)
+	}
+
+	releases := map[string]func(){}
+	for _, assetId := range assets {
+		release, err := p.client.authorizeSpend(ctx, spends[assetId])
+		if err != nil {
+			for _, release := range releases {
+				release()
+			}
+			return nil, err
+		}
+		releases[assetId] = release
+	}
+	return releases, nil
+}
+
+// send 发出一个批次: 请求已经签名或提交时直接返回, 已创建但没有提交时继续签名提交, 不存在时创建.
+// sent 见 signAndSubmit
+func (p *PayoutImporter) send(ctx context.Context, req *TransferManyRequest) (*mixin.SafeTransactionRequest, bool, error) {
+	request, err := p.client.readRequest(ctx, req.RequestId)
+	switch {
+	case mixin.IsErrorCodes(err, mixin.EndpointNotFound):
+		return p.client.transferManyObserved(ctx, req, false)
+	case err != nil:
+		return nil, false, err
+	case request.State == mixin.SafeUtxoStateUnspent:
+		return p.client.resumeRequest(ctx, request, req.AssetId, req.Memo, false)
+	default:
+		return request, true, nil
+	}
+}
+
+// WritePayoutResults 以 csv 或 jsonl 格式写出 Run 的结果
+func WritePayoutResults(w io.Writer, format string, results []PayoutResult) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"line", "asset_id", "members", "threshold", "amount", "memo", "request_id", "transaction_hash", "status", "error"})
+		for _, r := range results {
+			_ = cw.Write([]string{
+				strconv.Itoa(r.Line), r.AssetId, strings.Join(r.Members, ";"), strconv.Itoa(int(r.Threshold)),
+				r.Amount.String(), r.Memo, r.RequestId, r.TransactionHash, r.Status, r.Error,
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "jsonl", "ndjson":
+		enc := json.NewEncoder(w)
+		for _, r := range results {
+			if err := enc.Encode(&r); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported payout result format %q", format)
+	}
+}
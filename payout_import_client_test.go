@@ -0,0 +1,139 @@
+package kit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+func TestPayoutImporter_Run(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	client := server.NewClientWrapper(t)
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+
+	const other = "8dcf823d-9eb3-4da2-8734-f0aad50c0da6"
+	rows := []kit.PayoutRow{
+		{Line: 2, Members: []string{testPoolRecipient}, Amount: decimal.NewFromInt(1)},
+		{Line: 3, Members: []string{other}, Amount: decimal.NewFromInt(2)},
+		{Line: 4, Members: []string{testPoolRecipient}, Amount: decimal.RequireFromString("0.5")},
+		{Line: 5, Members: []string{other}, Amount: decimal.NewFromInt(1), Memo: "bonus"},
+	}
+
+	var progress []kit.PayoutProgress
+	importer := kit.NewPayoutImporter(client,
+		kit.WithPayoutAsset(testPoolAsset),
+		kit.WithPayoutProgress(func(p kit.PayoutProgress) { progress = append(progress, p) }),
+	)
+
+	ctx := context.Background()
+	plan, err := importer.Plan(ctx, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Totals[testPoolAsset].Equal(decimal.RequireFromString("4.5")) || !plan.Balances[testPoolAsset].Equal(decimal.NewFromInt(10)) {
+		t.Errorf("totals = %v, balances = %v", plan.Totals, plan.Balances)
+	}
+
+	requestId := mixin.RandomTraceID()
+	results, err := importer.Run(ctx, plan, requestId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4", len(results))
+	}
+	for i, r := range results {
+		if r.Line != rows[i].Line || r.Status != kit.PayoutStatusSubmitted || r.TransactionHash == "" {
+			t.Errorf("results[%d] = %+v", i, r)
+		}
+	}
+	// 合并的行共用一笔交易, memo 不同的行单独成批
+	if results[0].RequestId != results[2].RequestId || results[0].RequestId == results[3].RequestId {
+		t.Errorf("request ids = %s, %s, %s", results[0].RequestId, results[2].RequestId, results[3].RequestId)
+	}
+	if len(progress) != 2 || progress[1].Batch != 2 || progress[1].Rows != 4 || progress[1].TotalRows != 4 {
+		t.Errorf("progress = %+v", progress)
+	}
+	if got := server.Balance(testPoolAsset, testPoolRecipient); !got.Equal(decimal.RequireFromString("1.5")) {
+		t.Errorf("recipient balance = %s, want 1.5", got)
+	}
+
+	// 使用相同的 requestId 重新执行不会重复转出
+	if _, err := importer.Run(ctx, plan, requestId); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.Balance(testPoolAsset, other); !got.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("other balance = %s, want 3", got)
+	}
+
+	// 余额不足时同时返回计划
+	plan, err = importer.Plan(ctx, []kit.PayoutRow{{Line: 2, Members: []string{other}, Amount: decimal.NewFromInt(100)}})
+	var insufficient *kit.InsufficientBalanceError
+	if !errors.Is(err, kit.ErrInsufficientBalance) || !errors.As(err, &insufficient) || plan == nil || !insufficient.Required.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("plan = %v, err = %v", plan, err)
+	}
+}
+
+func TestPayoutImporter_RunResume(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	client := server.NewClientWrapper(t)
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+
+	importer := kit.NewPayoutImporter(client, kit.WithPayoutAsset(testPoolAsset))
+	ctx := context.Background()
+	plan, err := importer.Plan(ctx, []kit.PayoutRow{{Line: 2, Members: []string{testPoolRecipient}, Amount: decimal.NewFromInt(2)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 请求已创建, 提交失败
+	requestId := mixin.RandomTraceID()
+	server.FailNext("POST", "/safe/transactions", 500, "timeout")
+	results, err := importer.Run(ctx, plan, requestId)
+	if err == nil || results[0].Status != kit.PayoutStatusFailed {
+		t.Fatalf("results = %+v, err = %v, want failed", results, err)
+	}
+
+	if results, err = importer.Run(ctx, plan, requestId); err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != kit.PayoutStatusSubmitted || results[0].TransactionHash == "" {
+		t.Errorf("results = %+v", results)
+	}
+	if got := server.Balance(testPoolAsset, testPoolRecipient); !got.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("recipient balance = %s, want 2", got)
+	}
+}
+
+func TestPayoutImporter_RunApproval(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	var approvals []*kit.Spend
+	policy := kit.NewSpendingPolicy(kit.WithApproval(testPoolAsset, decimal.Zero, func(ctx context.Context, spend *kit.Spend) error {
+		approvals = append(approvals, spend)
+		return nil
+	}))
+	client := server.NewClientWrapper(t, kit.WithSpendingPolicy(policy))
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+
+	// memo 不同, 分为两批
+	importer := kit.NewPayoutImporter(client, kit.WithPayoutAsset(testPoolAsset))
+	ctx := context.Background()
+	plan, err := importer.Plan(ctx, []kit.PayoutRow{
+		{Line: 2, Members: []string{testPoolRecipient}, Amount: decimal.NewFromInt(1)},
+		{Line: 3, Members: []string{testPoolRecipient}, Amount: decimal.NewFromInt(2), Memo: "bonus"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := importer.Run(ctx, plan, mixin.RandomTraceID()); err != nil {
+		t.Fatal(err)
+	}
+	if len(approvals) != 1 || !approvals[0].Amount.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("approvals = %+v, want one for the total", approvals)
+	}
+}
@@ -0,0 +1,165 @@
+package kit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	testPayoutAsset = "965e5c6e-434c-3fa9-b780-c50f43cd955c"
+	testPayoutA     = "e9e5b807-fa8b-455a-8dfa-b189d28310ff"
+	testPayoutB     = "8dcf823d-9eb3-4da2-8734-f0aad50c0da6"
+)
+
+func TestReadPayoutCSV(t *testing.T) {
+	csv := "members,threshold,amount,memo\n" +
+		testPayoutA + ",,1.5,\n" +
+		testPayoutA + ";" + testPayoutB + ",1,2,vault\n" +
+		"\n" +
+		testPayoutB + ",x,1,\n" +
+		testPayoutB + ",,abc,\n"
+
+	rows, err := ReadPayoutCSV(strings.NewReader(csv))
+	var errs PayoutErrors
+	if !errors.As(err, &errs) || !errors.Is(err, ErrInvalidPayout) {
+		t.Fatalf("err = %v, want PayoutErrors", err)
+	}
+	if len(errs) != 2 || errs[0].Line != 5 || errs[0].Field != "threshold" || errs[1].Line != 6 || errs[1].Field != "amount" {
+		t.Errorf("errs = %v", errs)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if r := rows[1]; r.Line != 3 || len(r.Members) != 2 || r.Threshold != 1 || !r.Amount.Equal(decimal.NewFromInt(2)) || r.Memo != "vault" {
+		t.Errorf("rows[1] = %+v", r)
+	}
+
+	// 兼容没有表头的 member,amount 文件
+	rows, err = ReadPayoutCSV(strings.NewReader(testPayoutA + ",1\n" + testPayoutB + ",2.5\n"))
+	if err != nil || len(rows) != 2 || rows[0].Line != 1 || rows[0].Members[0] != testPayoutA || !rows[1].Amount.Equal(decimal.RequireFromString("2.5")) {
+		t.Errorf("csv without header: rows = %+v, err = %v", rows, err)
+	}
+	if _, err := ReadPayoutCSV(strings.NewReader("user,value\n" + testPayoutA + ",1\n")); !errors.Is(err, ErrInvalidPayout) {
+		t.Errorf("csv with unknown header: err = %v", err)
+	}
+}
+
+func TestReadPayoutJSONL(t *testing.T) {
+	jsonl := `{"member":"` + testPayoutA + `","amount":"1.5"}` + "\n" +
+		`{"members":["` + testPayoutA + `","` + testPayoutB + `"],"threshold":2,"amount":2,"asset_id":"` + testPayoutAsset + `"}` + "\n" +
+		"\n" +
+		`{"member":` + "\n"
+
+	rows, err := ReadPayoutJSONL(strings.NewReader(jsonl))
+	var errs PayoutErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 4 {
+		t.Fatalf("err = %v, want error on line 4", err)
+	}
+	if len(rows) != 2 || rows[1].Line != 2 || rows[1].Threshold != 2 || rows[1].AssetId != testPayoutAsset || !rows[0].Amount.Equal(decimal.RequireFromString("1.5")) {
+		t.Errorf("rows = %+v", rows)
+	}
+}
+
+func TestPayoutImporter_Validate(t *testing.T) {
+	valid := PayoutRow{Line: 2, Members: []string{testPayoutA}, Amount: decimal.NewFromInt(1)}
+	tests := []struct {
+		name  string
+		opts  []PayoutOption
+		edit  func(r *PayoutRow)
+		field string
+	}{
+		{"valid", []PayoutOption{WithPayoutAsset(testPayoutAsset)}, func(r *PayoutRow) {}, ""},
+		{"row asset", nil, func(r *PayoutRow) { r.AssetId = testPayoutAsset }, ""},
+		{"missing asset", nil, func(r *PayoutRow) {}, "asset_id"},
+		{"bad asset", []PayoutOption{WithPayoutAsset("btc")}, func(r *PayoutRow) {}, "asset_id"},
+		{"no members", []PayoutOption{WithPayoutAsset(testPayoutAsset)}, func(r *PayoutRow) { r.Members = nil }, "members"},
+		{"uppercase member", []PayoutOption{WithPayoutAsset(testPayoutAsset)}, func(r *PayoutRow) { r.Members = []string{strings.ToUpper(testPayoutA)} }, "members"},
+		{"duplicate member", []PayoutOption{WithPayoutAsset(testPayoutAsset)}, func(r *PayoutRow) { r.Members = []string{testPayoutA, testPayoutA} }, "members"},
+		{"threshold", []PayoutOption{WithPayoutAsset(testPayoutAsset)}, func(r *PayoutRow) { r.Threshold = 2 }, "threshold"},
+		{"zero amount", []PayoutOption{WithPayoutAsset(testPayoutAsset)}, func(r *PayoutRow) { r.Amount = decimal.Zero }, "amount"},
+		{"precision", []PayoutOption{WithPayoutAsset(testPayoutAsset)}, func(r *PayoutRow) { r.Amount = decimal.RequireFromString("0.000000001") }, "amount"},
+		{"custom precision", []PayoutOption{WithPayoutAsset(testPayoutAsset), WithPayoutPrecision(2)}, func(r *PayoutRow) { r.Amount = decimal.RequireFromString("0.001") }, "amount"},
+		{"long memo", []PayoutOption{WithPayoutAsset(testPayoutAsset)}, func(r *PayoutRow) { r.Memo = strings.Repeat("m", 257) }, "memo"},
+		{"long default memo", []PayoutOption{WithPayoutAsset(testPayoutAsset), WithPayoutMemo(strings.Repeat("m", 257))}, func(r *PayoutRow) {}, "memo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := valid
+			tt.edit(&row)
+			err := NewPayoutImporter(nil, tt.opts...).Validate([]PayoutRow{row})
+
+			var errs PayoutErrors
+			switch {
+			case tt.field == "" && err != nil:
+				t.Errorf("err = %v", err)
+			case tt.field != "" && (!errors.As(err, &errs) || errs[0].Field != tt.field || errs[0].Line != 2):
+				t.Errorf("err = %v, want %s error on line 2", err, tt.field)
+			}
+		})
+	}
+}
+
+func TestPayoutBatchRequestId(t *testing.T) {
+	// 各部分直接拼接时 "x"+"12" 和 "x1"+"2" 相同
+	if payoutBatchRequestId("run", testPayoutAsset, "x", 12) == payoutBatchRequestId("run", testPayoutAsset, "x1", 2) {
+		t.Error("batch request ids collide")
+	}
+	if payoutBatchRequestId("run", testPayoutAsset, "x", 1) != payoutBatchRequestId("run", testPayoutAsset, "x", 1) {
+		t.Error("batch request id is not deterministic")
+	}
+}
+
+func TestPayoutImporter_Merge(t *testing.T) {
+	rows := []PayoutRow{
+		{Line: 2, Members: []string{testPayoutA}, Amount: decimal.NewFromInt(1)},
+		{Line: 3, Members: []string{testPayoutB, testPayoutA}, Amount: decimal.NewFromInt(2)},
+		{Line: 4, Members: []string{testPayoutA}, Amount: decimal.RequireFromString("0.5")},
+		{Line: 5, Members: []string{testPayoutA, testPayoutB}, Threshold: 2, Amount: decimal.NewFromInt(3)},
+		{Line: 6, Members: []string{testPayoutA}, Amount: decimal.NewFromInt(1), Memo: "bonus"},
+	}
+
+	payouts := NewPayoutImporter(nil, WithPayoutAsset(testPayoutAsset)).Merge(rows)
+	if len(payouts) != 3 {
+		t.Fatalf("got %d payouts, want 3: %+v", len(payouts), payouts)
+	}
+	if p := payouts[0]; !p.Amount.Equal(decimal.RequireFromString("1.5")) || len(p.Lines) != 2 || p.Lines[1] != 4 || p.AssetId != testPayoutAsset {
+		t.Errorf("payouts[0] = %+v", p)
+	}
+	// 门限为 0 时为成员数, 与显式的 2 相同
+	if p := payouts[1]; !p.Amount.Equal(decimal.NewFromInt(5)) || p.Threshold != 2 || len(p.Lines) != 2 {
+		t.Errorf("payouts[1] = %+v", p)
+	}
+	if p := payouts[2]; p.Memo != "bonus" || p.Lines[0] != 6 {
+		t.Errorf("payouts[2] = %+v", p)
+	}
+
+	// 没有 memo 的行使用默认 memo, 与显式写同样 memo 的行合并
+	payouts = NewPayoutImporter(nil, WithPayoutAsset(testPayoutAsset), WithPayoutMemo("bonus")).Merge(rows)
+	if len(payouts) != 2 || payouts[0].Memo != "bonus" || !payouts[0].Amount.Equal(decimal.RequireFromString("2.5")) {
+		t.Errorf("payouts with default memo = %+v", payouts)
+	}
+}
+
+func TestWritePayoutResults(t *testing.T) {
+	results := []PayoutResult{{Line: 2, AssetId: testPayoutAsset, Members: []string{testPayoutA, testPayoutB}, Threshold: 1, Amount: decimal.NewFromInt(1), Status: PayoutStatusSkipped}}
+
+	var buf bytes.Buffer
+	if err := WritePayoutResults(&buf, "csv", results); err != nil {
+		t.Fatal(err)
+	}
+	if want := "2," + testPayoutAsset + "," + testPayoutA + ";" + testPayoutB + ",1,1,,,,skipped,\n"; !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("csv = %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := WritePayoutResults(&buf, "jsonl", results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"status":"skipped"`) {
+		t.Errorf("jsonl = %q", buf.String())
+	}
+}
@@ -0,0 +1,191 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	ErrPriceNotFound = errors.New("price not found")
+	ErrFiatNotFound  = errors.New("fiat rate not found")
+)
+
+const (
+	FiatUSD = "USD"
+	FiatCNY = "CNY"
+
+	DefaultPriceConcurrency = 8
+)
+
+// GetAssetInfos 并发获取多个资产的行情, 最多同时发起 concurrency 个请求; 重复的 asset id 只请求一次
+func (c *MarketClient) GetAssetInfos(ctx context.Context, assetIds []string, concurrency int) (map[string]*MarketAssetInfo, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultPriceConcurrency
+	}
+
+	var mu sync.Mutex
+	infos := make(map[string]*MarketAssetInfo, len(assetIds))
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, assetId := range assetIds {
+		mu.Lock()
+		_, ok := infos[assetId]
+		infos[assetId] = nil
+		mu.Unlock()
+		if ok {
+			continue
+		}
+
+		g.Go(func() error {
+			info, err := c.GetAssetInfo(ctx, assetId)
+			if err != nil {
+				return fmt.Errorf("asset %s: %w", assetId, err)
+			}
+
+			mu.Lock()
+			infos[assetId] = info
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// PriceBook 资产的美元价格和法币汇率快照, 用于在资产和法币之间换算金额
+type PriceBook struct {
+	UpdatedAt time.Time
+
+	prices map[string]decimal.Decimal // asset id -> USD price
+	fiats  map[string]decimal.Decimal // fiat code -> 1 USD 可兑换的数量
+}
+
+func NewPriceBook(prices map[string]decimal.Decimal, fiats []mixin.Fiat) *PriceBook {
+	b := &PriceBook{
+		UpdatedAt: time.Now(),
+		prices:    make(map[string]decimal.Decimal, len(prices)),
+		fiats:     map[string]decimal.Decimal{FiatUSD: decimal.NewFromInt(1)},
+	}
+	for assetId, price := range prices {
+		b.prices[assetId] = price
+	}
+	for _, fiat := range fiats {
+		if fiat.Rate.IsPositive() {
+			b.fiats[strings.ToUpper(fiat.Code)] = fiat.Rate
+		}
+	}
+	return b
+}
+
+// LoadPriceBook 并发获取 assetIds 的价格和 Mixin 法币汇率, 生成 PriceBook
+func LoadPriceBook(ctx context.Context, market *MarketClient, client *mixin.Client, assetIds []string, concurrency int) (*PriceBook, error) {
+	var (
+		infos map[string]*MarketAssetInfo
+		fiats []mixin.Fiat
+	)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		infos, err = market.GetAssetInfos(gctx, assetIds, concurrency)
+		return
+	})
+	g.Go(func() (err error) {
+		fiats, err = client.ReadFiats(gctx)
+		return
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]decimal.Decimal, len(infos))
+	for assetId, info := range infos {
+		prices[assetId] = info.CurrentPrice
+	}
+	return NewPriceBook(prices, fiats), nil
+}
+
+// LoadPriceBook 见 LoadPriceBook
+func (m *ClientWrapper) LoadPriceBook(ctx context.Context, assetIds ...string) (*PriceBook, error) {
+	return LoadPriceBook(ctx, m.Market, m.Client, assetIds, DefaultPriceConcurrency)
+}
+
+// Price 资产的美元价格
+func (b *PriceBook) Price(assetId string) (decimal.Decimal, bool) {
+	price, ok := b.prices[assetId]
+	return price, ok
+}
+
+// FiatRate 1 USD 可兑换的法币数量
+func (b *PriceBook) FiatRate(code string) (decimal.Decimal, bool) {
+	rate, ok := b.fiats[strings.ToUpper(code)]
+	return rate, ok
+}
+
+// ToUSD 将资产数量换算为美元
+func (b *PriceBook) ToUSD(assetId string, amount decimal.Decimal) (decimal.Decimal, error) {
+	price, ok := b.Price(assetId)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrPriceNotFound, assetId)
+	}
+	return amount.Mul(price), nil
+}
+
+// ToFiat 将资产数量换算为法币
+func (b *PriceBook) ToFiat(assetId string, amount decimal.Decimal, code string) (decimal.Decimal, error) {
+	usd, err := b.ToUSD(assetId, amount)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return b.ConvertFiat(usd, FiatUSD, code)
+}
+
+// FromFiat 将法币金额换算为资产数量
+func (b *PriceBook) FromFiat(code string, amount decimal.Decimal, assetId string) (decimal.Decimal, error) {
+	usd, err := b.ConvertFiat(amount, code, FiatUSD)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	price, ok := b.Price(assetId)
+	if !ok || !price.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrPriceNotFound, assetId)
+	}
+	return usd.DivRound(price, priceDivisionPrecision), nil
+}
+
+// Convert 按美元价格将 from 资产的数量换算为 to 资产的数量
+func (b *PriceBook) Convert(amount decimal.Decimal, fromAssetId, toAssetId string) (decimal.Decimal, error) {
+	usd, err := b.ToUSD(fromAssetId, amount)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return b.FromFiat(FiatUSD, usd, toAssetId)
+}
+
+// ConvertFiat 在两种法币之间换算
+func (b *PriceBook) ConvertFiat(amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	fromRate, ok := b.FiatRate(from)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrFiatNotFound, from)
+	}
+	toRate, ok := b.FiatRate(to)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrFiatNotFound, to)
+	}
+	if fromRate.Equal(toRate) {
+		return amount, nil
+	}
+	return amount.Mul(toRate).DivRound(fromRate, priceDivisionPrecision), nil
+}
@@ -0,0 +1,68 @@
+package kit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+func TestPriceBook(t *testing.T) {
+	b := NewPriceBook(map[string]decimal.Decimal{
+		testInvoiceAssetBTC: decimal.NewFromInt(60000),
+		testInvoiceAssetSOL: decimal.NewFromInt(150),
+	}, []mixin.Fiat{
+		{Code: "USD", Rate: decimal.NewFromInt(1)},
+		{Code: "CNY", Rate: decimal.RequireFromString("7.2")},
+	})
+
+	tests := []struct {
+		name string
+		got  func() (decimal.Decimal, error)
+		want string
+	}{
+		{
+			name: "to usd",
+			got:  func() (decimal.Decimal, error) { return b.ToUSD(testInvoiceAssetBTC, decimal.RequireFromString("0.5")) },
+			want: "30000",
+		},
+		{
+			name: "to cny",
+			got:  func() (decimal.Decimal, error) { return b.ToFiat(testInvoiceAssetSOL, decimal.NewFromInt(2), "cny") },
+			want: "2160",
+		},
+		{
+			name: "from cny",
+			got: func() (decimal.Decimal, error) {
+				return b.FromFiat(FiatCNY, decimal.NewFromInt(1080), testInvoiceAssetSOL)
+			},
+			want: "1",
+		},
+		{
+			name: "btc to sol",
+			got: func() (decimal.Decimal, error) {
+				return b.Convert(decimal.NewFromInt(1), testInvoiceAssetBTC, testInvoiceAssetSOL)
+			},
+			want: "400",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.got()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := b.ToUSD("unknown", decimal.NewFromInt(1)); !errors.Is(err, ErrPriceNotFound) {
+		t.Errorf("ToUSD() error = %v, want %v", err, ErrPriceNotFound)
+	}
+	if _, err := b.ConvertFiat(decimal.NewFromInt(1), FiatUSD, "EUR"); !errors.Is(err, ErrFiatNotFound) {
+		t.Errorf("ConvertFiat() error = %v, want %v", err, ErrFiatNotFound)
+	}
+}
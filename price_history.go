@@ -0,0 +1,206 @@
+package kit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrNotEnoughPricePoints = errors.New("not enough price points")
+
+// priceDivisionPrecision 价格相除时保留的小数位数
+const priceDivisionPrecision = 16
+
+type (
+	PricePoint struct {
+		Time  time.Time
+		Price decimal.Decimal
+	}
+
+	// Candle OHLC K 线, Start 为区间起始时间, Count 为区间内的价格点数量
+	Candle struct {
+		Start time.Time
+		Open  decimal.Decimal
+		High  decimal.Decimal
+		Low   decimal.Decimal
+		Close decimal.Decimal
+		Count int
+	}
+)
+
+// Time 返回价格点的时间, Unix 兼容秒和毫秒
+func (d HistoricalPriceDatum) Time() time.Time {
+	// 大于 1e12 视为毫秒时间戳
+	if d.Unix > 1e12 {
+		return time.UnixMilli(d.Unix).UTC()
+	}
+	return time.Unix(d.Unix, 0).UTC()
+}
+
+func (d HistoricalPriceDatum) Point() (PricePoint, error) {
+	price, err := decimal.NewFromString(d.Price)
+	if err != nil {
+		return PricePoint{}, fmt.Errorf("invalid price %q: %w", d.Price, err)
+	}
+	return PricePoint{Time: d.Time(), Price: price}, nil
+}
+
+// Points 将历史价格解析为按时间升序排列的价格点
+func (h *HistoricalPrice) Points() ([]PricePoint, error) {
+	points := make([]PricePoint, 0, len(h.Data))
+	for _, d := range h.Data {
+		p, err := d.Point()
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Time.Before(points[j].Time)
+	})
+	return points, nil
+}
+
+// Resample 将历史价格按 interval 聚合为 OHLC K 线
+func (h *HistoricalPrice) Resample(interval time.Duration) ([]Candle, error) {
+	points, err := h.Points()
+	if err != nil {
+		return nil, err
+	}
+	return ResampleOHLC(points, interval)
+}
+
+// ParseSparkline 解析行情中的 sparkline 字段, 支持 "[1,2,3]" 和 "1,2,3" 两种格式
+func ParseSparkline(s string) ([]decimal.Decimal, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "[")
+	s = strings.TrimSuffix(s, "]")
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	prices := make([]decimal.Decimal, 0, len(parts))
+	for _, part := range parts {
+		part = strings.Trim(strings.TrimSpace(part), `"`)
+		price, err := decimal.NewFromString(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sparkline price %q: %w", part, err)
+		}
+		prices = append(prices, price)
+	}
+	return prices, nil
+}
+
+func (m *MarketAssetInfo) Sparkline7D() ([]decimal.Decimal, error) {
+	return ParseSparkline(m.SparklineIn7D)
+}
+
+func (m *MarketAssetInfo) Sparkline24H() ([]decimal.Decimal, error) {
+	return ParseSparkline(m.SparklineIn24H)
+}
+
+// ResampleOHLC 将按时间升序的价格点按 interval 对齐聚合为 K 线, 没有价格点的区间会被跳过
+func ResampleOHLC(points []PricePoint, interval time.Duration) ([]Candle, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid resample interval: %s", interval)
+	}
+
+	var candles []Candle
+	for _, p := range points {
+		start := p.Time.Truncate(interval)
+		if n := len(candles); n > 0 && candles[n-1].Start.Equal(start) {
+			c := &candles[n-1]
+			c.High = decimal.Max(c.High, p.Price)
+			c.Low = decimal.Min(c.Low, p.Price)
+			c.Close = p.Price
+			c.Count++
+			continue
+		}
+
+		candles = append(candles, Candle{
+			Start: start,
+			Open:  p.Price,
+			High:  p.Price,
+			Low:   p.Price,
+			Close: p.Price,
+			Count: 1,
+		})
+	}
+	return candles, nil
+}
+
+// Returns 相邻价格点之间的收益率 p[i]/p[i-1] - 1, 前一个价格为 0 时跳过
+func Returns(points []PricePoint) []decimal.Decimal {
+	if len(points) < 2 {
+		return nil
+	}
+
+	returns := make([]decimal.Decimal, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		prev := points[i-1].Price
+		if prev.IsZero() {
+			continue
+		}
+		returns = append(returns, points[i].Price.DivRound(prev, priceDivisionPrecision).Sub(decimal.NewFromInt(1)))
+	}
+	return returns
+}
+
+// Volatility 收益率的样本标准差
+func Volatility(points []PricePoint) (decimal.Decimal, error) {
+	returns := Returns(points)
+	if len(returns) < 2 {
+		return decimal.Zero, ErrNotEnoughPricePoints
+	}
+
+	mean := decimal.Avg(returns[0], returns[1:]...)
+	variance := decimal.Zero
+	for _, r := range returns {
+		diff := r.Sub(mean)
+		variance = variance.Add(diff.Mul(diff))
+	}
+	variance = variance.DivRound(decimal.NewFromInt(int64(len(returns)-1)), priceDivisionPrecision)
+
+	return decimal.NewFromFloat(math.Sqrt(variance.InexactFloat64())), nil
+}
+
+// AveragePrice 价格点的算术平均价
+func AveragePrice(points []PricePoint) (decimal.Decimal, error) {
+	if len(points) == 0 {
+		return decimal.Zero, ErrNotEnoughPricePoints
+	}
+
+	sum := decimal.Zero
+	for _, p := range points {
+		sum = sum.Add(p.Price)
+	}
+	return sum.DivRound(decimal.NewFromInt(int64(len(points))), priceDivisionPrecision), nil
+}
+
+// TimeWeightedAveragePrice 按持续时间加权的平均价 (历史价格没有成交量, 用时间代替 VWAP 中的成交量),
+// 每个价格点的权重为到下一个价格点的时长, 最后一个点不计入
+func TimeWeightedAveragePrice(points []PricePoint) (decimal.Decimal, error) {
+	if len(points) < 2 {
+		return decimal.Zero, ErrNotEnoughPricePoints
+	}
+
+	sum := decimal.Zero
+	total := decimal.Zero
+	for i := 0; i < len(points)-1; i++ {
+		weight := decimal.NewFromInt(int64(points[i+1].Time.Sub(points[i].Time)))
+		sum = sum.Add(points[i].Price.Mul(weight))
+		total = total.Add(weight)
+	}
+
+	if total.IsZero() {
+		return AveragePrice(points)
+	}
+	return sum.DivRound(total, priceDivisionPrecision), nil
+}
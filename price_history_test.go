@@ -0,0 +1,104 @@
+package kit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func testPricePoints(start time.Time, step time.Duration, prices ...string) []PricePoint {
+	points := make([]PricePoint, len(prices))
+	for i, p := range prices {
+		points[i] = PricePoint{
+			Time:  start.Add(time.Duration(i) * step),
+			Price: decimal.RequireFromString(p),
+		}
+	}
+	return points
+}
+
+func TestResampleOHLC(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := testPricePoints(start, 15*time.Minute, "10", "12", "9", "11", "11.5", "13")
+
+	candles, err := ResampleOHLC(points, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Candle{
+		{Start: start, Open: decimal.RequireFromString("10"), High: decimal.RequireFromString("12"), Low: decimal.RequireFromString("9"), Close: decimal.RequireFromString("11"), Count: 4},
+		{Start: start.Add(time.Hour), Open: decimal.RequireFromString("11.5"), High: decimal.RequireFromString("13"), Low: decimal.RequireFromString("11.5"), Close: decimal.RequireFromString("13"), Count: 2},
+	}
+	if len(candles) != len(want) {
+		t.Fatalf("len(candles) = %d, want %d", len(candles), len(want))
+	}
+	for i := range want {
+		got, w := candles[i], want[i]
+		if !got.Start.Equal(w.Start) || !got.Open.Equal(w.Open) || !got.High.Equal(w.High) ||
+			!got.Low.Equal(w.Low) || !got.Close.Equal(w.Close) || got.Count != w.Count {
+			t.Errorf("candle %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	if _, err := ResampleOHLC(points, 0); err == nil {
+		t.Error("ResampleOHLC() with zero interval should fail")
+	}
+}
+
+func TestHistoricalPrice_Points(t *testing.T) {
+	h := &HistoricalPrice{
+		Data: []HistoricalPriceDatum{
+			{Price: "2", Unix: 1704070800000},
+			{Price: "1", Unix: 1704067200},
+		},
+	}
+
+	points, err := h.Points()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !points[0].Price.Equal(decimal.NewFromInt(1)) || !points[1].Time.Equal(time.Unix(1704070800, 0)) {
+		t.Errorf("Points() = %+v", points)
+	}
+}
+
+func TestParseSparkline(t *testing.T) {
+	for _, s := range []string{"[1,2.5,3]", "1, 2.5, 3", `["1","2.5","3"]`} {
+		prices, err := ParseSparkline(s)
+		if err != nil {
+			t.Fatalf("ParseSparkline(%q) error = %v", s, err)
+		}
+		if len(prices) != 3 || !prices[1].Equal(decimal.RequireFromString("2.5")) {
+			t.Errorf("ParseSparkline(%q) = %v", s, prices)
+		}
+	}
+
+	if prices, err := ParseSparkline(""); err != nil || prices != nil {
+		t.Errorf("ParseSparkline(\"\") = %v, %v", prices, err)
+	}
+}
+
+func TestTimeWeightedAveragePrice(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	points := []PricePoint{
+		{Time: start, Price: decimal.NewFromInt(10)},
+		{Time: start.Add(3 * time.Hour), Price: decimal.NewFromInt(20)},
+		{Time: start.Add(4 * time.Hour), Price: decimal.NewFromInt(30)},
+	}
+
+	// (10*3 + 20*1) / 4
+	got, err := TimeWeightedAveragePrice(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decimal.RequireFromString("12.5"); !got.Equal(want) {
+		t.Errorf("TimeWeightedAveragePrice() = %s, want %s", got, want)
+	}
+
+	returns := Returns(points)
+	if len(returns) != 2 || !returns[0].Equal(decimal.NewFromInt(1)) || !returns[1].Equal(decimal.RequireFromString("0.5")) {
+		t.Errorf("Returns() = %v", returns)
+	}
+}
@@ -0,0 +1,240 @@
+package kit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var metricHelp = map[string]string{
+	MetricOperationDuration: "Duration of mixin-kit operations.",
+	MetricAPIRequests:       "Mixin API requests.",
+	MetricAPIDuration:       "Duration of Mixin API requests.",
+	MetricTransfers:         "Transfers by asset, kind and status.",
+	MetricTransferAmount:    "Amount transferred by asset.",
+	MetricConsolidations:    "Submitted utxo consolidation transactions.",
+}
+
+type promSeries struct {
+	labels string // 已格式化的 {k="v",...}
+	value  float64
+
+	// histogram
+	counts []uint64 // 每个桶的计数 (非累计), 最后一个为 +Inf
+	sum    float64
+}
+
+type promFamily struct {
+	histogram bool
+	series    map[string]*promSeries
+}
+
+// PrometheusRegistry 实现 Meter, 以 Prometheus 文本格式导出指标, 可以直接挂载为 /metrics.
+// 这是手写的文本格式导出 (exposition), 不依赖 prometheus/client_golang, 也不是已有 registry 的适配器:
+// 指标不会注册到 prometheus.DefaultRegisterer, 需要和其他指标一起导出时使用 kitotel
+type PrometheusRegistry struct {
+	buckets []time.Duration
+
+	mu       sync.Mutex
+	families map[string]*promFamily
+}
+
+// PrometheusOption 定义 PrometheusRegistry 选项
+type PrometheusOption func(*PrometheusRegistry)
+
+// WithPrometheusBuckets 设置耗时直方图的分桶上界, 默认 DefaultLatencyBuckets
+func WithPrometheusBuckets(buckets ...time.Duration) PrometheusOption {
+	return func(r *PrometheusRegistry) {
+		if len(buckets) > 0 {
+			r.buckets = append([]time.Duration(nil), buckets...)
+			sort.Slice(r.buckets, func(i, j int) bool { return r.buckets[i] < r.buckets[j] })
+		}
+	}
+}
+
+func NewPrometheusRegistry(opts ...PrometheusOption) *PrometheusRegistry {
+	r := &PrometheusRegistry{
+		buckets:  DefaultLatencyBuckets,
+		families: map[string]*promFamily{},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// series 调用方需持有 r.mu
+func (r *PrometheusRegistry) series(name string, histogram bool, attrs []Attr) *promSeries {
+	f, ok := r.families[name]
+	if !ok {
+		f = &promFamily{histogram: histogram, series: map[string]*promSeries{}}
+		r.families[name] = f
+	}
+
+	labels := formatPromLabels(attrs)
+	s, ok := f.series[labels]
+	if !ok {
+		s = &promSeries{labels: labels}
+		if histogram {
+			s.counts = make([]uint64, len(r.buckets)+1)
+		}
+		f.series[labels] = s
+	}
+	return s
+}
+
+func (r *PrometheusRegistry) AddCounter(ctx context.Context, name string, value float64, attrs ...Attr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok && f.histogram {
+		return
+	}
+	r.series(name, false, attrs).value += value
+}
+
+func (r *PrometheusRegistry) ObserveDuration(ctx context.Context, name string, d time.Duration, attrs ...Attr) {
+	i := sort.Search(len(r.buckets), func(i int) bool { return d <= r.buckets[i] })
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok && !f.histogram {
+		return
+	}
+	s := r.series(name, true, attrs)
+	s.counts[i]++
+	s.sum += d.Seconds()
+}
+
+// Counter 返回计数器的当前值, 不存在时返回 0
+func (r *PrometheusRegistry) Counter(name string, attrs ...Attr) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.families[name]; ok && !f.histogram {
+		if s, ok := f.series[formatPromLabels(attrs)]; ok {
+			return s.value
+		}
+	}
+	return 0
+}
+
+// ServeHTTP 以 Prometheus 文本格式 (0.0.4) 输出所有指标
+func (r *PrometheusRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set(HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	r.writeText(bw)
+	bw.Flush()
+}
+
+func (r *PrometheusRegistry) writeText(w *bufio.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := r.families[name]
+		typ := "counter"
+		if f.histogram {
+			typ = "histogram"
+		}
+		if help, ok := metricHelp[name]; ok {
+			fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+		}
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+
+		keys := make([]string, 0, len(f.series))
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			s := f.series[k]
+			if !f.histogram {
+				fmt.Fprintf(w, "%s%s %s\n", name, s.labels, formatPromValue(s.value))
+				continue
+			}
+
+			var cumulative uint64
+			for i, c := range s.counts {
+				cumulative += c
+				le := "+Inf"
+				if i < len(r.buckets) {
+					le = formatPromValue(r.buckets[i].Seconds())
+				}
+				fmt.Fprintf(w, "%s_bucket%s %d\n", name, withPromLabel(s.labels, "le", le), cumulative)
+			}
+			fmt.Fprintf(w, "%s_sum%s %s\n", name, s.labels, formatPromValue(s.sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", name, s.labels, cumulative)
+		}
+	}
+}
+
+// formatPromLabels 按 key 排序后格式化为 {k="v",...}, 没有标签时返回空字符串
+func formatPromLabels(attrs []Attr) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	attrs = append([]Attr(nil), attrs...)
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Key < attrs[j].Key })
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, a := range attrs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(promLabelName(a.Key))
+		b.WriteString(`="`)
+		b.WriteString(promLabelEscaper.Replace(a.Value))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func withPromLabel(labels, key, value string) string {
+	l := key + `="` + value + `"`
+	if labels == "" {
+		return "{" + l + "}"
+	}
+	return labels[:len(labels)-1] + "," + l + "}"
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promLabelName 将 . 等非法字符替换为 _
+func promLabelName(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+func formatPromValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,183 @@
+package kit
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	MixinPayHost   = "mixin.one"
+	MixinPayScheme = "mixin"
+
+	DefaultQRCodeSize = 256
+)
+
+type QRCodeLevel uint8
+
+const (
+	QRCodeLevelLow     QRCodeLevel = iota // 7% 容错
+	QRCodeLevelMedium                     // 15% 容错
+	QRCodeLevelHigh                       // 25% 容错
+	QRCodeLevelHighest                    // 30% 容错
+)
+
+func (l QRCodeLevel) recoveryLevel() qrcode.RecoveryLevel {
+	switch l {
+	case QRCodeLevelLow:
+		return qrcode.Low
+	case QRCodeLevelHigh:
+		return qrcode.High
+	case QRCodeLevelHighest:
+		return qrcode.Highest
+	default:
+		return qrcode.Medium
+	}
+}
+
+type qrCodeConfig struct {
+	size          int
+	level         QRCodeLevel
+	disableBorder bool
+}
+
+// QRCodeOption 定义二维码选项
+type QRCodeOption func(*qrCodeConfig)
+
+// WithQRCodeSize 设置二维码图片的边长 (像素)
+func WithQRCodeSize(size int) QRCodeOption {
+	return func(c *qrCodeConfig) {
+		c.size = size
+	}
+}
+
+// WithQRCodeLevel 设置二维码的容错等级, 默认 QRCodeLevelMedium
+func WithQRCodeLevel(level QRCodeLevel) QRCodeOption {
+	return func(c *qrCodeConfig) {
+		c.level = level
+	}
+}
+
+// WithQRCodeBorder 设置是否保留二维码四周的空白边框, 默认保留
+func WithQRCodeBorder(border bool) QRCodeOption {
+	return func(c *qrCodeConfig) {
+		c.disableBorder = !border
+	}
+}
+
+func newQRCode(content string, opts []QRCodeOption) (*qrcode.QRCode, *qrCodeConfig, error) {
+	cfg := &qrCodeConfig{
+		size:  DefaultQRCodeSize,
+		level: QRCodeLevelMedium,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.size <= 0 {
+		return nil, nil, fmt.Errorf("invalid qrcode size: %d", cfg.size)
+	}
+
+	q, err := qrcode.New(content, cfg.level.recoveryLevel())
+	if err != nil {
+		return nil, nil, err
+	}
+	q.DisableBorder = cfg.disableBorder
+
+	return q, cfg, nil
+}
+
+// QRCodePNG 将 content 渲染为 PNG 格式的二维码
+func QRCodePNG(content string, opts ...QRCodeOption) ([]byte, error) {
+	q, cfg, err := newQRCode(content, opts)
+	if err != nil {
+		return nil, err
+	}
+	return q.PNG(cfg.size)
+}
+
+// QRCodeSVG 将 content 渲染为 SVG 格式的二维码
+func QRCodeSVG(content string, opts ...QRCodeOption) ([]byte, error) {
+	q, cfg, err := newQRCode(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	bitmap := q.Bitmap()
+	n := len(bitmap)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, cfg.size, cfg.size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#ffffff"/>`, n, n)
+	buf.WriteString(`<path fill="#000000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes(), nil
+}
+
+// PaymentURL 返回发票的 https 支付链接, 如 https://mixin.one/pay/MIN...
+func (m *MixinInvoiceWrapper) PaymentURL() string {
+	return buildPayURL("https", m.String(), nil)
+}
+
+// DeepLink 返回发票的 mixin:// 支付链接
+func (m *MixinInvoiceWrapper) DeepLink() string {
+	return buildPayURL(MixinPayScheme, m.String(), nil)
+}
+
+// QRCodePNG 将发票的 https 支付链接渲染为 PNG 二维码
+func (m *MixinInvoiceWrapper) QRCodePNG(opts ...QRCodeOption) ([]byte, error) {
+	return QRCodePNG(m.PaymentURL(), opts...)
+}
+
+// QRCodeSVG 将发票的 https 支付链接渲染为 SVG 二维码
+func (m *MixinInvoiceWrapper) QRCodeSVG(opts ...QRCodeOption) ([]byte, error) {
+	return QRCodeSVG(m.PaymentURL(), opts...)
+}
+
+// TransferPaymentURL 返回单笔转账的 https 支付链接
+func TransferPaymentURL(req *TransferOneRequest) string {
+	return buildPayURL("https", req.Member, transferPayQuery(req))
+}
+
+// TransferDeepLink 返回单笔转账的 mixin:// 支付链接
+func TransferDeepLink(req *TransferOneRequest) string {
+	return buildPayURL(MixinPayScheme, req.Member, transferPayQuery(req))
+}
+
+func transferPayQuery(req *TransferOneRequest) url.Values {
+	query := url.Values{}
+	if req.AssetId != "" {
+		query.Set("asset", req.AssetId)
+	}
+	if req.Amount.IsPositive() {
+		query.Set("amount", req.Amount.String())
+	}
+	if req.Memo != "" {
+		query.Set("memo", req.Memo)
+	}
+	if req.RequestId != "" {
+		query.Set("trace", req.RequestId)
+	}
+	return query
+}
+
+// mixin://mixin.one/pay/${recipient}?asset=...&amount=...&memo=...&trace=...
+func buildPayURL(scheme, recipient string, query url.Values) string {
+	u := url.URL{
+		Scheme:   scheme,
+		Host:     MixinPayHost,
+		Path:     "/pay/" + recipient,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
@@ -0,0 +1,65 @@
+package kit
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTransferPaymentURL(t *testing.T) {
+	req := &TransferOneRequest{
+		RequestId: testInvoiceTrace1,
+		AssetId:   testInvoiceAssetBTC,
+		Member:    testInvoiceRecipient,
+		Amount:    decimal.NewFromFloat(0.01),
+		Memo:      "hello world",
+	}
+
+	want := "https://mixin.one/pay/" + testInvoiceRecipient +
+		"?amount=0.01&asset=" + testInvoiceAssetBTC +
+		"&memo=hello+world&trace=" + testInvoiceTrace1
+	if got := TransferPaymentURL(req); got != want {
+		t.Errorf("TransferPaymentURL() = %s, want %s", got, want)
+	}
+
+	if got := TransferDeepLink(req); got != "mixin"+strings.TrimPrefix(want, "https") {
+		t.Errorf("TransferDeepLink() = %s", got)
+	}
+}
+
+func TestQRCodePNG(t *testing.T) {
+	b, err := QRCodePNG("https://mixin.one/pay/"+testInvoiceRecipient, WithQRCodeSize(300), WithQRCodeLevel(QRCodeLevelHigh))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Dx(); size != 300 {
+		t.Errorf("png size = %d, want 300", size)
+	}
+
+	if _, err := QRCodePNG("x", WithQRCodeSize(0)); err == nil {
+		t.Error("QRCodePNG() with zero size should fail")
+	}
+}
+
+func TestQRCodeSVG(t *testing.T) {
+	b, err := QRCodeSVG("https://mixin.one/pay/"+testInvoiceRecipient, WithQRCodeSize(128))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svg := string(b)
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") {
+		t.Errorf("invalid svg: %s", svg)
+	}
+	if !strings.Contains(svg, `width="128"`) {
+		t.Errorf("svg missing width: %s", svg)
+	}
+}
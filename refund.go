@@ -0,0 +1,161 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrRefundSenderNotFound = errors.New("refund sender not found")
+	ErrRefundMultisigSender = errors.New("refund to multisig sender not supported")
+	ErrRefundDeposit        = errors.New("refund of deposit not supported")
+	ErrRefundSelf           = errors.New("refund to self not allowed")
+)
+
+const refundSnapshotLimit = 100
+
+type RefundResult struct {
+	RequestId       string
+	Recipient       string
+	AssetId         string
+	Amount          decimal.Decimal
+	TransactionHash string // 被退款的原交易
+	OutputIndex     uint8
+	AlreadyRefunded bool // 同一 request id 的退款此前已经发出
+	Request         *mixin.SafeTransactionRequest
+}
+
+// Refunder 将无法识别的收款原路退回给付款人
+type Refunder struct {
+	client *ClientWrapper
+	memo   func(utxo *mixin.SafeUtxo) string
+}
+
+// RefunderOption 定义 Refunder 选项
+type RefunderOption func(*Refunder)
+
+// WithRefundMemo 自定义退款的 memo, 默认为 "refund <原交易 hash>"
+func WithRefundMemo(memo func(utxo *mixin.SafeUtxo) string) RefunderOption {
+	return func(r *Refunder) {
+		r.memo = memo
+	}
+}
+
+func NewRefunder(client *ClientWrapper, opts ...RefunderOption) *Refunder {
+	r := &Refunder{
+		client: client,
+		memo: func(utxo *mixin.SafeUtxo) string {
+			return "refund " + utxo.TransactionHash.String()
+		},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// RefundRequestId 由原交易 hash 和 output index 生成确定的退款 request id, 保证同一笔收款只会退款一次
+func RefundRequestId(utxo *mixin.SafeUtxo) string {
+	return GenUuidFromStrings("refund", utxo.TransactionHash.String(), strconv.Itoa(int(utxo.OutputIndex)))
+}
+
+// Handler 返回可以注册到 PaymentListener 的退款 handler
+func (r *Refunder) Handler() PaymentHandler {
+	return func(ctx context.Context, p *Payment) error {
+		_, err := r.Refund(ctx, p.SafeUtxo)
+		return err
+	}
+}
+
+// Refund 将 utxo 原路退回, 已经退过款时直接返回之前的交易; 上次只创建了请求没有提交时继续签名提交
+func (r *Refunder) Refund(ctx context.Context, utxo *mixin.SafeUtxo) (*RefundResult, error) {
+	sender, err := r.Sender(ctx, utxo)
+	if err != nil {
+		return nil, err
+	}
+	if sender == r.client.ClientID {
+		return nil, ErrRefundSelf
+	}
+
+	result := &RefundResult{
+		RequestId:       RefundRequestId(utxo),
+		Recipient:       sender,
+		AssetId:         utxo.AssetID,
+		Amount:          utxo.Amount,
+		TransactionHash: utxo.TransactionHash.String(),
+		OutputIndex:     utxo.OutputIndex,
+	}
+
+	memo := r.memo(utxo)
+	req, err := r.client.readRequest(ctx, result.RequestId)
+	switch {
+	case err == nil && req.State != mixin.SafeUtxoStateUnspent:
+		result.AlreadyRefunded = true
+		result.Request = req
+		return result, nil
+	case err == nil:
+		// 上次创建了请求但没有提交, 使用同一个请求继续
+		result.Request, _, err = r.client.resumeRequest(ctx, req, utxo.AssetID, memo, true)
+	case !mixin.IsErrorCodes(err, mixin.EndpointNotFound):
+		return nil, err
+	case utxo.InscriptionHash.HasValue():
+		result.Request, err = r.client.InscriptionTransfer(ctx, &InscriptionTransferRequest{
+			RequestId:   result.RequestId,
+			AssetId:     utxo.AssetID,
+			Inscription: utxo.InscriptionHash.String(),
+			Memo:        memo,
+			Member:      sender,
+		})
+	default:
+		result.Request, err = r.client.TransferOne(ctx, &TransferOneRequest{
+			RequestId: result.RequestId,
+			AssetId:   utxo.AssetID,
+			Member:    sender,
+			Amount:    utxo.Amount,
+			Memo:      memo,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("refund %s:%d: %w", result.TransactionHash, utxo.OutputIndex, err)
+	}
+
+	return result, nil
+}
+
+// Sender 找出 utxo 的付款人: 优先使用 utxo 的 senders, 否则查找对应 snapshot 的 opponent
+func (r *Refunder) Sender(ctx context.Context, utxo *mixin.SafeUtxo) (string, error) {
+	switch {
+	case len(utxo.Senders) == 1:
+		return utxo.Senders[0], nil
+	case len(utxo.Senders) > 1:
+		return "", ErrRefundMultisigSender
+	}
+
+	offset := utxo.CreatedAt.Add(-time.Second)
+	snapshots, err := r.client.ReadSafeSnapshots(ctx, utxo.AssetID, offset, "ASC", refundSnapshotLimit)
+	if err != nil {
+		return "", err
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.TransactionHash == nil || *snapshot.TransactionHash != utxo.TransactionHash {
+			continue
+		}
+		if snapshot.Deposit != nil {
+			return "", ErrRefundDeposit
+		}
+		if snapshot.OpponentID != "" {
+			return snapshot.OpponentID, nil
+		}
+	}
+
+	return "", ErrRefundSenderNotFound
+}
@@ -0,0 +1,148 @@
+package kit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"github.com/shopspring/decimal"
+)
+
+// newRefundPayment 另一个机器人向 server 的机器人转账 amount, 返回收到的 utxo 和付款机器人
+func newRefundPayment(t *testing.T, server *kittest.SafeServer, amount decimal.Decimal) (*mixin.SafeUtxo, *kit.Config) {
+	t.Helper()
+
+	payer := server.AddBot(t)
+	server.Deposit(testPoolAsset, amount, payer.AppID)
+	if _, err := server.NewBotClientWrapper(t, payer).TransferOne(context.Background(), &kit.TransferOneRequest{
+		RequestId: mixin.RandomTraceID(),
+		AssetId:   testPoolAsset,
+		Member:    server.User.UserID,
+		Amount:    amount,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, utxo := range server.Outputs() {
+		if len(utxo.Senders) == 1 && utxo.Senders[0] == payer.AppID {
+			return &utxo, payer
+		}
+	}
+	t.Fatal("payment not found")
+	return nil, nil
+}
+
+func TestRefundRequestId(t *testing.T) {
+	hash := mixinnet.NewHash([]byte("payment"))
+	utxo := &mixin.SafeUtxo{TransactionHash: hash, OutputIndex: 1}
+
+	id := kit.RefundRequestId(utxo)
+	if id != kit.RefundRequestId(&mixin.SafeUtxo{TransactionHash: hash, OutputIndex: 1}) {
+		t.Errorf("RefundRequestId is not deterministic")
+	}
+	if id == kit.RefundRequestId(&mixin.SafeUtxo{TransactionHash: hash, OutputIndex: 0}) {
+		t.Errorf("RefundRequestId ignores the output index")
+	}
+	if id == kit.RefundRequestId(&mixin.SafeUtxo{TransactionHash: mixinnet.NewHash([]byte("other")), OutputIndex: 1}) {
+		t.Errorf("RefundRequestId ignores the transaction hash")
+	}
+}
+
+func TestRefunder_Sender(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	refunder := kit.NewRefunder(server.NewClientWrapper(t))
+	payment, payer := newRefundPayment(t, server, decimal.NewFromInt(1))
+
+	tests := []struct {
+		name   string
+		utxo   *mixin.SafeUtxo
+		sender string
+		err    error
+	}{
+		{"sender", payment, payer.AppID, nil},
+		{"multisig", &mixin.SafeUtxo{Senders: []string{payer.AppID, server.User.UserID}}, "", kit.ErrRefundMultisigSender},
+		{"deposit", server.Deposit(testPoolAsset, decimal.NewFromInt(1)), "", kit.ErrRefundDeposit},
+		{"unknown", &mixin.SafeUtxo{AssetID: testPoolAsset, TransactionHash: mixinnet.NewHash([]byte("unknown"))}, "", kit.ErrRefundSenderNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender, err := refunder.Sender(context.Background(), tt.utxo)
+			if sender != tt.sender || !errors.Is(err, tt.err) {
+				t.Errorf("Sender() = %q, %v, want %q, %v", sender, err, tt.sender, tt.err)
+			}
+		})
+	}
+}
+
+func TestRefunder_Refund(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	refunder := kit.NewRefunder(server.NewClientWrapper(t))
+	payment, payer := newRefundPayment(t, server, decimal.NewFromInt(3))
+
+	ctx := context.Background()
+	result, err := refunder.Refund(ctx, payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AlreadyRefunded || result.Recipient != payer.AppID || result.RequestId != kit.RefundRequestId(payment) {
+		t.Errorf("result = %+v", result)
+	}
+	if got := server.Balance(testPoolAsset, payer.AppID); !got.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("payer balance = %s, want 3", got)
+	}
+
+	// 再次退款直接返回之前的交易
+	again, err := refunder.Refund(ctx, payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.AlreadyRefunded || again.Request.TransactionHash != result.Request.TransactionHash {
+		t.Errorf("second refund = %+v", again)
+	}
+	if got := server.Balance(testPoolAsset, payer.AppID); !got.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("payer balance after second refund = %s, want 3", got)
+	}
+}
+
+func TestRefunder_RefundResume(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []kit.ClientWrapperOption
+	}{
+		{"eager", nil},
+		{"lazy user", []kit.ClientWrapperOption{kit.WithLazyUser()}}, // 继续提交前需要加载 spend key
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := kittest.NewSafeServer(t)
+			payment, payer := newRefundPayment(t, server, decimal.NewFromInt(3))
+
+			// 请求已创建, 提交失败
+			ctx := context.Background()
+			server.FailNext("POST", "/safe/transactions", 500, "timeout")
+			if _, err := kit.NewRefunder(server.NewClientWrapper(t)).Refund(ctx, payment); err == nil {
+				t.Fatal("Refund() succeeded, want submit error")
+			}
+			client := server.NewClientWrapper(t, tt.opts...)
+			req, err := client.SafeReadTransactionRequest(ctx, kit.RefundRequestId(payment))
+			if err != nil || req.State != mixin.SafeUtxoStateUnspent {
+				t.Fatalf("request = %+v, %v, want unspent", req, err)
+			}
+
+			result, err := kit.NewRefunder(client).Refund(ctx, payment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.AlreadyRefunded || result.Request.State != mixin.SafeUtxoStateSpent || result.Request.TransactionHash != req.TransactionHash {
+				t.Errorf("result = %+v, request = %+v", result, result.Request)
+			}
+			if got := server.Balance(testPoolAsset, payer.AppID); !got.Equal(decimal.NewFromInt(3)) {
+				t.Errorf("payer balance = %s, want 3", got)
+			}
+		})
+	}
+}
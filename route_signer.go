@@ -0,0 +1,232 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"filippo.io/edwards25519"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"golang.org/x/crypto/curve25519"
+)
+
+var (
+	ErrInvalidSessionPrivateKey = errors.New("invalid session private key")
+	ErrInvalidRoutePublicKey    = errors.New("invalid route public key")
+)
+
+// RoutePublicKeyFunc 返回 Mixin Route 机器人的 ed25519 session 公钥
+type RoutePublicKeyFunc func(ctx context.Context) (ed25519.PublicKey, error)
+
+// StaticRoutePublicKey 使用已知的 Route 公钥, 便于离线签名和测试
+func StaticRoutePublicKey(key ed25519.PublicKey) RoutePublicKeyFunc {
+	return func(ctx context.Context) (ed25519.PublicKey, error) {
+		return key, nil
+	}
+}
+
+// FetchRoutePublicKey 通过 /sessions/fetch 获取 Route 机器人的 session 公钥
+func FetchRoutePublicKey(client *mixin.Client) RoutePublicKeyFunc {
+	return func(ctx context.Context) (ed25519.PublicKey, error) {
+		sessions, err := client.FetchSessions(ctx, []string{MixinRouteClientID})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range sessions {
+			if s.PublicKey == "" {
+				continue
+			}
+			return decodeRoutePublicKey(s.PublicKey)
+		}
+		return nil, fmt.Errorf("%w: no session of %s", ErrInvalidRoutePublicKey, MixinRouteClientID)
+	}
+}
+
+func decodeRoutePublicKey(s string) (ed25519.PublicKey, error) {
+	for _, enc := range []func(string) ([]byte, error){
+		base64.RawURLEncoding.DecodeString,
+		base64.StdEncoding.DecodeString,
+		hex.DecodeString,
+	} {
+		if b, err := enc(s); err == nil && len(b) == ed25519.PublicKeySize {
+			return b, nil
+		}
+	}
+	return nil, ErrInvalidRoutePublicKey
+}
+
+// RouteSigner 计算 Mixin Route 接口的 MR-ACCESS-SIGN:
+//
+//	content   = timestamp + method + uri + body
+//	key       = X25519(session private key, route public key)
+//	signature = base64url(app_id + HMAC-SHA256(key, content))
+//
+// uri 为实际发送的路径和 query (不含 scheme 和 host), body 为实际发送的字节.
+type RouteSigner struct {
+	appID      string
+	privateKey ed25519.PrivateKey
+	publicKey  RoutePublicKeyFunc
+	now        func() time.Time
+
+	mu        sync.Mutex
+	sharedKey []byte
+}
+
+// NewRouteSigner 创建签名器, sessionPrivateKey 为 hex 编码的 ed25519 seed (与 Config.SessionPrivateKey 一致) 或完整私钥
+func NewRouteSigner(appID, sessionPrivateKey string, publicKey RoutePublicKeyFunc) (*RouteSigner, error) {
+	b, err := hex.DecodeString(sessionPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSessionPrivateKey, err)
+	}
+
+	var key ed25519.PrivateKey
+	switch len(b) {
+	case ed25519.SeedSize:
+		key = ed25519.NewKeyFromSeed(b)
+	case ed25519.PrivateKeySize:
+		key = ed25519.PrivateKey(b)
+	default:
+		return nil, fmt.Errorf("%w: length %d", ErrInvalidSessionPrivateKey, len(b))
+	}
+
+	return &RouteSigner{
+		appID:      appID,
+		privateKey: key,
+		publicKey:  publicKey,
+		now:        time.Now,
+	}, nil
+}
+
+func (s *RouteSigner) key(ctx context.Context) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sharedKey != nil {
+		return s.sharedKey, nil
+	}
+
+	pub, err := s.publicKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("route public key: %w", err)
+	}
+
+	key, err := routeSharedKey(s.privateKey, pub)
+	if err != nil {
+		return nil, err
+	}
+	s.sharedKey = key
+	return key, nil
+}
+
+// routeSharedKey 将 ed25519 密钥对转换为 curve25519 后做 ECDH
+func routeSharedKey(private ed25519.PrivateKey, public ed25519.PublicKey) ([]byte, error) {
+	if len(public) != ed25519.PublicKeySize {
+		return nil, ErrInvalidRoutePublicKey
+	}
+
+	digest := sha512.Sum512(private.Seed())
+	digest[0] &= 248
+	digest[31] &= 127
+	digest[31] |= 64
+
+	p, err := new(edwards25519.Point).SetBytes(public)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRoutePublicKey, err)
+	}
+
+	return curve25519.X25519(digest[:32], p.BytesMontgomery())
+}
+
+// Sign 计算签名, uri 需与实际发送的请求完全一致 (包括 query 的顺序)
+func (s *RouteSigner) Sign(ctx context.Context, ts int64, method, uri string, body []byte) (string, error) {
+	key, err := s.key(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte(method))
+	mac.Write([]byte(uri))
+	mac.Write(body)
+
+	sig := append([]byte(s.appID), mac.Sum(nil)...)
+	return base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// SignRequest 对任意 http.Request 签名并设置 MR-ACCESS-TIMESTAMP 和 MR-ACCESS-SIGN,
+// 签名使用 req.URL.RequestURI() 和请求体的原始字节, 读取后会恢复 req.Body
+func (s *RouteSigner) SignRequest(req *http.Request) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("read body for signing: %w", err)
+		}
+		_ = req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	ts := s.now().Unix()
+	signature, err := s.Sign(req.Context(), ts, req.Method, req.URL.RequestURI(), body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(HeaderAccessTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderAccessSign, signature)
+	return nil
+}
+
+// Transport 返回对每个请求签名的 http.RoundTripper, base 为 nil 时使用 http.DefaultTransport
+func (s *RouteSigner) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &routeSignerTransport{signer: s, base: base}
+}
+
+type routeSignerTransport struct {
+	signer *RouteSigner
+	base   http.RoundTripper
+}
+
+func (t *routeSignerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper 不应修改传入的请求
+	req = req.Clone(req.Context())
+	if err := t.signer.SignRequest(req); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Verify 校验签名, 供离线测试和模拟服务端使用.
+// 服务端可以用 Route 私钥和机器人的 session 公钥创建 RouteSigner, 得到的共享密钥相同.
+func (s *RouteSigner) Verify(ctx context.Context, ts int64, method, uri string, body []byte, signature string) bool {
+	want, err := s.Sign(ctx, ts, method, uri, body)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(want), []byte(signature))
+}
+
+// PublicKey 返回 session 私钥对应的 ed25519 公钥
+func (s *RouteSigner) PublicKey() ed25519.PublicKey {
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
@@ -0,0 +1,193 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRouteAppID = "7fa6d0c1-2c7a-4d3e-9b1a-0c3f4e5d6a7b"
+
+var (
+	testRouteBotSeed = hex.EncodeToString(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	testRouteKey     = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+)
+
+func testRouteSigner(t *testing.T) *RouteSigner {
+	t.Helper()
+	s, err := NewRouteSigner(testRouteAppID, testRouteBotSeed, StaticRoutePublicKey(testRouteKey.Public().(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// signRouteIndependent 不经过 RouteSigner, 按文档中的算法计算签名, 用于交叉验证:
+// ed25519 公钥用 u = (1+y)/(1-y) mod p 转换为 X25519 公钥, seed 的 SHA-512 前 32 字节作为 X25519 私钥 (由 crypto/ecdh 做 clamp)
+func signRouteIndependent(t *testing.T, seed []byte, public ed25519.PublicKey, appID string, ts int64, method, uri string, body []byte) string {
+	t.Helper()
+
+	p := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	le := func(b []byte) []byte {
+		r := make([]byte, len(b))
+		for i := range b {
+			r[len(b)-1-i] = b[i]
+		}
+		return r
+	}
+	yb := le(public)
+	yb[0] &= 0x7f // 去掉 x 的符号位
+	y := new(big.Int).SetBytes(yb)
+	one := big.NewInt(1)
+	u := new(big.Int).Add(one, y)
+	u.Mul(u, new(big.Int).ModInverse(new(big.Int).Mod(new(big.Int).Sub(one, y), p), p))
+	u.Mod(u, p)
+	ub := le(u.FillBytes(make([]byte, 32)))
+
+	digest := sha512.Sum512(seed)
+	private, err := ecdh.X25519().NewPrivateKey(digest[:32])
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := ecdh.X25519().NewPublicKey(ub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := private.ECDH(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strconv.FormatInt(ts, 10) + method + uri))
+	mac.Write(body)
+	return base64.RawURLEncoding.EncodeToString(append([]byte(appID), mac.Sum(nil)...))
+}
+
+func TestRouteSigner_GoldenVectors(t *testing.T) {
+	s := testRouteSigner(t)
+
+	tests := []struct {
+		method string
+		uri    string
+		body   string
+		want   string
+	}{
+		{
+			method: "GET",
+			uri:    "/markets/c6d0c728-2624-429b-8e0d-d9d19b6592fa",
+			want:   "N2ZhNmQwYzEtMmM3YS00ZDNlLTliMWEtMGMzZjRlNWQ2YTdi7uu8-oXHs1SHIZ3ADIwBBpEYDWcPN3x4j_PBT3FtAQg",
+		},
+		{
+			method: "GET",
+			uri:    "/web3/quote?inputMint=a&outputMint=b&amount=1",
+			want:   "N2ZhNmQwYzEtMmM3YS00ZDNlLTliMWEtMGMzZjRlNWQ2YTdirTkYCOT3EXZDSRarZvDHnKl3iiSplmnT1aAg4AWUFlQ",
+		},
+		{
+			method: "POST",
+			uri:    "/web3/swap",
+			body:   `{"payer":"x","inputMint":"a"}`,
+			want:   "N2ZhNmQwYzEtMmM3YS00ZDNlLTliMWEtMGMzZjRlNWQ2YTdik_q_tiGbwkbFpUKTi9DIFCVUauGsuIFkNvli15vGEBw",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.uri, func(t *testing.T) {
+			got, err := s.Sign(context.Background(), 1700000000, tt.method, tt.uri, []byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Sign() = %s, want %s", got, tt.want)
+			}
+
+			// 期望值由独立实现交叉验证, 机器人和 Route 两侧的计算结果相同
+			botSeed, _ := hex.DecodeString(testRouteBotSeed)
+			botPublic := ed25519.NewKeyFromSeed(botSeed).Public().(ed25519.PublicKey)
+			for side, v := range map[string]string{
+				"bot":   signRouteIndependent(t, botSeed, testRouteKey.Public().(ed25519.PublicKey), testRouteAppID, 1700000000, tt.method, tt.uri, []byte(tt.body)),
+				"route": signRouteIndependent(t, testRouteKey.Seed(), botPublic, testRouteAppID, 1700000000, tt.method, tt.uri, []byte(tt.body)),
+			} {
+				if v != tt.want {
+					t.Errorf("independent %s side = %s, want %s", side, v, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRouteSigner_SharedKeySymmetric(t *testing.T) {
+	bot := testRouteSigner(t)
+
+	// 服务端用 Route 私钥和机器人公钥计算出的签名应当一致
+	server, err := NewRouteSigner(testRouteAppID, hex.EncodeToString(testRouteKey.Seed()), StaticRoutePublicKey(bot.PublicKey()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	sig, err := bot.Sign(ctx, 1700000000, "POST", "/web3/swap", []byte(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !server.Verify(ctx, 1700000000, "POST", "/web3/swap", []byte(`{}`), sig) {
+		t.Error("server failed to verify signature")
+	}
+	if server.Verify(ctx, 1700000000, "POST", "/web3/swap", []byte(`{ }`), sig) {
+		t.Error("signature verified with different body")
+	}
+}
+
+func TestRouteSigner_SignRequest(t *testing.T) {
+	s := testRouteSigner(t)
+	s.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	const body = `{"payer":"x","inputMint":"a"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(HeaderAccessTimestamp), 10, 64)
+		if string(got) != body || !s.Verify(r.Context(), ts, r.Method, r.URL.RequestURI(), got, r.Header.Get(HeaderAccessSign)) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	// query 保持原始顺序, 不做重新编码
+	client := &http.Client{Transport: s.Transport(nil)}
+	req, err := http.NewRequest("POST", srv.URL+"/web3/swap?z=1&a=2", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if req.Header.Get(HeaderAccessSign) != "" {
+		t.Error("Transport modified the original request")
+	}
+}
+
+func TestNewRouteSigner_InvalidKey(t *testing.T) {
+	for _, key := range []string{"", "zz", hex.EncodeToString([]byte("short"))} {
+		if _, err := NewRouteSigner(testRouteAppID, key, nil); err == nil {
+			t.Errorf("NewRouteSigner(%q) should fail", key)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrSpendingPolicy 所有 SpendingPolicy 拒绝转账的错误都满足 errors.Is(err, ErrSpendingPolicy)
+var ErrSpendingPolicy = errors.New("spending policy violation")
+
+const DefaultSpendingWindow = 24 * time.Hour
+
+// TransactionLimitError 单笔转账金额超过上限
+type TransactionLimitError struct {
+	AssetId string
+	Amount  decimal.Decimal
+	Limit   decimal.Decimal
+}
+
+func (e *TransactionLimitError) Error() string {
+	return fmt.Sprintf("%s: asset %s amount %s exceeds per transaction limit %s", ErrSpendingPolicy, e.AssetId, e.Amount, e.Limit)
+}
+
+func (e *TransactionLimitError) Is(target error) bool { return target == ErrSpendingPolicy }
+
+// DailyLimitError 滚动窗口内的累计转账金额超过上限
+type DailyLimitError struct {
+	AssetId string
+	Amount  decimal.Decimal
+	Spent   decimal.Decimal // 窗口内已转出的金额
+	Limit   decimal.Decimal
+	Window  time.Duration
+}
+
+func (e *DailyLimitError) Error() string {
+	return fmt.Sprintf("%s: asset %s amount %s exceeds limit %s per %s, already spent %s", ErrSpendingPolicy, e.AssetId, e.Amount, e.Limit, e.Window, e.Spent)
+}
+
+func (e *DailyLimitError) Is(target error) bool { return target == ErrSpendingPolicy }
+
+// RecipientError 收款人在黑名单中, 或设置了白名单但收款人不在其中
+type RecipientError struct {
+	Recipient string
+	Denied    bool // true 为命中黑名单, false 为不在白名单
+}
+
+func (e *RecipientError) Error() string {
+	if e.Denied {
+		return fmt.Sprintf("%s: recipient %s is denied", ErrSpendingPolicy, e.Recipient)
+	}
+	return fmt.Sprintf("%s: recipient %s is not allowed", ErrSpendingPolicy, e.Recipient)
+}
+
+func (e *RecipientError) Is(target error) bool { return target == ErrSpendingPolicy }
+
+// ApprovalError 金额超过审批阈值, 审批未通过
+type ApprovalError struct {
+	AssetId   string
+	Amount    decimal.Decimal
+	Threshold decimal.Decimal
+	Err       error // ApprovalFunc 返回的错误
+}
+
+func (e *ApprovalError) Error() string {
+	return fmt.Sprintf("%s: asset %s amount %s above approval threshold %s not approved: %v", ErrSpendingPolicy, e.AssetId, e.Amount, e.Threshold, e.Err)
+}
+
+func (e *ApprovalError) Is(target error) bool { return target == ErrSpendingPolicy }
+
+func (e *ApprovalError) Unwrap() error { return e.Err }
+
+// Spend 一笔待签名的转出
+type Spend struct {
+	RequestId  string
+	AssetId    string
+	Amount     decimal.Decimal // 所有收款人的金额合计
+	Recipients []string
+	Memo       string
+}
+
+// ApprovalFunc 审批大额转账, 返回 nil 表示通过; 可以在这里等待第二个人确认
+type ApprovalFunc func(ctx context.Context, spend *Spend) error
+
+type spendRecord struct {
+	requestId string
+	amount    decimal.Decimal
+	at        time.Time
+}
+
+type approvalRule struct {
+	threshold decimal.Decimal
+	approve   ApprovalFunc
+}
+
+// SpendingPolicy 在签名前检查每一笔转出: 单笔上限, 滚动窗口累计上限, 收款人黑白名单, 大额审批.
+// 累计金额只保存在内存中, 进程重启后从零开始
+type SpendingPolicy struct {
+	window    time.Duration
+	perTx     map[string]decimal.Decimal // asset id -> 单笔上限
+	daily     map[string]decimal.Decimal // asset id -> 窗口内上限
+	allow     map[string]bool
+	deny      map[string]bool
+	approvals map[string]approvalRule // asset id -> 审批规则
+	now       func() time.Time
+
+	mu    sync.Mutex
+	spent map[string][]spendRecord // asset id -> 窗口内的转出
+}
+
+// SpendingPolicyOption 定义 SpendingPolicy 选项
+type SpendingPolicyOption func(*SpendingPolicy)
+
+// WithTransactionLimit 设置 assetId 单笔转账上限
+func WithTransactionLimit(assetId string, limit decimal.Decimal) SpendingPolicyOption {
+	return func(p *SpendingPolicy) {
+		p.perTx[assetId] = limit
+	}
+}
+
+// WithDailyLimit 设置 assetId 在滚动窗口 (默认 24 小时) 内的累计转账上限
+func WithDailyLimit(assetId string, limit decimal.Decimal) SpendingPolicyOption {
+	return func(p *SpendingPolicy) {
+		p.daily[assetId] = limit
+	}
+}
+
+// WithSpendingWindow 设置累计上限的滚动窗口
+func WithSpendingWindow(window time.Duration) SpendingPolicyOption {
+	return func(p *SpendingPolicy) {
+		if window > 0 {
+			p.window = window
+		}
+	}
+}
+
+// WithRecipientAllowList 只允许向 recipients 转账
+func WithRecipientAllowList(recipients ...string) SpendingPolicyOption {
+	return func(p *SpendingPolicy) {
+		for _, r := range recipients {
+			p.allow[r] = true
+		}
+	}
+}
+
+// WithRecipientDenyList 禁止向 recipients 转账, 优先于白名单
+func WithRecipientDenyList(recipients ...string) SpendingPolicyOption {
+	return func(p *SpendingPolicy) {
+		for _, r := range recipients {
+			p.deny[r] = true
+		}
+	}
+}
+
+// WithApproval assetId 单笔金额超过 threshold 时需要 approve 通过
+func WithApproval(assetId string, threshold decimal.Decimal, approve ApprovalFunc) SpendingPolicyOption {
+	return func(p *SpendingPolicy) {
+		p.approvals[assetId] = approvalRule{threshold: threshold, approve: approve}
+	}
+}
+
+func NewSpendingPolicy(opts ...SpendingPolicyOption) *SpendingPolicy {
+	p := &SpendingPolicy{
+		window:    DefaultSpendingWindow,
+		perTx:     map[string]decimal.Decimal{},
+		daily:     map[string]decimal.Decimal{},
+		allow:     map[string]bool{},
+		deny:      map[string]bool{},
+		approvals: map[string]approvalRule{},
+		now:       time.Now,
+		spent:     map[string][]spendRecord{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Check 检查 spend 是否允许, 不记录累计金额
+func (p *SpendingPolicy) Check(ctx context.Context, spend *Spend) error {
+	if err := p.checkStatic(spend); err != nil {
+		return err
+	}
+	if err := p.approve(ctx, spend); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.checkDaily(spend)
+}
+
+// Authorize 检查 spend 并计入累计金额. 转账没有发出时调用 release 撤销;
+// 同一个 RequestId 只计入一次, 金额与已计入的不同时返回错误
+func (p *SpendingPolicy) Authorize(ctx context.Context, spend *Spend) (release func(), err error) {
+	if err := p.checkStatic(spend); err != nil {
+		return nil, err
+	}
+	// 审批可能需要等待, 不持有锁
+	if err := p.approve(ctx, spend); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, r := range p.spent[spend.AssetId] {
+		if r.requestId != spend.RequestId {
+			continue
+		}
+		if !r.amount.Equal(spend.Amount) {
+			return nil, fmt.Errorf("%w: request %s was authorized for %s, got %s", ErrSpendingPolicy, spend.RequestId, r.amount, spend.Amount)
+		}
+		return func() {}, nil
+	}
+	if err := p.checkDaily(spend); err != nil {
+		return nil, err
+	}
+
+	p.spent[spend.AssetId] = append(p.spent[spend.AssetId], spendRecord{
+		requestId: spend.RequestId,
+		amount:    spend.Amount,
+		at:        p.now(),
+	})
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		records := p.spent[spend.AssetId]
+		for i, r := range records {
+			if r.requestId == spend.RequestId {
+				p.spent[spend.AssetId] = append(records[:i], records[i+1:]...)
+				return
+			}
+		}
+	}, nil
+}
+
+// Spent 返回 assetId 在当前窗口内已转出的金额
+func (p *SpendingPolicy) Spent(assetId string) decimal.Decimal {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.spentLocked(assetId)
+}
+
+func (p *SpendingPolicy) spentLocked(assetId string) decimal.Decimal {
+	since := p.now().Add(-p.window)
+
+	records := p.spent[assetId]
+	i := 0
+	for i < len(records) && !records[i].at.After(since) {
+		i++
+	}
+	records = records[i:]
+	p.spent[assetId] = records
+
+	spent := decimal.Zero
+	for _, r := range records {
+		spent = spent.Add(r.amount)
+	}
+	return spent
+}
+
+func (p *SpendingPolicy) checkStatic(spend *Spend) error {
+	for _, r := range spend.Recipients {
+		if p.deny[r] {
+			return &RecipientError{Recipient: r, Denied: true}
+		}
+		if len(p.allow) > 0 && !p.allow[r] {
+			return &RecipientError{Recipient: r}
+		}
+	}
+
+	if limit, ok := p.perTx[spend.AssetId]; ok && spend.Amount.GreaterThan(limit) {
+		return &TransactionLimitError{AssetId: spend.AssetId, Amount: spend.Amount, Limit: limit}
+	}
+	return nil
+}
+
+func (p *SpendingPolicy) approve(ctx context.Context, spend *Spend) error {
+	rule, ok := p.approvals[spend.AssetId]
+	if !ok || !spend.Amount.GreaterThan(rule.threshold) {
+		return nil
+	}
+
+	err := errors.New("no approver")
+	if rule.approve != nil {
+		err = rule.approve(ctx, spend)
+	}
+	if err != nil {
+		return &ApprovalError{AssetId: spend.AssetId, Amount: spend.Amount, Threshold: rule.threshold, Err: err}
+	}
+	return nil
+}
+
+// checkDaily 调用方需持有 p.mu
+func (p *SpendingPolicy) checkDaily(spend *Spend) error {
+	limit, ok := p.daily[spend.AssetId]
+	if !ok {
+		return nil
+	}
+	spent := p.spentLocked(spend.AssetId)
+	if spent.Add(spend.Amount).GreaterThan(limit) {
+		return &DailyLimitError{AssetId: spend.AssetId, Amount: spend.Amount, Spent: spent, Limit: limit, Window: p.window}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package kit_test
+
+import (
+	"context"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+func TestClientWrapper_TransferManyNApproval(t *testing.T) {
+	tests := []struct {
+		name    string
+		members int
+	}{
+		{"one batch", 2},
+		{"two batches", kit.MAX_UTXO_NUM + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := kittest.NewSafeServer(t)
+
+			var approvals []*kit.Spend
+			policy := kit.NewSpendingPolicy(kit.WithApproval(testPoolAsset, decimal.Zero, func(ctx context.Context, spend *kit.Spend) error {
+				approvals = append(approvals, spend)
+				return nil
+			}))
+			client := server.NewClientWrapper(t, kit.WithSpendingPolicy(policy))
+			server.Deposit(testPoolAsset, decimal.NewFromInt(1000))
+
+			req := &kit.TransferManyRequest{RequestId: mixin.RandomTraceID(), AssetId: testPoolAsset}
+			for i := 0; i < tt.members; i++ {
+				req.MemberAmount = append(req.MemberAmount, kit.MemberAmount{Member: []string{mixin.RandomTraceID()}, Amount: decimal.NewFromInt(1)})
+			}
+			if err := client.TransferManyN(context.Background(), req); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(approvals) != 1 || approvals[0].RequestId != req.RequestId || !approvals[0].Amount.Equal(decimal.NewFromInt(int64(tt.members))) {
+				t.Fatalf("approvals = %+v, want one for the total", approvals)
+			}
+			if spent := policy.Spent(testPoolAsset); !spent.Equal(decimal.NewFromInt(int64(tt.members))) {
+				t.Errorf("Spent() = %s, want %d", spent, tt.members)
+			}
+		})
+	}
+}
+
+func TestClientWrapper_SpendReleasedBeforeSubmit(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	policy := kit.NewSpendingPolicy(kit.WithDailyLimit(testPoolAsset, decimal.NewFromInt(100)))
+	client := server.NewClientWrapper(t, kit.WithSpendingPolicy(policy))
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+
+	transfer := func() error {
+		_, err := client.TransferOne(context.Background(), &kit.TransferOneRequest{
+			RequestId: mixin.RandomTraceID(),
+			AssetId:   testPoolAsset,
+			Member:    testPoolRecipient,
+			Amount:    decimal.NewFromInt(3),
+		})
+		return err
+	}
+
+	// 创建请求失败, 交易没有发出, 撤销累计金额
+	server.FailNext("POST", "/safe/transaction/requests", 500, "unavailable")
+	if err := transfer(); err == nil {
+		t.Fatal("TransferOne() succeeded, want error")
+	}
+	if spent := policy.Spent(testPoolAsset); !spent.IsZero() {
+		t.Errorf("Spent() after create failure = %s, want 0", spent)
+	}
+
+	// 提交失败时交易可能已经上链, 保留累计金额
+	server.FailNext("POST", "/safe/transactions", 500, "timeout")
+	if err := transfer(); err == nil {
+		t.Fatal("TransferOne() succeeded, want error")
+	}
+	if spent := policy.Spent(testPoolAsset); !spent.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("Spent() after submit failure = %s, want 3", spent)
+	}
+}
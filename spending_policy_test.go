@@ -0,0 +1,185 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	testSpendAsset = "965e5c6e-434c-3fa9-b780-c50f43cd955c"
+	testSpendAlice = "e9e5b807-fa8b-455a-8dfa-b189d28310ff"
+	testSpendBob   = "8dcf823d-9eb3-4da2-8734-f0aad50c0da6"
+)
+
+func TestSpendingPolicy(t *testing.T) {
+	errRejected := errors.New("rejected by reviewer")
+	approved := map[string]bool{"big-ok": true}
+
+	tests := []struct {
+		name    string
+		opts    []SpendingPolicyOption
+		spends  []Spend
+		wantErr error // 最后一笔的错误
+	}{
+		{
+			name:   "per transaction limit",
+			opts:   []SpendingPolicyOption{WithTransactionLimit(testSpendAsset, decimal.NewFromInt(10))},
+			spends: []Spend{{RequestId: "1", Amount: decimal.NewFromInt(11), Recipients: []string{testSpendAlice}}},
+			wantErr: &TransactionLimitError{
+				AssetId: testSpendAsset, Amount: decimal.NewFromInt(11), Limit: decimal.NewFromInt(10),
+			},
+		},
+		{
+			name: "daily limit",
+			opts: []SpendingPolicyOption{WithDailyLimit(testSpendAsset, decimal.NewFromInt(10))},
+			spends: []Spend{
+				{RequestId: "1", Amount: decimal.NewFromInt(6), Recipients: []string{testSpendAlice}},
+				{RequestId: "1", Amount: decimal.NewFromInt(6), Recipients: []string{testSpendAlice}}, // 同一 request id 只计一次
+				{RequestId: "2", Amount: decimal.NewFromInt(5), Recipients: []string{testSpendAlice}},
+			},
+			wantErr: &DailyLimitError{},
+		},
+		{
+			name: "reused request id",
+			opts: []SpendingPolicyOption{WithDailyLimit(testSpendAsset, decimal.NewFromInt(10))},
+			spends: []Spend{
+				{RequestId: "1", Amount: decimal.NewFromInt(6), Recipients: []string{testSpendAlice}},
+				{RequestId: "1", Amount: decimal.NewFromInt(60), Recipients: []string{testSpendAlice}}, // 金额不同时不能绕过累计上限
+			},
+			wantErr: ErrSpendingPolicy,
+		},
+		{
+			name:    "deny list",
+			opts:    []SpendingPolicyOption{WithRecipientDenyList(testSpendBob)},
+			spends:  []Spend{{RequestId: "1", Amount: decimal.NewFromInt(1), Recipients: []string{testSpendAlice, testSpendBob}}},
+			wantErr: &RecipientError{Recipient: testSpendBob, Denied: true},
+		},
+		{
+			name:    "allow list",
+			opts:    []SpendingPolicyOption{WithRecipientAllowList(testSpendAlice)},
+			spends:  []Spend{{RequestId: "1", Amount: decimal.NewFromInt(1), Recipients: []string{testSpendBob}}},
+			wantErr: &RecipientError{Recipient: testSpendBob},
+		},
+		{
+			name: "approval",
+			opts: []SpendingPolicyOption{WithApproval(testSpendAsset, decimal.NewFromInt(100), func(ctx context.Context, spend *Spend) error {
+				if approved[spend.RequestId] {
+					return nil
+				}
+				return errRejected
+			})},
+			spends: []Spend{
+				{RequestId: "small", Amount: decimal.NewFromInt(100), Recipients: []string{testSpendAlice}},
+				{RequestId: "big-ok", Amount: decimal.NewFromInt(101), Recipients: []string{testSpendAlice}},
+				{RequestId: "big", Amount: decimal.NewFromInt(101), Recipients: []string{testSpendAlice}},
+			},
+			wantErr: errRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewSpendingPolicy(tt.opts...)
+
+			var err error
+			for i, spend := range tt.spends {
+				spend.AssetId = testSpendAsset
+				_, err = p.Authorize(context.Background(), &spend)
+				if i < len(tt.spends)-1 && err != nil {
+					t.Fatalf("Authorize(%d) error = %v", i, err)
+				}
+			}
+
+			if !errors.Is(err, ErrSpendingPolicy) {
+				t.Fatalf("Authorize() error = %v, want ErrSpendingPolicy", err)
+			}
+			switch want := tt.wantErr.(type) {
+			case *TransactionLimitError:
+				var got *TransactionLimitError
+				if !errors.As(err, &got) || !got.Limit.Equal(want.Limit) {
+					t.Errorf("Authorize() error = %v, want %v", err, want)
+				}
+			case *DailyLimitError:
+				var got *DailyLimitError
+				if !errors.As(err, &got) || !got.Spent.Equal(decimal.NewFromInt(6)) {
+					t.Errorf("Authorize() error = %v", err)
+				}
+			case *RecipientError:
+				var got *RecipientError
+				if !errors.As(err, &got) || *got != *want {
+					t.Errorf("Authorize() error = %v, want %v", err, want)
+				}
+			default:
+				if !errors.Is(err, want) {
+					t.Errorf("Authorize() error = %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSpendingPolicy_Window(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewSpendingPolicy(WithDailyLimit(testSpendAsset, decimal.NewFromInt(10)))
+	p.now = func() time.Time { return now }
+
+	spend := func(id string, amount int64) error {
+		_, err := p.Authorize(context.Background(), &Spend{RequestId: id, AssetId: testSpendAsset, Amount: decimal.NewFromInt(amount)})
+		return err
+	}
+
+	if err := spend("1", 8); err != nil {
+		t.Fatal(err)
+	}
+
+	// 转账没有发出时撤销
+	release, err := p.Authorize(context.Background(), &Spend{RequestId: "2", AssetId: testSpendAsset, Amount: decimal.NewFromInt(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if got := p.Spent(testSpendAsset); !got.Equal(decimal.NewFromInt(8)) {
+		t.Errorf("Spent() = %s, want 8", got)
+	}
+
+	if err := spend("3", 3); !errors.Is(err, ErrSpendingPolicy) {
+		t.Errorf("Authorize() error = %v, want ErrSpendingPolicy", err)
+	}
+
+	now = now.Add(DefaultSpendingWindow + time.Second)
+	if err := spend("3", 3); err != nil {
+		t.Errorf("Authorize() after window error = %v", err)
+	}
+}
+
+func TestClientWrapper_SpendingPolicy(t *testing.T) {
+	config := testValidConfig()
+	spendKey, err := mixinnet.KeyFromSeed(config.SpendKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := NewSpendingPolicy(WithRecipientAllowList(testSpendAlice))
+	c, err := NewMixinClientWrapper(&config, WithSpendPublicKey(spendKey.Public().String()), WithSpendingPolicy(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 在访问网络前就被拒绝
+	ctx := context.Background()
+	if _, err := c.TransferOne(ctx, &TransferOneRequest{RequestId: "1", AssetId: testSpendAsset, Member: testSpendBob, Amount: decimal.NewFromInt(1)}); !errors.Is(err, ErrSpendingPolicy) {
+		t.Errorf("TransferOne() error = %v, want ErrSpendingPolicy", err)
+	}
+	err = c.TransferManyN(ctx, &TransferManyRequest{
+		RequestId:    "2",
+		AssetId:      testSpendAsset,
+		MemberAmount: []MemberAmount{{Member: []string{testSpendAlice}, Amount: decimal.NewFromInt(1)}, {Member: []string{testSpendBob}, Amount: decimal.NewFromInt(1)}},
+	})
+	if !errors.Is(err, ErrSpendingPolicy) {
+		t.Errorf("TransferManyN() error = %v, want ErrSpendingPolicy", err)
+	}
+}
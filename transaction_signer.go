@@ -0,0 +1,343 @@
+package kit
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+)
+
+var (
+	ErrSignerUnauthorized = errors.New("signer unauthorized")
+	ErrSignerMismatch     = errors.New("signed transaction not matched")
+	ErrSignerNoToken      = errors.New("signer server requires a token")
+)
+
+// TransactionSigner 使用 spend key 为交易的输入签名, views 为 SafeCreateTransactionRequest 返回的 Views,
+// index 为多签中签名者的序号, 单签为 0. 签名写入 tx.Signatures
+type TransactionSigner interface {
+	SignTransaction(ctx context.Context, tx *mixinnet.Transaction, views []mixinnet.Key, index uint16) error
+}
+
+// SpendKeySigner 使用内存中的 spend key 签名, ClientWrapper 的默认实现
+type SpendKeySigner struct {
+	Key mixinnet.Key
+}
+
+func (s SpendKeySigner) SignTransaction(ctx context.Context, tx *mixinnet.Transaction, views []mixinnet.Key, index uint16) error {
+	if !s.Key.HasValue() {
+		return errors.New("spend key not loaded")
+	}
+	return mixin.SafeSignTransaction(tx, s.Key, views, index)
+}
+
+func (s SpendKeySigner) PublicKey() mixinnet.Key {
+	return s.Key.Public()
+}
+
+const (
+	signerPathSign      = "/sign"
+	signerPathPublicKey = "/public_key"
+	signerMaxBodySize   = 1 << 20
+)
+
+type signRequest struct {
+	RawTransaction string         `json:"raw_transaction"`
+	Views          []mixinnet.Key `json:"views"`
+	Index          uint16         `json:"index"`
+}
+
+type signResponse struct {
+	RawTransaction string `json:"raw_transaction,omitempty"`
+	PublicKey      string `json:"public_key,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// RemoteSigner 通过 HTTP 请求独立的签名进程 (见 SignerServer), spend key 不进入当前进程
+type RemoteSigner struct {
+	baseURL string
+	client  *http.Client
+	token   string
+}
+
+// RemoteSignerOption 定义 RemoteSigner 选项
+type RemoteSignerOption func(*RemoteSigner)
+
+// WithSignerToken 请求时携带 Authorization: Bearer token, 与 WithSignerServerToken 对应
+func WithSignerToken(token string) RemoteSignerOption {
+	return func(s *RemoteSigner) {
+		s.token = token
+	}
+}
+
+// WithSignerTimeout 设置请求超时, 默认 10s
+func WithSignerTimeout(timeout time.Duration) RemoteSignerOption {
+	return func(s *RemoteSigner) {
+		s.client.Timeout = timeout
+	}
+}
+
+// NewRemoteSigner 连接签名进程, network 为 unix 时 address 为 socket 路径, 为 tcp 时为 host:port
+func NewRemoteSigner(network, address string, opts ...RemoteSignerOption) *RemoteSigner {
+	s := &RemoteSigner{
+		baseURL: "http://" + address,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	if network == "unix" {
+		var dialer net.Dialer
+		s.baseURL = "http://signer"
+		s.client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", address)
+			},
+		}
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *RemoteSigner) SignTransaction(ctx context.Context, tx *mixinnet.Transaction, views []mixinnet.Key, index uint16) error {
+	hash, err := tx.TransactionHash()
+	if err != nil {
+		return err
+	}
+	raw, err := tx.Dump()
+	if err != nil {
+		return err
+	}
+
+	var resp signResponse
+	if err := s.do(ctx, http.MethodPost, signerPathSign, &signRequest{RawTransaction: raw, Views: views, Index: index}, &resp); err != nil {
+		return err
+	}
+
+	signed, err := mixinnet.TransactionFromRaw(resp.RawTransaction)
+	if err != nil {
+		return fmt.Errorf("decode signed transaction: %w", err)
+	}
+	if signedHash, err := signed.TransactionHash(); err != nil || signedHash != hash {
+		return ErrSignerMismatch
+	}
+	tx.Signatures = signed.Signatures
+	return nil
+}
+
+// PublicKey 返回签名进程的 spend 公钥
+func (s *RemoteSigner) PublicKey(ctx context.Context) (mixinnet.Key, error) {
+	var resp signResponse
+	if err := s.do(ctx, http.MethodGet, signerPathPublicKey, nil, &resp); err != nil {
+		return mixinnet.Key{}, err
+	}
+	return mixinnet.KeyFromString(resp.PublicKey)
+}
+
+func (s *RemoteSigner) do(ctx context.Context, method, path string, body any, resp *signResponse) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(HeaderContentType, ContentTypeJSON)
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	httpResp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, signerMaxBodySize)).Decode(resp); err != nil {
+		return fmt.Errorf("signer: status %d: %w", httpResp.StatusCode, err)
+	}
+	switch {
+	case httpResp.StatusCode == http.StatusUnauthorized:
+		return ErrSignerUnauthorized
+	case httpResp.StatusCode != http.StatusOK || resp.Error != "":
+		return fmt.Errorf("signer: status %d: %s", httpResp.StatusCode, resp.Error)
+	}
+	return nil
+}
+
+// SignHook 签名前检查交易, 返回错误时拒绝签名
+type SignHook func(ctx context.Context, tx *mixinnet.Transaction) error
+
+// SignerServer 签名进程的 HTTP 服务, 实现
+//
+//	POST /sign        {"raw_transaction", "views", "index"} -> {"raw_transaction"}
+//	GET  /public_key  -> {"public_key"}
+type SignerServer struct {
+	signer TransactionSigner
+	token  string
+	hooks  []SignHook
+}
+
+// SignerServerOption 定义 SignerServer 选项
+type SignerServerOption func(*SignerServer)
+
+// WithSignerServerToken 只接受携带 Authorization: Bearer token 的请求, NewSignerServer 要求必须设置
+func WithSignerServerToken(token string) SignerServerOption {
+	return func(s *SignerServer) {
+		s.token = token
+	}
+}
+
+// WithSignHook 签名前调用 hook 检查交易, 如限制输出的数量和金额
+func WithSignHook(hook SignHook) SignerServerOption {
+	return func(s *SignerServer) {
+		s.hooks = append(s.hooks, hook)
+	}
+}
+
+// NewSignerServer 创建签名服务, 没有 WithSignerServerToken 时返回 ErrSignerNoToken;
+// 否则任何能连接 socket 或端口的进程都可以请求签名
+func NewSignerServer(signer TransactionSigner, opts ...SignerServerOption) (*SignerServer, error) {
+	s := &SignerServer{signer: signer}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.token == "" {
+		return nil, ErrSignerNoToken
+	}
+	return s, nil
+}
+
+func (s *SignerServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// 没有 token 的 SignerServer 不接受任何请求
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if s.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		writeSignerResponse(w, http.StatusUnauthorized, signResponse{Error: "unauthorized"})
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == signerPathSign:
+		s.handleSign(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == signerPathPublicKey:
+		pub, ok := s.signer.(interface{ PublicKey() mixinnet.Key })
+		if !ok {
+			writeSignerResponse(w, http.StatusNotFound, signResponse{Error: "public key not available"})
+			return
+		}
+		writeSignerResponse(w, http.StatusOK, signResponse{PublicKey: pub.PublicKey().String()})
+	default:
+		writeSignerResponse(w, http.StatusNotFound, signResponse{Error: "not found"})
+	}
+}
+
+func (s *SignerServer) handleSign(w http.ResponseWriter, r *http.Request) {
+	var req signRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, signerMaxBodySize)).Decode(&req); err != nil {
+		writeSignerResponse(w, http.StatusBadRequest, signResponse{Error: err.Error()})
+		return
+	}
+
+	tx, err := mixinnet.TransactionFromRaw(req.RawTransaction)
+	if err != nil {
+		writeSignerResponse(w, http.StatusBadRequest, signResponse{Error: "invalid raw transaction: " + err.Error()})
+		return
+	}
+	if len(req.Views) != len(tx.Inputs) {
+		writeSignerResponse(w, http.StatusBadRequest, signResponse{Error: fmt.Sprintf("expect %d views, got %d", len(tx.Inputs), len(req.Views))})
+		return
+	}
+
+	for _, hook := range s.hooks {
+		if err := hook(r.Context(), tx); err != nil {
+			writeSignerResponse(w, http.StatusForbidden, signResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	if err := s.signer.SignTransaction(r.Context(), tx, req.Views, req.Index); err != nil {
+		writeSignerResponse(w, http.StatusInternalServerError, signResponse{Error: err.Error()})
+		return
+	}
+	raw, err := tx.Dump()
+	if err != nil {
+		writeSignerResponse(w, http.StatusInternalServerError, signResponse{Error: err.Error()})
+		return
+	}
+	writeSignerResponse(w, http.StatusOK, signResponse{RawTransaction: raw})
+}
+
+func writeSignerResponse(w http.ResponseWriter, status int, resp signResponse) {
+	w.Header().Set(HeaderContentType, ContentTypeJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// ListenSigner 监听 unix socket 或 tcp 地址; unix socket 会先删除残留的文件,
+// 在只有当前用户可以访问的临时目录中创建并设置为 0600 后再移动到 address, 不存在其他用户可以连接的窗口
+func ListenSigner(network, address string) (net.Listener, error) {
+	if network != "unix" {
+		return net.Listen(network, address)
+	}
+
+	if err := os.Remove(address); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	// MkdirTemp 创建的目录权限为 0700
+	dir, err := os.MkdirTemp(filepath.Dir(address), ".signer-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "signer.sock")
+	l, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, 0o600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmp, address); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return &signerListener{Listener: l, path: address}, nil
+}
+
+// signerListener 关闭时删除 unix socket 文件
+type signerListener struct {
+	net.Listener
+	path string
+}
+
+func (l *signerListener) Close() error {
+	err := l.Listener.Close()
+	if rerr := os.Remove(l.path); rerr != nil && !errors.Is(rerr, os.ErrNotExist) && err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,171 @@
+package kit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"github.com/shopspring/decimal"
+)
+
+// startSigner 在 unix socket 上启动签名进程, 返回 socket 路径
+func startSigner(t *testing.T, spendKey string, opts ...kit.SignerServerOption) string {
+	t.Helper()
+
+	key, err := mixinnet.KeyFromString(spendKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unix socket 路径有长度限制, 不使用 t.TempDir
+	dir, err := os.MkdirTemp("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "signer.sock")
+
+	l, err := kit.ListenSigner("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := kit.NewSignerServer(kit.SpendKeySigner{Key: key}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: handler}
+	go server.Serve(l)
+	t.Cleanup(func() { server.Close() })
+	return socket
+}
+
+func TestRemoteSigner_TransferOne(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	socket := startSigner(t, server.Config.SpendKey, kit.WithSignerServerToken("secret"))
+
+	// 当前进程不持有 spend key
+	config := *server.Config
+	config.SpendKey = ""
+	signer := kit.NewRemoteSigner("unix", socket, kit.WithSignerToken("secret"))
+	client := server.NewBotClientWrapper(t, &config, kit.WithTransactionSigner(signer))
+
+	ctx := context.Background()
+	pub, err := signer.PublicKey(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.String() != server.User.SpendPublicKey {
+		t.Errorf("PublicKey() = %s, want %s", pub, server.User.SpendPublicKey)
+	}
+
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+	if _, err := client.TransferOne(ctx, &kit.TransferOneRequest{
+		RequestId: mixin.RandomTraceID(),
+		AssetId:   testPoolAsset,
+		Member:    testPoolRecipient,
+		Amount:    decimal.NewFromInt(4),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got := server.Balance(testPoolAsset, testPoolRecipient); !got.Equal(decimal.NewFromInt(4)) {
+		t.Errorf("recipient balance = %s, want 4", got)
+	}
+
+	if _, err := kit.NewRemoteSigner("unix", socket).PublicKey(ctx); !errors.Is(err, kit.ErrSignerUnauthorized) {
+		t.Errorf("PublicKey() without token error = %v, want ErrSignerUnauthorized", err)
+	}
+}
+
+func TestRemoteSigner_SignHook(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	errTooManyOutputs := errors.New("too many outputs")
+	socket := startSigner(t, server.Config.SpendKey, kit.WithSignerServerToken("secret"), kit.WithSignHook(func(ctx context.Context, tx *mixinnet.Transaction) error {
+		if len(tx.Outputs) > 1 {
+			return errTooManyOutputs
+		}
+		return nil
+	}))
+
+	client := server.NewClientWrapper(t, kit.WithTransactionSigner(kit.NewRemoteSigner("unix", socket, kit.WithSignerToken("secret"))))
+	server.Deposit(testPoolAsset, decimal.NewFromInt(10))
+
+	// 找零输出使交易有两个输出, 签名进程拒绝签名
+	_, err := client.TransferOne(context.Background(), &kit.TransferOneRequest{
+		RequestId: mixin.RandomTraceID(),
+		AssetId:   testPoolAsset,
+		Member:    testPoolRecipient,
+		Amount:    decimal.NewFromInt(4),
+	})
+	if err == nil {
+		t.Fatal("TransferOne() should be rejected by sign hook")
+	}
+	if got := server.Balance(testPoolAsset); !got.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("balance = %s, want 10", got)
+	}
+}
+
+func TestListenSigner(t *testing.T) {
+	dir, err := os.MkdirTemp("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "signer.sock")
+
+	// 残留的文件先删除
+	if err := os.WriteFile(socket, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := kit.ListenSigner("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %s, want 0600 socket", info.Mode())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("dir has %d entries, want only the socket", len(entries))
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(socket); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("socket not removed after Close: %v", err)
+	}
+}
+
+func TestSignerServer_Unauthenticated(t *testing.T) {
+	key, err := mixinnet.KeyFromString(kittest.NewSafeServer(t).Config.SpendKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kit.NewSignerServer(kit.SpendKeySigner{Key: key}); !errors.Is(err, kit.ErrSignerNoToken) {
+		t.Errorf("NewSignerServer() without token error = %v, want ErrSignerNoToken", err)
+	}
+
+	server, err := kit.NewSignerServer(kit.SpendKeySigner{Key: key}, kit.WithSignerServerToken("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 零值的 SignerServer 没有 token, 也不接受请求
+	for name, handler := range map[string]http.Handler{"server": server, "zero value": &kit.SignerServer{}} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/public_key", nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", name, rec.Code, http.StatusUnauthorized)
+		}
+	}
+}
@@ -0,0 +1,562 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/fox-one/mixin-sdk-go/v2/mixinnet"
+	"github.com/shopspring/decimal"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	ErrWalletExists      = errors.New("wallet already exists")
+	ErrWalletNotFound    = errors.New("wallet not found")
+	ErrNoWalletAvailable = errors.New("no wallet has enough balance")
+)
+
+const (
+	RebalanceMemo = "rebalance"
+
+	DefaultWalletConcurrency = 8
+)
+
+// WalletTier 钱包类型, 冷钱包只作为 Rebalance 的资金来源, 不参与 WalletPolicy 的选择
+type WalletTier string
+
+const (
+	WalletTierHot  WalletTier = "hot"
+	WalletTierCold WalletTier = "cold"
+)
+
+// Wallet WalletPool 中的一个机器人
+type Wallet struct {
+	*ClientWrapper
+
+	Name   string
+	Tier   WalletTier
+	Assets []string // 只处理这些资产, 为空时处理所有资产
+
+	// 由 WalletPool.mu 保护
+	pending  map[string]decimal.Decimal // asset id -> 进行中的转账金额
+	inflight int
+}
+
+// WalletOption 定义 Wallet 选项
+type WalletOption func(*Wallet)
+
+// WithWalletTier 设置钱包类型, 默认为热钱包
+func WithWalletTier(tier WalletTier) WalletOption {
+	return func(w *Wallet) {
+		w.Tier = tier
+	}
+}
+
+// WithWalletAssets 钱包只处理 assets 的转账
+func WithWalletAssets(assets ...string) WalletOption {
+	return func(w *Wallet) {
+		w.Assets = assets
+	}
+}
+
+// Supports 钱包是否处理 assetId 的转账
+func (w *Wallet) Supports(assetId string) bool {
+	return len(w.Assets) == 0 || slices.Contains(w.Assets, assetId)
+}
+
+// WalletCandidate 选择转账钱包时的候选
+type WalletCandidate struct {
+	Wallet *Wallet
+	// Balance 可用余额, 已扣除进行中的转账
+	Balance  decimal.Decimal
+	Inflight int
+}
+
+// WalletPolicy 从 candidates 中选择转账的钱包, candidates 均为支持该资产且可用余额不少于 amount 的热钱包.
+// 没有合适的钱包时返回 ErrNoWalletAvailable
+type WalletPolicy func(assetId string, amount decimal.Decimal, candidates []WalletCandidate) (*Wallet, error)
+
+// LeastLoadedPolicy 选择进行中转账最少的钱包, 相同时选择余额最多的
+func LeastLoadedPolicy() WalletPolicy {
+	return func(assetId string, amount decimal.Decimal, candidates []WalletCandidate) (*Wallet, error) {
+		if len(candidates) == 0 {
+			return nil, ErrNoWalletAvailable
+		}
+		best := candidates[0]
+		for _, c := range candidates[1:] {
+			if c.Inflight < best.Inflight || (c.Inflight == best.Inflight && c.Balance.GreaterThan(best.Balance)) {
+				best = c
+			}
+		}
+		return best.Wallet, nil
+	}
+}
+
+// MostBalancePolicy 选择可用余额最多的钱包
+func MostBalancePolicy() WalletPolicy {
+	return func(assetId string, amount decimal.Decimal, candidates []WalletCandidate) (*Wallet, error) {
+		if len(candidates) == 0 {
+			return nil, ErrNoWalletAvailable
+		}
+		best := candidates[0]
+		for _, c := range candidates[1:] {
+			if c.Balance.GreaterThan(best.Balance) {
+				best = c
+			}
+		}
+		return best.Wallet, nil
+	}
+}
+
+// HotColdPolicy 只从热钱包中按 next 选择, 冷钱包不直接转出.
+// WalletPool 传给策略的候选已经不含冷钱包, 用于在其他场合复用同样的规则
+func HotColdPolicy(next WalletPolicy) WalletPolicy {
+	return func(assetId string, amount decimal.Decimal, candidates []WalletCandidate) (*Wallet, error) {
+		hot := make([]WalletCandidate, 0, len(candidates))
+		for _, c := range candidates {
+			if c.Wallet.Tier != WalletTierCold {
+				hot = append(hot, c)
+			}
+		}
+		return next(assetId, amount, hot)
+	}
+}
+
+// WalletPool 管理多个机器人, 按策略选择转账的钱包, 并在钱包之间调拨资金
+type WalletPool struct {
+	policy      WalletPolicy
+	concurrency int
+
+	mu      sync.Mutex
+	wallets []*Wallet
+}
+
+// WalletPoolOption 定义 WalletPool 选项
+type WalletPoolOption func(*WalletPool)
+
+// WithWalletPolicy 设置选择转账钱包的策略, 默认为 LeastLoadedPolicy
+func WithWalletPolicy(policy WalletPolicy) WalletPoolOption {
+	return func(p *WalletPool) {
+		p.policy = policy
+	}
+}
+
+// WithWalletConcurrency 设置查询余额时的并发数
+func WithWalletConcurrency(concurrency int) WalletPoolOption {
+	return func(p *WalletPool) {
+		if concurrency > 0 {
+			p.concurrency = concurrency
+		}
+	}
+}
+
+func NewWalletPool(opts ...WalletPoolOption) *WalletPool {
+	p := &WalletPool{
+		policy:      LeastLoadedPolicy(),
+		concurrency: DefaultWalletConcurrency,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Add 添加钱包, name 为空时使用机器人的 ClientID
+func (p *WalletPool) Add(name string, client *ClientWrapper, opts ...WalletOption) (*Wallet, error) {
+	if name == "" {
+		name = client.ClientID
+	}
+	w := &Wallet{
+		ClientWrapper: client,
+		Name:          name,
+		Tier:          WalletTierHot,
+		pending:       map[string]decimal.Decimal{},
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, other := range p.wallets {
+		if other.Name == w.Name {
+			return nil, fmt.Errorf("%w: %s", ErrWalletExists, w.Name)
+		}
+	}
+	p.wallets = append(p.wallets, w)
+	return w, nil
+}
+
+// LoadDir 加载 dir 中所有的 keystore 文件 (json, toml, yaml), 文件名 (不含扩展名) 作为钱包名称
+func (p *WalletPool) LoadDir(dir string, tier WalletTier, opts ...ClientWrapperOption) ([]*Wallet, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var wallets []*Wallet
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		switch strings.ToLower(ext) {
+		case ".json", ".toml", ".yaml", ".yml":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		config, err := LoadConfigFile(path)
+		if err != nil {
+			return wallets, fmt.Errorf("load %s: %w", path, err)
+		}
+		client, err := NewMixinClientWrapper(config, opts...)
+		if err != nil {
+			return wallets, fmt.Errorf("load %s: %w", path, err)
+		}
+		w, err := p.Add(strings.TrimSuffix(entry.Name(), ext), client, WithWalletTier(tier))
+		if err != nil {
+			return wallets, err
+		}
+		wallets = append(wallets, w)
+	}
+	return wallets, nil
+}
+
+// Wallet 按名称查找钱包
+func (p *WalletPool) Wallet(name string) (*Wallet, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, w := range p.wallets {
+		if w.Name == name {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrWalletNotFound, name)
+}
+
+// Wallets 返回所有钱包
+func (p *WalletPool) Wallets() []*Wallet {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.wallets)
+}
+
+// WalletBalance 钱包的链上余额
+type WalletBalance struct {
+	Wallet   *Wallet
+	Balances map[string]decimal.Decimal // asset id -> 余额
+}
+
+// Balances 并发查询所有支持 assetId 的钱包余额, assetId 为空时查询所有钱包的所有资产
+func (p *WalletPool) Balances(ctx context.Context, assetId string) ([]WalletBalance, error) {
+	var wallets []*Wallet
+	for _, w := range p.Wallets() {
+		if assetId == "" || w.Supports(assetId) {
+			wallets = append(wallets, w)
+		}
+	}
+
+	balances := make([]WalletBalance, len(wallets))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(p.concurrency)
+	for i, w := range wallets {
+		g.Go(func() error {
+			b, err := w.Balances(ctx, assetId)
+			if err != nil {
+				return fmt.Errorf("wallet %s: %w", w.Name, err)
+			}
+			balances[i] = WalletBalance{Wallet: w, Balances: b}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// TotalBalances 所有钱包的余额合计, asset id -> 余额; assetId 为空时统计所有资产
+func (p *WalletPool) TotalBalances(ctx context.Context, assetId string) (map[string]decimal.Decimal, error) {
+	balances, err := p.Balances(ctx, assetId)
+	if err != nil {
+		return nil, err
+	}
+
+	total := make(map[string]decimal.Decimal)
+	for _, b := range balances {
+		for asset, amount := range b.Balances {
+			total[asset] = total[asset].Add(amount)
+		}
+	}
+	return total, nil
+}
+
+// candidates 返回可用余额不少于 amount 的热钱包, 调用方需持有 p.mu
+func (p *WalletPool) candidates(balances []WalletBalance, assetId string, amount decimal.Decimal) []WalletCandidate {
+	candidates := make([]WalletCandidate, 0, len(balances))
+	for _, b := range balances {
+		if b.Wallet.Tier == WalletTierCold {
+			continue
+		}
+		available := b.Balances[assetId].Sub(b.Wallet.pending[assetId])
+		if available.LessThan(amount) {
+			continue
+		}
+		candidates = append(candidates, WalletCandidate{
+			Wallet:   b.Wallet,
+			Balance:  available,
+			Inflight: b.Wallet.inflight,
+		})
+	}
+	return candidates
+}
+
+// reserve 按策略选择钱包并预留 amount, 转账结束后调用返回的 release
+func (p *WalletPool) reserve(ctx context.Context, assetId string, amount decimal.Decimal) (*Wallet, func(), error) {
+	balances, err := p.Balances(ctx, assetId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w, err := p.policy(assetId, amount, p.candidates(balances, assetId, amount))
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, p.hold(w, assetId, amount), nil
+}
+
+// hold 预留 w 的 amount, 返回 release; 调用方需持有 p.mu
+func (p *WalletPool) hold(w *Wallet, assetId string, amount decimal.Decimal) func() {
+	w.pending[assetId] = w.pending[assetId].Add(amount)
+	w.inflight++
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		w.pending[assetId] = w.pending[assetId].Sub(amount)
+		w.inflight--
+	}
+}
+
+// Select 按策略选择一个可以转出 amount 的钱包, 不预留余额
+func (p *WalletPool) Select(ctx context.Context, assetId string, amount decimal.Decimal) (*Wallet, error) {
+	w, release, err := p.reserve(ctx, assetId, amount)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	return w, nil
+}
+
+// TransferOne 按策略选择钱包并转账, 返回实际转出的钱包
+func (p *WalletPool) TransferOne(ctx context.Context, req *TransferOneRequest) (*Wallet, *mixin.SafeTransactionRequest, error) {
+	w, release, err := p.reserve(ctx, req.AssetId, req.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	request, err := w.TransferOne(ctx, req)
+	return w, request, err
+}
+
+// TransferMany 按策略选择一个余额足够支付所有 MemberAmount 的钱包并转账
+func (p *WalletPool) TransferMany(ctx context.Context, req *TransferManyRequest) (*Wallet, *mixin.SafeTransactionRequest, error) {
+	amount := decimal.Zero
+	for _, ma := range req.MemberAmount {
+		amount = amount.Add(ma.Amount)
+	}
+
+	w, release, err := p.reserve(ctx, req.AssetId, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	request, err := w.TransferMany(ctx, req)
+	return w, request, err
+}
+
+// RebalanceRule 热钱包 AssetId 的可用余额低于 Min 时, 从其他钱包补充到 Target.
+// 每笔调拨的 RequestId 由 rule, 调出和调入的钱包以及 RequestId 派生, 同一个 RequestId 下每对钱包只调拨一次,
+// 中断后使用相同的 RequestId 重新执行不会重复调拨; RequestId 为空时使用当前的 UTC 小时
+type RebalanceRule struct {
+	AssetId   string
+	Min       decimal.Decimal
+	Target    decimal.Decimal
+	RequestId string
+}
+
+// requestId 对 JSON 编码后的各部分取 uuid, 避免直接拼接时不同的 Min 和 Target 得到相同的请求 id
+func (rule RebalanceRule) requestId(epoch, from, to string) string {
+	b, _ := json.Marshal([]string{RebalanceMemo, rule.AssetId, rule.Min.String(), rule.Target.String(), from, to, epoch})
+	return GenUuidFromStrings(string(b))
+}
+
+// RebalanceTransfer Rebalance 发出的一笔调拨
+type RebalanceTransfer struct {
+	From    *Wallet
+	To      *Wallet
+	AssetId string
+	Amount  decimal.Decimal
+	Request *mixin.SafeTransactionRequest
+}
+
+// Rebalance 按 rule 在钱包之间调拨资金. 资金优先来自冷钱包, 其次是余额超过 Target 的热钱包的超出部分;
+// 调出的金额在结束前一直预留, 不会被 TransferOne 等同时选中. 出错时返回已经完成的调拨
+func (p *WalletPool) Rebalance(ctx context.Context, rule RebalanceRule) ([]RebalanceTransfer, error) {
+	if rule.Target.LessThan(rule.Min) {
+		return nil, fmt.Errorf("rebalance target %s less than min %s", rule.Target, rule.Min)
+	}
+	epoch := rule.RequestId
+	if epoch == "" {
+		epoch = time.Now().UTC().Format("2006-01-02T15")
+	}
+
+	balances, err := p.Balances(ctx, rule.AssetId)
+	if err != nil {
+		return nil, err
+	}
+
+	type entry struct {
+		wallet *Wallet
+		amount decimal.Decimal
+	}
+	var receivers, donors []entry
+	var releases []func()
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+
+	p.mu.Lock()
+	for _, b := range balances {
+		w := b.Wallet
+		available := b.Balances[rule.AssetId].Sub(w.pending[rule.AssetId])
+		switch {
+		case w.Tier == WalletTierCold:
+			if available.IsPositive() {
+				donors = append(donors, entry{w, available})
+			}
+		case available.LessThan(rule.Min):
+			receivers = append(receivers, entry{w, rule.Target.Sub(available)})
+		case available.GreaterThan(rule.Target):
+			donors = append(donors, entry{w, available.Sub(rule.Target)})
+		}
+	}
+	for _, d := range donors {
+		releases = append(releases, p.hold(d.wallet, rule.AssetId, d.amount))
+	}
+	p.mu.Unlock()
+
+	// 冷钱包优先, 同类型按可调拨金额从大到小
+	sort.SliceStable(donors, func(i, j int) bool {
+		ci, cj := donors[i].wallet.Tier == WalletTierCold, donors[j].wallet.Tier == WalletTierCold
+		if ci != cj {
+			return ci
+		}
+		return donors[i].amount.GreaterThan(donors[j].amount)
+	})
+
+	var transfers []RebalanceTransfer
+	for _, r := range receivers {
+		for i := range donors {
+			if !r.amount.IsPositive() {
+				break
+			}
+			d := &donors[i]
+			if !d.amount.IsPositive() {
+				continue
+			}
+
+			request, amount, done, err := d.wallet.rebalance(ctx, &TransferOneRequest{
+				RequestId: rule.requestId(epoch, d.wallet.ClientID, r.wallet.ClientID),
+				AssetId:   rule.AssetId,
+				Member:    r.wallet.ClientID,
+				Amount:    decimal.Min(r.amount, d.amount),
+				Memo:      RebalanceMemo,
+			})
+			if err != nil {
+				return transfers, fmt.Errorf("rebalance %s -> %s: %w", d.wallet.Name, r.wallet.Name, err)
+			}
+			if done {
+				// 之前已经调拨过, 不再重复
+				continue
+			}
+
+			transfers = append(transfers, RebalanceTransfer{
+				From:    d.wallet,
+				To:      r.wallet,
+				AssetId: rule.AssetId,
+				Amount:  amount,
+				Request: request,
+			})
+			d.amount = d.amount.Sub(amount)
+			r.amount = r.amount.Sub(amount)
+		}
+	}
+	return transfers, nil
+}
+
+// rebalance 转出 req, 返回实际转出的金额; 请求已创建但没有提交时继续签名提交,
+// 金额以创建时的交易为准, 可能与 req.Amount 不同; 已经提交过时 done 为 true
+func (w *Wallet) rebalance(ctx context.Context, req *TransferOneRequest) (_ *mixin.SafeTransactionRequest, amount decimal.Decimal, done bool, err error) {
+	request, err := w.readRequest(ctx, req.RequestId)
+	switch {
+	case mixin.IsErrorCodes(err, mixin.EndpointNotFound):
+		request, err = w.TransferOne(ctx, req)
+		return request, req.Amount, false, err
+	case err != nil:
+		return nil, decimal.Zero, false, err
+	case request.State == mixin.SafeUtxoStateUnspent:
+		if amount, err = requestAmount(request, req.Member); err != nil {
+			return nil, decimal.Zero, false, err
+		}
+		request, _, err = w.resumeRequest(ctx, request, req.AssetId, req.Memo, true)
+		return request, amount, false, err
+	default:
+		return request, decimal.Zero, true, nil
+	}
+}
+
+// requestAmount 请求的交易中转给 member 的金额
+func requestAmount(request *mixin.SafeTransactionRequest, member string) (decimal.Decimal, error) {
+	tx, err := mixinnet.TransactionFromRaw(request.RawTransaction)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if len(tx.Outputs) != len(request.Receivers) {
+		return decimal.Zero, fmt.Errorf("request %s: %d outputs, %d receivers", request.RequestID, len(tx.Outputs), len(request.Receivers))
+	}
+
+	amount := decimal.Zero
+	for i, r := range request.Receivers {
+		if r.Threshold == 1 && len(r.Members) == 1 && r.Members[0] == member {
+			amount = amount.Add(decimal.RequireFromString(tx.Outputs[i].Amount.String()))
+		}
+	}
+	return amount, nil
+}
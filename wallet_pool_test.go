@@ -0,0 +1,190 @@
+package kit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kit "github.com/DomeLiquid/mixin-kit-go"
+	"github.com/DomeLiquid/mixin-kit-go/kittest"
+	"github.com/fox-one/mixin-sdk-go/v2"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	testPoolAsset     = "965e5c6e-434c-3fa9-b780-c50f43cd955c"
+	testPoolRecipient = "e9e5b807-fa8b-455a-8dfa-b189d28310ff"
+)
+
+func newTestWalletPool(t *testing.T, server *kittest.SafeServer, opts ...kit.WalletPoolOption) *kit.WalletPool {
+	t.Helper()
+
+	pool := kit.NewWalletPool(opts...)
+	wallets := []struct {
+		name string
+		tier kit.WalletTier
+		cfg  *kit.Config
+	}{
+		{"hot-1", kit.WalletTierHot, server.Config},
+		{"hot-2", kit.WalletTierHot, server.AddBot(t)},
+		{"cold", kit.WalletTierCold, server.AddBot(t)},
+	}
+	for _, w := range wallets {
+		client := server.NewBotClientWrapper(t, w.cfg)
+		if _, err := pool.Add(w.name, client, kit.WithWalletTier(w.tier)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := pool.Add("hot-1", nil); !errors.Is(err, kit.ErrWalletExists) {
+		t.Errorf("Add(duplicated) error = %v", err)
+	}
+	return pool
+}
+
+func deposit(t *testing.T, server *kittest.SafeServer, pool *kit.WalletPool, name string, amount int64) {
+	t.Helper()
+
+	w, err := pool.Wallet(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Deposit(testPoolAsset, decimal.NewFromInt(amount), w.ClientID)
+}
+
+func TestWalletPool_TransferOne(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	pool := newTestWalletPool(t, server, kit.WithWalletPolicy(kit.HotColdPolicy(kit.MostBalancePolicy())))
+	deposit(t, server, pool, "hot-1", 3)
+	deposit(t, server, pool, "hot-2", 8)
+	deposit(t, server, pool, "cold", 100)
+
+	ctx := context.Background()
+	w, _, err := pool.TransferOne(ctx, &kit.TransferOneRequest{
+		RequestId: mixin.RandomTraceID(),
+		AssetId:   testPoolAsset,
+		Member:    testPoolRecipient,
+		Amount:    decimal.NewFromInt(5),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "hot-2" {
+		t.Errorf("TransferOne() used %s, want hot-2", w.Name)
+	}
+
+	// 热钱包余额都不足, 冷钱包不直接转出
+	_, _, err = pool.TransferOne(ctx, &kit.TransferOneRequest{
+		RequestId: mixin.RandomTraceID(),
+		AssetId:   testPoolAsset,
+		Member:    testPoolRecipient,
+		Amount:    decimal.NewFromInt(4),
+	})
+	if !errors.Is(err, kit.ErrNoWalletAvailable) {
+		t.Errorf("TransferOne() error = %v, want ErrNoWalletAvailable", err)
+	}
+
+	total, err := pool.TotalBalances(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := decimal.NewFromInt(106); !total[testPoolAsset].Equal(want) {
+		t.Errorf("TotalBalances() = %s, want %s", total[testPoolAsset], want)
+	}
+}
+
+func TestWalletPool_SelectSkipsCold(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	pool := newTestWalletPool(t, server)
+	deposit(t, server, pool, "hot-1", 3)
+	deposit(t, server, pool, "cold", 100)
+
+	// 默认的 LeastLoadedPolicy 会选择余额最多的钱包, 冷钱包不参与选择
+	ctx := context.Background()
+	w, err := pool.Select(ctx, testPoolAsset, decimal.NewFromInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "hot-1" {
+		t.Errorf("Select() = %s, want hot-1", w.Name)
+	}
+	if _, err := pool.Select(ctx, testPoolAsset, decimal.NewFromInt(50)); !errors.Is(err, kit.ErrNoWalletAvailable) {
+		t.Errorf("Select() error = %v, want ErrNoWalletAvailable", err)
+	}
+}
+
+func TestWalletPool_Rebalance(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	pool := newTestWalletPool(t, server)
+	deposit(t, server, pool, "hot-1", 2)
+	deposit(t, server, pool, "hot-2", 30)
+	deposit(t, server, pool, "cold", 5)
+
+	transfers, err := pool.Rebalance(context.Background(), kit.RebalanceRule{
+		AssetId: testPoolAsset,
+		Min:     decimal.NewFromInt(10),
+		Target:  decimal.NewFromInt(20),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// hot-1 需要 18: 冷钱包 5, hot-2 超出 Target 的 10
+	if len(transfers) != 2 {
+		t.Fatalf("Rebalance() = %d transfers, want 2", len(transfers))
+	}
+	if transfers[0].From.Name != "cold" || !transfers[0].Amount.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("transfers[0] = %s %s", transfers[0].From.Name, transfers[0].Amount)
+	}
+	if transfers[1].From.Name != "hot-2" || !transfers[1].Amount.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("transfers[1] = %s %s", transfers[1].From.Name, transfers[1].Amount)
+	}
+
+	hot1, _ := pool.Wallet("hot-1")
+	if got := server.Balance(testPoolAsset, hot1.ClientID); !got.Equal(decimal.NewFromInt(17)) {
+		t.Errorf("hot-1 balance = %s, want 17", got)
+	}
+}
+
+func TestWalletPool_RebalanceResume(t *testing.T) {
+	server := kittest.NewSafeServer(t)
+	pool := newTestWalletPool(t, server)
+	deposit(t, server, pool, "hot-1", 2)
+	deposit(t, server, pool, "hot-2", 30)
+	deposit(t, server, pool, "cold", 5)
+
+	rule := kit.RebalanceRule{
+		AssetId:   testPoolAsset,
+		Min:       decimal.NewFromInt(10),
+		Target:    decimal.NewFromInt(20),
+		RequestId: mixin.RandomTraceID(),
+	}
+
+	// 冷钱包的调拨已创建, 提交失败
+	ctx := context.Background()
+	server.FailNext("POST", "/safe/transactions", 500, "timeout")
+	if transfers, err := pool.Rebalance(ctx, rule); err == nil || len(transfers) != 0 {
+		t.Fatalf("Rebalance() = %d transfers, %v, want submit error", len(transfers), err)
+	}
+
+	// 预留的金额已释放
+	if _, err := pool.Select(ctx, testPoolAsset, decimal.NewFromInt(30)); err != nil {
+		t.Errorf("Select() after failed rebalance: %v", err)
+	}
+
+	// 冷钱包的余额变化后, 继续提交的仍然是创建时的 5
+	deposit(t, server, pool, "cold", 3)
+	transfers, err := pool.Rebalance(ctx, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 2 || transfers[0].From.Name != "cold" || transfers[0].Request.State != mixin.SafeUtxoStateSpent {
+		t.Fatalf("Rebalance() = %+v", transfers)
+	}
+	if !transfers[0].Amount.Equal(decimal.NewFromInt(5)) || !transfers[1].Amount.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("transfers = %s, %s, want 5, 10", transfers[0].Amount, transfers[1].Amount)
+	}
+	hot1, _ := pool.Wallet("hot-1")
+	if got := server.Balance(testPoolAsset, hot1.ClientID); !got.Equal(decimal.NewFromInt(17)) {
+		t.Errorf("hot-1 balance = %s, want 17", got)
+	}
+}
@@ -0,0 +1,151 @@
+package kit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Web3Response Route 接口统一的返回格式, 部分接口在 200 时也会返回 error
+type Web3Response[T any] struct {
+	Data       T              `json:"data"`
+	Error      *Web3ErrorBody `json:"error,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type Web3ErrorBody struct {
+	Status      int    `json:"status"`
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+}
+
+// DoJSON 发送请求并解开 data; 响应中包含 error 时返回 MixinOracleAPIError
+func DoJSON[T any](ctx context.Context, c Web3Client, method, path, query string, body any) (T, error) {
+	resp, err := doWeb3Response[T](ctx, c, method, path, query, body)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return resp.Data, nil
+}
+
+// GetJSON 见 DoJSON
+func GetJSON[T any](ctx context.Context, c Web3Client, path, query string) (T, error) {
+	return DoJSON[T](ctx, c, http.MethodGet, path, query, nil)
+}
+
+// PostJSON 见 DoJSON
+func PostJSON[T any](ctx context.Context, c Web3Client, path string, body any) (T, error) {
+	return DoJSON[T](ctx, c, http.MethodPost, path, "", body)
+}
+
+func doWeb3Response[T any](ctx context.Context, c Web3Client, method, path, query string, body any) (*Web3Response[T], error) {
+	var raw json.RawMessage
+	if err := c.DoRequest(ctx, method, path, query, body, &raw); err != nil {
+		return nil, err
+	}
+
+	var resp Web3Response[T]
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+	}
+
+	if e := resp.Error; e != nil && (e.Code != 0 || e.Description != "") {
+		status := e.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		return nil, &MixinOracleAPIError{
+			StatusCode:  status,
+			Code:        e.Code,
+			Description: e.Description,
+			RawBody:     string(raw),
+		}
+	}
+	return &resp, nil
+}
+
+// Web3Pager 描述分页接口的游标
+type Web3Pager[T any] struct {
+	// CursorParam 游标的 query 参数名, 如 offset
+	CursorParam string
+	// Cursor 第一页的游标, 为空时不传
+	Cursor string
+
+	LimitParam string
+	Limit      int
+
+	// Next 根据本页的游标和数据计算下一页的游标, 返回 false 表示没有更多数据.
+	// 为空时使用响应中的 next_cursor, next_cursor 为空即结束
+	Next func(cursor string, page []T) (string, bool)
+}
+
+// OffsetPager offset/limit 分页, 某页不足 limit 条时结束
+func OffsetPager[T any](limit int) Web3Pager[T] {
+	return Web3Pager[T]{
+		CursorParam: "offset",
+		Cursor:      "0",
+		LimitParam:  "limit",
+		Limit:       limit,
+		Next: func(cursor string, page []T) (string, bool) {
+			if len(page) == 0 || len(page) < limit {
+				return "", false
+			}
+			offset, _ := strconv.Atoi(cursor)
+			return strconv.Itoa(offset + len(page)), true
+		},
+	}
+}
+
+// GetPage 按 pager 获取 cursor 对应的一页, 返回数据和下一页游标, 游标为空表示没有更多数据
+func GetPage[T any](ctx context.Context, c Web3Client, path string, query url.Values, pager Web3Pager[T], cursor string) ([]T, string, error) {
+	q := url.Values{}
+	for k, vs := range query {
+		q[k] = append([]string(nil), vs...)
+	}
+	if cursor != "" {
+		q.Set(pager.CursorParam, cursor)
+	}
+	if pager.LimitParam != "" && pager.Limit > 0 {
+		q.Set(pager.LimitParam, strconv.Itoa(pager.Limit))
+	}
+
+	resp, err := doWeb3Response[[]T](ctx, c, http.MethodGet, path, q.Encode(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if pager.Next == nil {
+		return resp.Data, resp.NextCursor, nil
+	}
+	next, ok := pager.Next(cursor, resp.Data)
+	if !ok {
+		next = ""
+	}
+	return resp.Data, next, nil
+}
+
+// ListJSON 从 pager.Cursor 开始翻页获取全部数据
+func ListJSON[T any](ctx context.Context, c Web3Client, path string, query url.Values, pager Web3Pager[T]) ([]T, error) {
+	var (
+		items  []T
+		cursor = pager.Cursor
+	)
+	for {
+		page, next, err := GetPage(ctx, c, path, query, pager, cursor)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page...)
+
+		if next == "" || next == cursor {
+			return items, nil
+		}
+		cursor = next
+	}
+}
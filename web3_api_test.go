@@ -0,0 +1,88 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestGetJSON_ErrorEnvelope(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			fmt.Fprint(w, `{"data":{"symbol":"BTC"}}`)
+		case "/error":
+			fmt.Fprint(w, `{"error":{"status":202,"code":10002,"description":"invalid amount"}}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewWeb3Client(nil, WithBaseURL(srv.URL), WithRouteSigner(testRouteSigner(t)))
+	ctx := context.Background()
+
+	token, err := GetJSON[TokenView](ctx, c, "/ok", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Symbol != "BTC" {
+		t.Errorf("Symbol = %q, want BTC", token.Symbol)
+	}
+
+	var apiErr *MixinOracleAPIError
+	if _, err := GetJSON[TokenView](ctx, c, "/error", ""); !errors.As(err, &apiErr) || apiErr.Code != 10002 {
+		t.Errorf("GetJSON() error = %v, want API error 10002", err)
+	}
+}
+
+func TestListJSON(t *testing.T) {
+	const total = 5
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		fmt.Fprint(w, `{"data":[`)
+		for i := offset; i < offset+limit && i < total; i++ {
+			if i > offset {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"coin_id":"%d"}`, i)
+		}
+		fmt.Fprint(w, `]}`)
+	}))
+	defer srv.Close()
+
+	c := NewWeb3Client(nil, WithBaseURL(srv.URL), WithRouteSigner(testRouteSigner(t)))
+
+	markets, err := ListJSON(context.Background(), c, "/markets", nil, OffsetPager[MarketAssetInfo](2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(markets) != total || markets[total-1].CoinID != "4" {
+		t.Errorf("ListJSON() = %+v", markets)
+	}
+}
+
+func TestContextWithIdempotencyKey(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(HeaderIdempotencyKey))
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	c := NewWeb3Client(nil, WithBaseURL(srv.URL), WithRouteSigner(testRouteSigner(t)), WithRetry(1, time.Millisecond))
+
+	// 没有 key 的 POST 不重试, 带 key 时重试并且每次都携带
+	ctx := context.Background()
+	_ = c.DoRequest(ctx, http.MethodPost, "/web3/swap", "", struct{}{}, nil)
+	_ = c.DoRequest(ContextWithIdempotencyKey(ctx, "order-1"), http.MethodPost, "/web3/swap", "", struct{}{}, nil)
+	if want := []string{"", "order-1", "order-1"}; !slices.Equal(keys, want) {
+		t.Errorf("keys = %q, want %q", keys, want)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	bot "github.com/MixinNetwork/bot-api-go-client/v3"
@@ -18,9 +21,12 @@ const (
 	HeaderAccessTimestamp = "MR-ACCESS-TIMESTAMP"
 	HeaderAccessSign      = "MR-ACCESS-SIGN"
 	HeaderContentType     = "Content-Type"
+	HeaderIdempotencyKey  = "Idempotency-Key"
 	ContentTypeJSON       = "application/json"
 )
 
+const defaultWeb3Timeout = 10 * time.Second
+
 type HistoryPriceType uint8
 
 const (
@@ -48,116 +54,205 @@ type Web3Client interface {
 
 // web3ClientImpl 实现
 type web3ClientImpl struct {
-	baseURL   string
-	botClient *bot.BotAuthClient
-	clientID  string
-	client    *resty.Client
+	baseURL     string
+	timeout     time.Duration
+	httpClient  *http.Client
+	botClient   *bot.BotAuthClient
+	clientID    string
+	client      *resty.Client
+	middlewares []Web3Middleware
+	signer      *RouteSigner
 }
 
 // Web3ClientOption 定义客户端选项
 type Web3ClientOption func(*web3ClientImpl)
 
-// NewWeb3Client 创建客户端
+// NewWeb3Client 创建客户端, 未设置 WithRouteSigner 时使用 botClient 签名
 func NewWeb3Client(botClient *bot.BotAuthClient, opts ...Web3ClientOption) Web3Client {
 	client := &web3ClientImpl{
 		baseURL:   MixinRouteApiPrefix,
 		botClient: botClient,
 		clientID:  MixinRouteClientID,
-		client: resty.New().
-			SetBaseURL(MixinRouteApiPrefix).
-			SetHeader(HeaderContentType, ContentTypeJSON).
-			SetTimeout(10 * time.Second),
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	if client.httpClient != nil {
+		client.client = resty.NewWithClient(client.httpClient)
+	} else {
+		client.client = resty.New()
+		if client.timeout == 0 {
+			client.timeout = defaultWeb3Timeout
+		}
+	}
+	client.client.
+		SetBaseURL(client.baseURL).
+		SetHeader(HeaderContentType, ContentTypeJSON)
+	if client.timeout > 0 {
+		client.client.SetTimeout(client.timeout)
+	}
+
 	return client
 }
 
 func WithBaseURL(url string) Web3ClientOption {
 	return func(c *web3ClientImpl) {
 		c.baseURL = url
-		c.client.SetBaseURL(url)
 	}
 }
 
+// WithTimeout 设置请求超时, 未设置时默认 10s; 使用 WithHTTPClient 时默认沿用 http.Client 的超时
 func WithTimeout(timeout time.Duration) Web3ClientOption {
 	return func(c *web3ClientImpl) {
-		c.client.SetTimeout(timeout)
+		c.timeout = timeout
+	}
+}
+
+// WithHTTPClient 使用自定义的 http.Client 发送请求, 如自定义 Transport 或代理
+func WithHTTPClient(client *http.Client) Web3ClientOption {
+	return func(c *web3ClientImpl) {
+		c.httpClient = client
 	}
 }
 
+// WithRetry 失败 (网络错误或 5xx) 时最多重试 count 次, 每次重试都会重新签名;
+// 只重试 GET 和带 Idempotency-Key 头的请求, 见 ContextWithIdempotencyKey
 func WithRetry(count int, waitTime time.Duration) Web3ClientOption {
+	return WithMiddleware(RetryMiddleware(count, waitTime))
+}
+
+// WithRouteSigner 使用 RouteSigner 签名, 此时 botClient 可以为 nil
+func WithRouteSigner(signer *RouteSigner) Web3ClientOption {
+	return func(c *web3ClientImpl) {
+		c.signer = signer
+	}
+}
+
+// WithMiddleware 追加中间件, 先添加的中间件在外层
+func WithMiddleware(mws ...Web3Middleware) Web3ClientOption {
 	return func(c *web3ClientImpl) {
-		c.client.SetRetryCount(count).
-			SetRetryWaitTime(waitTime).
-			SetRetryMaxWaitTime(waitTime * 2)
+		c.middlewares = append(c.middlewares, mws...)
 	}
 }
 
+type idempotencyKeyContextKey struct{}
+
+// ContextWithIdempotencyKey 该 ctx 下的 Route 请求携带 Idempotency-Key 头, RetryMiddleware 会重试这些请求 (包括 POST);
+// 只在服务端按该 key 去重的接口上使用
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
 func (c *web3ClientImpl) DoRequest(ctx context.Context, method, path string, query string, body interface{}, result interface{}) (err error) {
-	// 准备请求
-	req := c.client.R().
-		SetContext(ctx).
-		SetResult(result)
+	req := &Web3Request{
+		Method: method,
+		Path:   path,
+		Query:  query,
+	}
+	if key, _ := ctx.Value(idempotencyKeyContextKey{}).(string); key != "" {
+		req.Header = http.Header{HeaderIdempotencyKey: []string{key}}
+	}
 	if body != nil {
-		req.SetBody(body)
+		req.Body, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal body: %w", err)
+		}
+	}
+
+	resp, err := chainWeb3Middlewares(c.send, c.middlewares)(ctx, req)
+	if err != nil {
+		return err
 	}
 
-	url := path
-	if len(query) > 0 {
-		url = fmt.Sprintf("%s?%s", path, query)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || resp.StatusCode == http.StatusAccepted {
+		return newMixinOracleAPIError(resp.StatusCode, resp.Body)
 	}
 
-	var bodyBytes []byte
-	if body != nil {
-		bodyBytes, err = json.Marshal(body)
-		if err != nil {
-			return fmt.Errorf("marshal body for signing: %w", err)
+	if result != nil && len(resp.Body) > 0 {
+		if err := json.Unmarshal(resp.Body, result); err != nil {
+			return fmt.Errorf("decode response: %w", err)
 		}
 	}
+	return nil
+}
+
+// signedURI 返回实际发送的路径和 query, 包括 baseURL 中的路径前缀
+func (c *web3ClientImpl) signedURI(req *Web3Request) string {
+	uri := req.URI()
+	if u, err := url.Parse(c.baseURL); err == nil {
+		uri = strings.TrimSuffix(u.Path, "/") + uri
+	}
+	return uri
+}
+
+func (c *web3ClientImpl) sign(ctx context.Context, ts int64, req *Web3Request) (string, error) {
+	uri := c.signedURI(req)
+	if c.signer != nil {
+		return c.signer.Sign(ctx, ts, req.Method, uri, req.Body)
+	}
 
-	ts := time.Now().Unix()
-	httpReq, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(bodyBytes))
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, uri, bytes.NewReader(req.Body))
 	if err != nil {
-		return fmt.Errorf("create request for signing: %w", err)
+		return "", fmt.Errorf("create request for signing: %w", err)
 	}
 	httpReq.Header.Set(HeaderContentType, ContentTypeJSON)
+	return c.botClient.SignRequest(ctx, ts, c.clientID, httpReq)
+}
 
-	signature, err := c.botClient.SignRequest(ctx, ts, c.clientID, httpReq)
+// send 对请求签名并发送, 每次调用 (包括重试) 都使用新的时间戳
+func (c *web3ClientImpl) send(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+	ts := time.Now().Unix()
+	signature, err := c.sign(ctx, ts, req)
 	if err != nil {
-		return fmt.Errorf("sign request: %w", err)
+		return nil, fmt.Errorf("sign request: %w", err)
+	}
+
+	r := c.client.R().
+		SetContext(ctx).
+		SetHeaders(map[string]string{
+			HeaderAccessTimestamp: strconv.FormatInt(ts, 10),
+			HeaderAccessSign:      signature,
+		})
+	for k, vs := range req.Header {
+		for _, v := range vs {
+			r.Header.Add(k, v)
+		}
+	}
+	if req.Body != nil {
+		// 发送签名时使用的同一份字节
+		r.SetBody(req.Body)
 	}
 
-	req.SetHeaders(map[string]string{
-		HeaderAccessTimestamp: fmt.Sprintf("%d", ts),
-		HeaderAccessSign:      signature,
-	})
-	resp, err := req.Execute(method, url)
+	resp, err := r.Execute(req.Method, req.URI())
 	if err != nil {
-		return fmt.Errorf("execute request: %w", err)
+		return nil, fmt.Errorf("execute request: %w", err)
 	}
 
-	if !resp.IsSuccess() || resp.StatusCode() == http.StatusAccepted {
-		var errResp ErrorResponse
-		if err := json.Unmarshal(resp.Body(), &errResp); err != nil {
-			return &MixinOracleAPIError{
-				StatusCode:  resp.StatusCode(),
-				Description: resp.String(),
-				RawBody:     resp.String(),
-			}
-		}
+	return &Web3RawResponse{
+		StatusCode: resp.StatusCode(),
+		Header:     resp.Header(),
+		Body:       resp.Body(),
+	}, nil
+}
 
+func newMixinOracleAPIError(statusCode int, body []byte) error {
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err != nil {
 		return &MixinOracleAPIError{
-			StatusCode:  resp.StatusCode(),
-			Code:        errResp.Error.Code,
-			Description: errResp.Error.Description,
-			RawBody:     resp.String(),
+			StatusCode:  statusCode,
+			Description: string(body),
+			RawBody:     string(body),
 		}
 	}
-	return nil
+
+	return &MixinOracleAPIError{
+		StatusCode:  statusCode,
+		Code:        errResp.Error.Code,
+		Description: errResp.Error.Description,
+		RawBody:     string(body),
+	}
 }
 
 func (c *web3ClientImpl) Get(ctx context.Context, path string, query string, result interface{}) error {
@@ -0,0 +1,335 @@
+package kit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Web3Request 发往 Mixin Route 的一次请求, Body 为已经序列化好的 JSON
+type Web3Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   []byte
+	Header http.Header
+
+	// Attempt 当前是第几次发送, 从 0 开始, 由 RetryMiddleware 维护
+	Attempt int
+}
+
+// URI 返回带 query 的请求路径, 如 /web3/quote?inputMint=...
+func (r *Web3Request) URI() string {
+	if len(r.Query) > 0 {
+		return r.Path + "?" + r.Query
+	}
+	return r.Path
+}
+
+// idempotent GET 或带 Idempotency-Key 头的请求可以安全地重复发送
+func (r *Web3Request) idempotent() bool {
+	return r.Method == http.MethodGet || r.Header.Get(HeaderIdempotencyKey) != ""
+}
+
+type Web3RawResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type Web3Handler func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error)
+
+// Web3Middleware 包装 Web3Handler, 最内层的 handler 负责签名和发送
+type Web3Middleware func(next Web3Handler) Web3Handler
+
+func chainWeb3Middlewares(h Web3Handler, mws []Web3Middleware) Web3Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+func web3SleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// LoggingMiddleware 使用 slog 记录每次请求的方法、路径、状态码和耗时
+func LoggingMiddleware(logger *slog.Logger) Web3Middleware {
+	return func(next Web3Handler) Web3Handler {
+		return func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+
+			attrs := []any{
+				slog.String("method", req.Method),
+				slog.String("path", req.Path),
+				slog.Int("attempt", req.Attempt),
+				slog.Duration("latency", time.Since(start)),
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, "web3 request", append(attrs, slog.Any("error", err))...)
+				return resp, err
+			}
+
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			if resp.StatusCode >= http.StatusBadRequest {
+				logger.WarnContext(ctx, "web3 request", attrs...)
+			} else {
+				logger.DebugContext(ctx, "web3 request", attrs...)
+			}
+			return resp, nil
+		}
+	}
+}
+
+// DefaultLatencyBuckets 默认的耗时分桶上界
+var DefaultLatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// LatencyHistogram 并发安全的请求耗时直方图
+type LatencyHistogram struct {
+	buckets []time.Duration
+
+	mu     sync.Mutex
+	counts []uint64 // 最后一个为 +Inf
+	count  uint64
+	errors uint64
+	sum    time.Duration
+}
+
+type LatencyHistogramSnapshot struct {
+	Buckets []time.Duration
+	Counts  []uint64 // 每个桶的计数 (非累计), 最后一个为 +Inf
+	Count   uint64
+	Errors  uint64 // 网络错误或状态码 >= 400 的请求数
+	Sum     time.Duration
+}
+
+// NewLatencyHistogram 使用给定的分桶上界创建直方图, buckets 为空时使用 DefaultLatencyBuckets
+func NewLatencyHistogram(buckets ...time.Duration) *LatencyHistogram {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]time.Duration(nil), buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+
+	return &LatencyHistogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+func (h *LatencyHistogram) Observe(d time.Duration, failed bool) {
+	i := sort.Search(len(h.buckets), func(i int) bool { return d <= h.buckets[i] })
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts[i]++
+	h.count++
+	h.sum += d
+	if failed {
+		h.errors++
+	}
+}
+
+func (h *LatencyHistogram) Snapshot() LatencyHistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return LatencyHistogramSnapshot{
+		Buckets: append([]time.Duration(nil), h.buckets...),
+		Counts:  append([]uint64(nil), h.counts...),
+		Count:   h.count,
+		Errors:  h.errors,
+		Sum:     h.sum,
+	}
+}
+
+// MetricsMiddleware 将每次请求的耗时记录到 h
+func MetricsMiddleware(h *LatencyHistogram) Web3Middleware {
+	return func(next Web3Handler) Web3Handler {
+		return func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			h.Observe(time.Since(start), err != nil || resp.StatusCode >= http.StatusBadRequest)
+			return resp, err
+		}
+	}
+}
+
+// TokenBucket 令牌桶限流器, 每秒补充 rate 个令牌, 最多累积 burst 个
+type TokenBucket struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		now:    time.Now,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve 取走一个令牌, 返回需要等待的时长
+func (b *TokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// unreserve 归还 reserve 取走的令牌
+func (b *TokenBucket) unreserve() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// Wait 阻塞直到取得一个令牌或 ctx 结束, ctx 结束时不消耗令牌
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := web3SleepContext(ctx, b.reserve()); err != nil {
+		b.unreserve()
+		return err
+	}
+	return nil
+}
+
+// RateLimitMiddleware 客户端限流, 每秒最多 rate 个请求, 允许 burst 个突发
+func RateLimitMiddleware(rate float64, burst int) Web3Middleware {
+	bucket := NewTokenBucket(rate, burst)
+	return func(next Web3Handler) Web3Handler {
+		return func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+			if err := bucket.Wait(ctx); err != nil {
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// RetryMiddleware 网络错误或 5xx 时最多重试 count 次, 第 n 次重试前等待 n*waitTime.
+// 只重试 GET 和带 Idempotency-Key 头的请求, 其他请求 (如 POST /web3/swap) 可能已经被服务端处理, 不重试
+func RetryMiddleware(count int, waitTime time.Duration) Web3Middleware {
+	return func(next Web3Handler) Web3Handler {
+		return func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+			if !req.idempotent() {
+				return next(ctx, req)
+			}
+			for attempt := 0; ; attempt++ {
+				r := *req
+				r.Attempt = req.Attempt + attempt
+
+				resp, err := next(ctx, &r)
+				retryable := err != nil || resp.StatusCode >= http.StatusInternalServerError
+				if !retryable || attempt >= count || ctx.Err() != nil {
+					return resp, err
+				}
+
+				if err := web3SleepContext(ctx, waitTime*time.Duration(attempt+1)); err != nil {
+					return resp, err
+				}
+			}
+		}
+	}
+}
+
+// RetryAfterMiddleware 收到 429 时按 Retry-After 等待后重试, 最多 maxRetries 次, 单次等待不超过 maxWait
+func RetryAfterMiddleware(maxRetries int, maxWait time.Duration) Web3Middleware {
+	return func(next Web3Handler) Web3Handler {
+		return func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+			for attempt := 0; ; attempt++ {
+				r := *req
+				r.Attempt = req.Attempt + attempt
+
+				resp, err := next(ctx, &r)
+				if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= maxRetries {
+					return resp, err
+				}
+
+				wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+				if !ok {
+					wait = time.Second << attempt
+				}
+				if wait > maxWait {
+					wait = maxWait
+				}
+
+				if err := web3SleepContext(ctx, wait); err != nil {
+					return resp, err
+				}
+			}
+		}
+	}
+}
+
+// parseRetryAfter 解析 Retry-After, 支持秒数和 HTTP 日期两种格式
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
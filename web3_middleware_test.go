@@ -0,0 +1,156 @@
+package kit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryMiddleware(t *testing.T) {
+	var attempts []int
+	h := func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+		attempts = append(attempts, req.Attempt)
+		if len(attempts) < 3 {
+			return &Web3RawResponse{StatusCode: http.StatusBadGateway}, nil
+		}
+		return &Web3RawResponse{StatusCode: http.StatusOK}, nil
+	}
+
+	resp, err := chainWeb3Middlewares(h, []Web3Middleware{RetryMiddleware(3, time.Millisecond)})(context.Background(), &Web3Request{Method: http.MethodGet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if len(attempts) != 3 || attempts[0] != 0 || attempts[2] != 2 {
+		t.Errorf("attempts = %v, want [0 1 2]", attempts)
+	}
+
+	// 4xx 不重试
+	calls := 0
+	h = func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+		calls++
+		return &Web3RawResponse{StatusCode: http.StatusBadRequest}, nil
+	}
+	_, _ = chainWeb3Middlewares(h, []Web3Middleware{RetryMiddleware(3, time.Millisecond)})(context.Background(), &Web3Request{Method: http.MethodGet})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+
+	// 只重试 GET 和带 Idempotency-Key 的请求
+	h = func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+		calls++
+		return &Web3RawResponse{StatusCode: http.StatusBadGateway}, nil
+	}
+	tests := []struct {
+		name  string
+		req   *Web3Request
+		calls int
+	}{
+		{"post", &Web3Request{Method: http.MethodPost}, 1},
+		{"post with key", &Web3Request{Method: http.MethodPost, Header: http.Header{HeaderIdempotencyKey: []string{"key"}}}, 3},
+		{"get", &Web3Request{Method: http.MethodGet}, 3},
+	}
+	for _, tt := range tests {
+		calls = 0
+		_, _ = chainWeb3Middlewares(h, []Web3Middleware{RetryMiddleware(2, time.Millisecond)})(context.Background(), tt.req)
+		if calls != tt.calls {
+			t.Errorf("%s: calls = %d, want %d", tt.name, calls, tt.calls)
+		}
+	}
+}
+
+func TestRetryAfterMiddleware(t *testing.T) {
+	calls := 0
+	h := func(ctx context.Context, req *Web3Request) (*Web3RawResponse, error) {
+		calls++
+		if calls == 1 {
+			return &Web3RawResponse{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": []string{"0"}},
+			}, nil
+		}
+		return &Web3RawResponse{StatusCode: http.StatusOK}, nil
+	}
+
+	resp, err := chainWeb3Middlewares(h, []Web3Middleware{RetryAfterMiddleware(1, time.Second)})(context.Background(), &Web3Request{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || calls != 2 {
+		t.Errorf("status = %d, calls = %d", resp.StatusCode, calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "3", want: 3 * time.Second, wantOK: true},
+		{value: now.Add(5 * time.Second).Format(http.TimeFormat), want: 5 * time.Second, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := NewTokenBucket(2, 2)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	if d := b.reserve(); d != 0 {
+		t.Errorf("1st reserve = %s, want 0", d)
+	}
+	if d := b.reserve(); d != 0 {
+		t.Errorf("2nd reserve = %s, want 0", d)
+	}
+	if d := b.reserve(); d != 500*time.Millisecond {
+		t.Errorf("3rd reserve = %s, want 500ms", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want %v", err, context.Canceled)
+	}
+	// 已经取消的 Wait 不消耗令牌
+	now = now.Add(time.Second)
+	if d := b.reserve(); d != 0 {
+		t.Errorf("reserve after cancelled Wait = %s, want 0", d)
+	}
+
+	// 等待中取消时归还令牌
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := b.reserve(); d != 500*time.Millisecond {
+		t.Errorf("reserve after Wait cancelled while sleeping = %s, want 500ms", d)
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	h := NewLatencyHistogram(100*time.Millisecond, time.Second)
+	h.Observe(50*time.Millisecond, false)
+	h.Observe(500*time.Millisecond, true)
+	h.Observe(2*time.Second, false)
+
+	s := h.Snapshot()
+	if s.Count != 3 || s.Errors != 1 || s.Counts[0] != 1 || s.Counts[1] != 1 || s.Counts[2] != 1 {
+		t.Errorf("Snapshot() = %+v", s)
+	}
+}